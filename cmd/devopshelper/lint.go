@@ -0,0 +1,224 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/microsoft/azure-devops-go-api/azuredevops"
+	adocore "github.com/microsoft/azure-devops-go-api/azuredevops/core"
+	adogit "github.com/microsoft/azure-devops-go-api/azuredevops/git"
+	adoidentity "github.com/microsoft/azure-devops-go-api/azuredevops/identity"
+
+	"github.com/samkreter/devopshelper/pkg/autoreviewer"
+)
+
+const (
+	formatText  = "text"
+	formatJSON  = "json"
+	formatSARIF = "sarif"
+)
+
+// runLintOwners lints the owners files of a local checkout or an ADO repository, exits 1 when issues are found
+func runLintOwners(ctx context.Context, args []string) int {
+	flags := flag.NewFlagSet("lint-owners", flag.ExitOnError)
+	path := flags.String("path", ".", "local checkout to lint, ignored when repo is set")
+	repoName := flags.String("repo", "", "ADO repository to lint instead of a local checkout")
+	project := flags.String("project", "", "ADO project of the repository, enables the team checks")
+	patToken := flags.String("pat-token", "", "vsts personal access token, enables the alias checks")
+	organizationUrl := flags.String("organizationUrl", "https://msazure.visualstudio.com", "vsts instance")
+	format := flags.String("format", formatText, "output format: text, json or sarif")
+	flags.Parse(args)
+
+	if *format != formatText && *format != formatJSON && *format != formatSARIF {
+		fmt.Fprintf(os.Stderr, "unknown format: %q\n", *format)
+		return 2
+	}
+	if *repoName != "" && (*project == "" || *patToken == "") {
+		fmt.Fprintln(os.Stderr, "project and pat-token are required to lint an ADO repository")
+		return 2
+	}
+
+	linter := &autoreviewer.OwnersLinter{}
+	var tree *autoreviewer.OwnersTree
+	var err error
+
+	if *patToken != "" {
+		conn := azuredevops.NewPatConnection(*organizationUrl, *patToken)
+
+		adoIdentityClient, err := adoidentity.NewClient(ctx, conn)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+		linter.KnownAlias = autoreviewer.NewADOAliasChecker(adoIdentityClient)
+
+		if *project != "" {
+			adoCoreClient, err := adocore.NewClient(ctx, conn)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				return 2
+			}
+			linter.KnownTeam = autoreviewer.NewADOTeamChecker(adoCoreClient, *project)
+		}
+
+		if *repoName != "" {
+			tree, err = loadADOTree(ctx, conn, *project, *repoName)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				return 2
+			}
+		}
+	}
+
+	if tree == nil {
+		tree, err = autoreviewer.LoadLocalOwnersTree(*path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+	}
+
+	issues, err := linter.Lint(ctx, tree)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	if err := writeIssues(os.Stdout, *format, issues); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	if len(issues) > 0 {
+		return 1
+	}
+	return 0
+}
+
+func loadADOTree(ctx context.Context, conn *azuredevops.Connection, project, repoName string) (*autoreviewer.OwnersTree, error) {
+	adoGitClient, err := adogit.NewClient(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	repo, err := adoGitClient.GetRepository(ctx, adogit.GetRepositoryArgs{
+		RepositoryId: &repoName,
+		Project:      &project,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get repository %s: %w", repoName, err)
+	}
+
+	return autoreviewer.LoadADOOwnersTree(ctx, adoGitClient, repo.Id.String())
+}
+
+func writeIssues(w io.Writer, format string, issues []autoreviewer.LintIssue) error {
+	switch format {
+	case formatJSON:
+		if issues == nil {
+			issues = []autoreviewer.LintIssue{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(issues)
+
+	case formatSARIF:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(newSARIFLog(issues))
+
+	default:
+		for _, issue := range issues {
+			location := issue.Path
+			if issue.Line > 0 {
+				location = fmt.Sprintf("%s:%d", issue.Path, issue.Line)
+			}
+			if _, err := fmt.Fprintf(w, "%s: %s (%s)\n", location, issue.Message, issue.Rule); err != nil {
+				return err
+			}
+		}
+		_, err := fmt.Fprintf(w, "%d issues found\n", len(issues))
+		return err
+	}
+}
+
+// sarifLog is the subset of the SARIF 2.1.0 format needed to report lint issues
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name string `json:"name"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+func newSARIFLog(issues []autoreviewer.LintIssue) sarifLog {
+	results := make([]sarifResult, 0, len(issues))
+	for _, issue := range issues {
+		location := sarifPhysicalLocation{
+			ArtifactLocation: sarifArtifactLocation{URI: strings.TrimPrefix(issue.Path, "/")},
+		}
+		if issue.Line > 0 {
+			location.Region = &sarifRegion{StartLine: issue.Line}
+		}
+
+		results = append(results, sarifResult{
+			RuleID:    issue.Rule,
+			Level:     "error",
+			Message:   sarifMessage{Text: issue.Message},
+			Locations: []sarifLocation{{PhysicalLocation: location}},
+		})
+	}
+
+	return sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs: []sarifRun{{
+			Tool:    sarifTool{Driver: sarifDriver{Name: "devopshelper lint-owners"}},
+			Results: results,
+		}},
+	}
+}
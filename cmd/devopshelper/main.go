@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// command is a devopshelper subcommand, it returns the process exit code
+type command struct {
+	description string
+	run         func(ctx context.Context, args []string) int
+}
+
+var commands = map[string]command{
+	"lint-owners": {
+		description: "check owners files for unknown aliases and teams, duplicates, empty files and unowned directories",
+		run:         runLintOwners,
+	},
+	"who-would-review": {
+		description: "show who would be assigned to review changes in a local checkout, without changing anything",
+		run:         runWhoWouldReview,
+	},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command: %q\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+
+	os.Exit(cmd.run(context.Background(), os.Args[2:]))
+}
+
+func usage() {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(os.Stderr, "usage: devopshelper <command> [flags]")
+	fmt.Fprintln(os.Stderr, "\ncommands:")
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-16s %s\n", name, commands[name].description)
+	}
+}
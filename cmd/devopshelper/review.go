@@ -0,0 +1,287 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/samkreter/devopshelper/pkg/autoreviewer"
+	"github.com/samkreter/devopshelper/pkg/store/memory"
+	"github.com/samkreter/devopshelper/pkg/types"
+)
+
+// runWhoWouldReview prints the reviewers that would be selected for changes in a local checkout. The store
+// snapshot is loaded into memory, so nothing is changed in ADO or the live store.
+func runWhoWouldReview(ctx context.Context, args []string) int {
+	flags := flag.NewFlagSet("who-would-review", flag.ExitOnError)
+	path := flags.String("path", ".", "local checkout to resolve the owners files from")
+	diffFile := flags.String("diff", "", "unified diff of the changes, - reads from stdin")
+	base := flags.String("base", "", "git ref to diff the working tree against for the changed files")
+	author := flags.String("author", "", "alias of the changes' author, defaults to the git user")
+	reviewersFile := flags.String("reviewers", "", "JSON reviewers snapshot, as returned by GET /api/reviewers")
+	teamsFile := flags.String("teams", "", "JSON teams snapshot, as returned by GET /api/teams")
+	repoFile := flags.String("repository", "", "JSON repository settings, as returned by GET /api/repositories/{id}")
+	format := flags.String("format", formatText, "output format: text or json")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: devopshelper who-would-review [flags] [changed files...]")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if *format != formatText && *format != formatJSON {
+		fmt.Fprintf(os.Stderr, "unknown format: %q\n", *format)
+		return 2
+	}
+
+	changePaths, err := getChangePaths(ctx, *path, *diffFile, *base, flags.Args())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	if len(changePaths) == 0 {
+		fmt.Fprintln(os.Stderr, "no changed files, pass files, --diff or --base")
+		return 2
+	}
+
+	repo := &types.Repository{}
+	if *repoFile != "" {
+		if err := readJSONFile(*repoFile, repo); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+	}
+
+	snapshot, err := loadSnapshot(ctx, *reviewersFile, *teamsFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	tree, err := autoreviewer.LoadLocalOwnersTree(*path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	if *author == "" {
+		*author = gitUserAlias(ctx, *path)
+	}
+
+	simulation := &autoreviewer.Simulation{
+		Repo:          repo,
+		ReviewerStore: snapshot,
+		TeamStore:     snapshot,
+		Tree:          tree,
+		Author:        *author,
+	}
+
+	if repo.ExpertiseWeight > 0 {
+		simulation.Expertise, err = autoreviewer.GetLocalExpertise(ctx, *path, changePaths, time.Now().UTC())
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+	}
+
+	selection, err := simulation.Run(ctx, changePaths)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	if err := writeSelection(os.Stdout, *format, selection); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	return 0
+}
+
+// getChangePaths gets the changed files from the args, a diff or the git working tree. Paths have a leading slash.
+func getChangePaths(ctx context.Context, root, diffFile, base string, args []string) ([]string, error) {
+	paths := append([]string{}, args...)
+
+	if diffFile != "" {
+		var r io.Reader = os.Stdin
+		if diffFile != "-" {
+			f, err := os.Open(diffFile)
+			if err != nil {
+				return nil, err
+			}
+			defer f.Close()
+			r = f
+		}
+
+		diffPaths, err := parseDiffPaths(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read diff: %w", err)
+		}
+		paths = append(paths, diffPaths...)
+	}
+
+	if base != "" {
+		cmd := exec.CommandContext(ctx, "git", "diff", "--name-only", base)
+		cmd.Dir = root
+		out, err := cmd.Output()
+		if err != nil {
+			return nil, fmt.Errorf("failed to diff against %s: %w", base, err)
+		}
+		paths = append(paths, strings.Fields(string(out))...)
+	}
+
+	seen := map[string]bool{}
+	changePaths := make([]string, 0, len(paths))
+	for _, path := range paths {
+		path = "/" + strings.TrimPrefix(path, "/")
+		if !seen[path] {
+			seen[path] = true
+			changePaths = append(changePaths, path)
+		}
+	}
+
+	return changePaths, nil
+}
+
+// parseDiffPaths gets the old and new paths of each file in a unified diff
+func parseDiffPaths(r io.Reader) ([]string, error) {
+	var paths []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "--- ") && !strings.HasPrefix(line, "+++ ") {
+			continue
+		}
+
+		path := strings.TrimSpace(line[4:])
+		if idx := strings.Index(path, "\t"); idx >= 0 {
+			path = path[:idx]
+		}
+		if path == "/dev/null" {
+			continue
+		}
+		if strings.HasPrefix(path, "a/") || strings.HasPrefix(path, "b/") {
+			path = path[2:]
+		}
+		paths = append(paths, path)
+	}
+
+	return paths, scanner.Err()
+}
+
+// loadSnapshot loads the reviewers and teams into a memory store
+func loadSnapshot(ctx context.Context, reviewersFile, teamsFile string) (*memory.Store, error) {
+	snapshot := memory.NewStore()
+
+	if reviewersFile != "" {
+		var reviewers []*types.Reviewer
+		if err := readJSONFile(reviewersFile, &reviewers); err != nil {
+			return nil, err
+		}
+		for _, reviewer := range reviewers {
+			if err := snapshot.AddReviewer(ctx, reviewer); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if teamsFile != "" {
+		var teams []*types.Team
+		if err := readJSONFile(teamsFile, &teams); err != nil {
+			return nil, err
+		}
+		for _, team := range teams {
+			if err := snapshot.AddTeam(ctx, team); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return snapshot, nil
+}
+
+func readJSONFile(path string, v interface{}) error {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(content, v); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return nil
+}
+
+// gitUserAlias gets the alias from the git user's email, empty when it isn't set
+func gitUserAlias(ctx context.Context, root string) string {
+	cmd := exec.CommandContext(ctx, "git", "config", "user.email")
+	cmd.Dir = root
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+
+	email := strings.TrimSpace(string(out))
+	if idx := strings.Index(email, "@"); idx > 0 {
+		return email[:idx]
+	}
+	return ""
+}
+
+// selectedReviewer is a reviewer pick in the json output
+type selectedReviewer struct {
+	Alias    string `json:"alias"`
+	Reason   string `json:"reason"`
+	NoNotify bool   `json:"noNotify,omitempty"`
+}
+
+func writeSelection(w io.Writer, format string, selection *autoreviewer.ReviewerSelection) error {
+	toSelected := func(reviewers []*types.Reviewer) []selectedReviewer {
+		selected := make([]selectedReviewer, 0, len(reviewers))
+		for _, reviewer := range reviewers {
+			selected = append(selected, selectedReviewer{
+				Alias:    reviewer.Alias,
+				Reason:   selection.Reasons[reviewer.Alias],
+				NoNotify: selection.NoNotify[reviewer.Alias],
+			})
+		}
+		sort.SliceStable(selected, func(i, j int) bool { return selected[i].Alias < selected[j].Alias })
+		return selected
+	}
+
+	required := toSelected(selection.Required)
+	optional := toSelected(selection.Optional)
+
+	if format == formatJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(map[string][]selectedReviewer{
+			"required": required,
+			"optional": optional,
+		})
+	}
+
+	for _, group := range []struct {
+		name      string
+		reviewers []selectedReviewer
+	}{
+		{name: "Required", reviewers: required},
+		{name: "Optional", reviewers: optional},
+	} {
+		fmt.Fprintf(w, "%s reviewers:\n", group.name)
+		if len(group.reviewers) == 0 {
+			fmt.Fprintln(w, "  (none)")
+		}
+		for _, reviewer := range group.reviewers {
+			fmt.Fprintf(w, "  - %s: %s\n", reviewer.Alias, reviewer.Reason)
+		}
+	}
+	return nil
+}
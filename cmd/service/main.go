@@ -14,13 +14,20 @@ import (
 	adocore "github.com/microsoft/azure-devops-go-api/azuredevops/core"
 	adoidentity "github.com/microsoft/azure-devops-go-api/azuredevops/identity"
 
+	"github.com/samkreter/devopshelper/pkg/auth"
 	"github.com/samkreter/devopshelper/pkg/autoreviewer"
 	"github.com/samkreter/devopshelper/pkg/server"
 	"github.com/samkreter/devopshelper/pkg/store"
+	"github.com/samkreter/devopshelper/pkg/store/bolt"
+	"github.com/samkreter/devopshelper/pkg/store/memory"
 )
 
 const (
 	defaultReviewerIntervalMin = 5
+
+	storeTypeMongo  = "mongo"
+	storeTypeMemory = "memory"
+	storeTypeBolt   = "bolt"
 )
 
 var (
@@ -30,29 +37,52 @@ var (
 	botIdentifier string
 	organizationUrl string
 	logLvl         string
+	storeType      string
+	boltPath       string
+	authJWKS       string
+	authIssuers    string
+	authAudiences  string
+	authGraphFallback bool
+	authCacheTTL   time.Duration
 	mongoOptions   = &store.MongoStoreOptions{}
 	serverOptions  = &server.Options{}
+	reviewerOptions = autoreviewer.Options{}
 )
 
 func main() {
 	flag.StringVar(&serverOptions.Addr, "addr", "localhost:8080", "the address for the api server to listen on.")
-	flag.StringVar(&adminsStr, "admins", "", "admins to be added to each repo, comma seperated")
+	flag.StringVar(&adminsStr, "admins", "", "admins to be added to each repo by user principal name or object id, comma seperated, mail addresses are not matched")
 	flag.BoolVar(&serverOptions.AllowCORS, "enable-cors", true, "enable cors for the api server.")
 
 	flag.StringVar(&logLvl, "log-level", "info", "the log level for the application")
 
+	flag.StringVar(&authJWKS, "auth-jwks", "", "JWKS file path or url used to validate bearer tokens locally, uses graph when empty")
+	flag.StringVar(&authIssuers, "auth-issuers", "", "trusted token issuers, comma seperated")
+	flag.StringVar(&authAudiences, "auth-audiences", "", "trusted token audiences, comma seperated")
+	flag.BoolVar(&authGraphFallback, "auth-graph-fallback", false, "fallback to microsoft graph when local token validation fails")
+	flag.DurationVar(&authCacheTTL, "auth-cache-ttl", auth.DefaultCacheTTL, "how long authenticated tokens are cached")
+
+	flag.BoolVar(&reviewerOptions.DryRun, "dry-run", false, "preview reviewers for every repository without adding them or changing the rotation")
+	flag.BoolVar(&reviewerOptions.DryRunPreviewComment, "dry-run-preview-comment", false, "post a preview comment with the would be reviewers in dry run mode")
+
+	flag.StringVar(&serverOptions.WebhookSecret, "webhook-secret", "", "shared secret for azure devops service hooks, enables event driven reviewer assignment")
+
 	flag.StringVar(&adoPatToken, "pat-token", "", "vsts personal access token")
 	flag.StringVar(&botIdentifier, "botmaker-id", "b03f5f7f11d50a3a", "identifier for the bot's message")
 	flag.StringVar(&organizationUrl, "organizationUrl", "https://msazure.visualstudio.com", "vsts instance")
 
+	flag.StringVar(&storeType, "store", storeTypeMongo, "the store backend to use: mongo, bolt or memory")
+	flag.StringVar(&boltPath, "bolt-path", "devopshelper.db", "file used by the bolt store")
 	flag.StringVar(&mongoOptions.MongoURI, "mongo-uri", "", "connection string for the mongo database")
 	flag.StringVar(&mongoOptions.RepositoryCollection, "mongo-repo-collection", "", "collection that stores the repositories")
 	flag.StringVar(&mongoOptions.TeamCollection, "mongo-team-collection", "", "collection that stores the teams")
 	flag.StringVar(&mongoOptions.ReviewerCollection, "mongo-reviewer-collection", "", "collection that stores the reviewers")
+	flag.StringVar(&mongoOptions.AssignmentCollection, "mongo-assignment-collection", "", "collection that stores the pull request assignments")
 	flag.StringVar(&mongoOptions.DBName, "mongo-dbname", "reviewerBot", "the mongo database to access")
 	flag.BoolVar(&mongoOptions.UseSSL, "mongo-ssl", false, "use ssl when accessing mongo database")
+	flag.BoolVar(&mongoOptions.SkipMigrations, "mongo-skip-migrations", false, "skip applying schema migrations and indexes on startup")
 
-	reviewIntervalMin := flag.Int("review-interval", defaultReviewerIntervalMin, "number of minutes to wait to reviwer")
+	reviewIntervalMin := flag.Int("review-interval", defaultReviewerIntervalMin, "number of minutes to wait to reviwer, can be raised when service hooks are enabled")
 	flag.Parse()
 
 	ctx := context.Background()
@@ -64,10 +94,15 @@ func main() {
 	
 	serverOptions.Admins = strings.Split(adminsStr, ",")
 
-	mongoStore, err := store.NewMongoStore(mongoOptions)
+	authenticator, err := newAuthenticator(ctx)
 	if err != nil {
 		logger.Fatal(AddStack(err))
+	}
+	serverOptions.Authenticator = authenticator
 
+	dataStore, err := newStore()
+	if err != nil {
+		logger.Fatal(AddStack(err))
 	}
 
 	conn := azuredevops.NewPatConnection(organizationUrl, adoPatToken)
@@ -90,7 +125,7 @@ func main() {
 	go func() {
 		logger.Info("Starting Reviewer Reconcile Loop....")
 
-		mgr, err := autoreviewer.NewDefaultManager(ctx, mongoStore, mongoStore, mongoStore, adoGitClient, adoIdentityClient, adoCoreClient)
+		mgr, err := autoreviewer.NewDefaultManager(ctx, dataStore, dataStore, dataStore, dataStore, adoGitClient, adoIdentityClient, adoCoreClient, reviewerOptions)
 		if err != nil {
 			logger.Errorf("Failed to create reviewer manager: %s", err)
 			return
@@ -103,7 +138,7 @@ func main() {
 		for {
 			select {
 			case <-time.NewTicker(time.Minute * time.Duration(*reviewIntervalMin)).C:
-				mgr, err := autoreviewer.NewDefaultManager(ctx, mongoStore, mongoStore, mongoStore, adoGitClient, adoIdentityClient, adoCoreClient)
+				mgr, err := autoreviewer.NewDefaultManager(ctx, dataStore, dataStore, dataStore, dataStore, adoGitClient, adoIdentityClient, adoCoreClient, reviewerOptions)
 				if err != nil {
 					logger.Errorf("Failed to create reviewer manager: %s", err)
 					continue
@@ -120,7 +155,9 @@ func main() {
 		}
 	}()
 
-	s, err := server.NewServer(adoGitClient, adoIdentityClient, mongoStore, serverOptions)
+	serverOptions.PullRequestBalancer = autoreviewer.NewBalancer(dataStore, dataStore, dataStore, dataStore, adoGitClient, adoIdentityClient, adoCoreClient, reviewerOptions)
+
+	s, err := server.NewServer(adoGitClient, adoIdentityClient, dataStore, dataStore, dataStore, serverOptions)
 	if err != nil {
 		logger.Fatal(AddStack(err))
 	}
@@ -128,6 +165,66 @@ func main() {
 	s.Run()
 }
 
+// storeBackend is implemented by each of the store backends
+type storeBackend interface {
+	store.RepositoryStore
+	store.ReviewerStore
+	store.TeamStore
+	store.AssignmentStore
+}
+
+// newStore creates the store backend selected by the store flag
+func newStore() (storeBackend, error) {
+	switch storeType {
+	case storeTypeMongo:
+		return store.NewMongoStore(mongoOptions)
+	case storeTypeMemory:
+		return memory.NewStore(), nil
+	case storeTypeBolt:
+		return bolt.NewStore(boltPath)
+	default:
+		return nil, fmt.Errorf("unknown store type: %q", storeType)
+	}
+}
+
+// newAuthenticator creates the request authenticator from the auth flags
+func newAuthenticator(ctx context.Context) (auth.Authenticator, error) {
+	if authJWKS == "" {
+		return auth.NewCachingAuthenticator(auth.NewGraphAuthenticator(), authCacheTTL), nil
+	}
+
+	keySet, err := auth.NewKeySet(ctx, authJWKS)
+	if err != nil {
+		return nil, err
+	}
+
+	jwtAuthenticator, err := auth.NewJWTAuthenticator(keySet, auth.JWTOptions{
+		Issuers:   splitList(authIssuers),
+		Audiences: splitList(authAudiences),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	authenticators := auth.ChainAuthenticator{jwtAuthenticator}
+	if authGraphFallback {
+		authenticators = append(authenticators, auth.NewGraphAuthenticator())
+	}
+
+	return auth.NewCachingAuthenticator(authenticators, authCacheTTL), nil
+}
+
+func splitList(list string) []string {
+	var values []string
+	for _, value := range strings.Split(list, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+
+	return values
+}
+
 func AddStack(err error) error {
 	stack := getStackTrace(err)
 	if stack == "" {
@@ -0,0 +1,59 @@
+package auth
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/samkreter/devopshelper/pkg/types"
+)
+
+const (
+	bearerPrefix = "bearer "
+)
+
+var (
+	// ErrMissingToken the authorization header does not hold a bearer token
+	ErrMissingToken = errors.New("missing bearer token")
+)
+
+// Authenticator validates the authorization header of a request and returns the authenticated user
+type Authenticator interface {
+	Authenticate(ctx context.Context, authHeader string) (*types.GraphUser, error)
+}
+
+// ChainAuthenticator tries each authenticator in order and returns the first successful user
+type ChainAuthenticator []Authenticator
+
+// Authenticate implements Authenticator
+func (c ChainAuthenticator) Authenticate(ctx context.Context, authHeader string) (*types.GraphUser, error) {
+	if len(c) == 0 {
+		return nil, errors.New("no authenticators configured")
+	}
+
+	var errs []string
+	for _, authenticator := range c {
+		user, err := authenticator.Authenticate(ctx, authHeader)
+		if err == nil {
+			return user, nil
+		}
+		errs = append(errs, err.Error())
+	}
+
+	return nil, errors.Errorf("all authenticators failed: %s", strings.Join(errs, "; "))
+}
+
+// bearerToken returns the token from a "Bearer <token>" authorization header
+func bearerToken(authHeader string) (string, error) {
+	if len(authHeader) <= len(bearerPrefix) || !strings.EqualFold(authHeader[:len(bearerPrefix)], bearerPrefix) {
+		return "", errors.WithStack(ErrMissingToken)
+	}
+
+	token := strings.TrimSpace(authHeader[len(bearerPrefix):])
+	if token == "" {
+		return "", errors.WithStack(ErrMissingToken)
+	}
+
+	return token, nil
+}
@@ -0,0 +1,123 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/samkreter/devopshelper/pkg/types"
+)
+
+const (
+	// DefaultCacheTTL is how long an authenticated user is cached for
+	DefaultCacheTTL = time.Minute * 2
+
+	maxCacheEntries = 1024
+)
+
+type cacheEntry struct {
+	user    *types.GraphUser
+	expires time.Time
+}
+
+// CachingAuthenticator caches successful authentications keyed by the hash of the token
+type CachingAuthenticator struct {
+	next Authenticator
+	ttl  time.Duration
+	now  func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+// NewCachingAuthenticator wraps the authenticator with a short lived cache
+func NewCachingAuthenticator(next Authenticator, ttl time.Duration) *CachingAuthenticator {
+	if ttl <= 0 {
+		ttl = DefaultCacheTTL
+	}
+
+	return &CachingAuthenticator{
+		next:    next,
+		ttl:     ttl,
+		now:     time.Now,
+		entries: map[string]cacheEntry{},
+	}
+}
+
+// Authenticate implements Authenticator
+func (c *CachingAuthenticator) Authenticate(ctx context.Context, authHeader string) (*types.GraphUser, error) {
+	key := hashToken(authHeader)
+	now := c.now()
+
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+
+	if ok && now.Before(entry.expires) {
+		user := *entry.user
+		return &user, nil
+	}
+
+	user, err := c.next.Authenticate(ctx, authHeader)
+	if err != nil {
+		return nil, err
+	}
+
+	// Never cache past the token's own expiry
+	expires := now.Add(c.ttl)
+	if tokenExpiry, ok := unverifiedExpiry(authHeader); ok && tokenExpiry.Before(expires) {
+		expires = tokenExpiry
+	}
+
+	c.mu.Lock()
+	if len(c.entries) >= maxCacheEntries {
+		c.evictExpired(now)
+	}
+	if len(c.entries) < maxCacheEntries {
+		cached := *user
+		c.entries[key] = cacheEntry{user: &cached, expires: expires}
+	}
+	c.mu.Unlock()
+
+	return user, nil
+}
+
+// evictExpired removes expired entries, the lock must be held by the caller
+func (c *CachingAuthenticator) evictExpired(now time.Time) {
+	for key, entry := range c.entries {
+		if !now.Before(entry.expires) {
+			delete(c.entries, key)
+		}
+	}
+}
+
+func hashToken(authHeader string) string {
+	sum := sha256.Sum256([]byte(authHeader))
+	return hex.EncodeToString(sum[:])
+}
+
+// unverifiedExpiry reads the exp claim without validating the token. It is only used to
+// shorten how long a token that was already authenticated is cached.
+func unverifiedExpiry(authHeader string) (time.Time, bool) {
+	token, err := bearerToken(authHeader)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}, false
+	}
+
+	var claims struct {
+		ExpiresAt *int64 `json:"exp"`
+	}
+	if err := decodeSegment(parts[1], &claims); err != nil || claims.ExpiresAt == nil {
+		return time.Time{}, false
+	}
+
+	return time.Unix(*claims.ExpiresAt, 0), true
+}
@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/samkreter/devopshelper/pkg/types"
+)
+
+const (
+	// GraphURI uri to grab the currently logged in users identity
+	GraphURI = "https://graph.microsoft.com/v1.0/me"
+
+	defaultGraphTimeout = time.Second * 10
+)
+
+// GraphAuthenticator authenticates users by looking up the token's identity with Microsoft Graph
+type GraphAuthenticator struct {
+	URI    string
+	Client *http.Client
+}
+
+// NewGraphAuthenticator creates a new graph authenticator
+func NewGraphAuthenticator() *GraphAuthenticator {
+	return &GraphAuthenticator{
+		URI:    GraphURI,
+		Client: &http.Client{Timeout: defaultGraphTimeout},
+	}
+}
+
+// Authenticate implements Authenticator
+func (g *GraphAuthenticator) Authenticate(ctx context.Context, authHeader string) (*types.GraphUser, error) {
+	req, err := http.NewRequest("GET", g.URI, nil)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	req.Header.Add("Authorization", authHeader)
+
+	resp, err := g.Client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("graph returned non 200 status code: '%d'", resp.StatusCode)
+	}
+
+	var user types.GraphUser
+	if err := json.NewDecoder(resp.Body).Decode(&user); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return &user, nil
+}
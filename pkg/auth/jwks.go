@@ -0,0 +1,275 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/samkreter/go-core/log"
+)
+
+const (
+	defaultJWKSTimeout         = time.Second * 10
+	defaultJWKSRefreshInterval = time.Minute * 5
+
+	// minRSAKeyBits is the smallest RSA key accepted for signing
+	minRSAKeyBits = 2048
+)
+
+var (
+	// ErrKeyNotFound the signing key is not in the key set
+	ErrKeyNotFound = errors.New("signing key not found")
+
+	// errUnsupportedKey the key can't be used to verify tokens, it's skipped when parsing the key set
+	errUnsupportedKey = errors.New("unsupported key")
+)
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+type jsonWebKeySet struct {
+	Keys []jsonWebKey `json:"keys"`
+}
+
+// KeySet holds the public keys from a JWKS document loaded from a file or URL. Keys loaded
+// from a URL are refreshed when an unknown key id is requested.
+type KeySet struct {
+	source          string
+	client          *http.Client
+	refreshInterval time.Duration
+
+	mu          sync.RWMutex
+	keys        map[string]crypto.PublicKey
+	lastRefresh time.Time
+}
+
+// NewKeySet creates a key set from a JWKS file path or http(s) URL
+func NewKeySet(ctx context.Context, source string) (*KeySet, error) {
+	if source == "" {
+		return nil, errors.New("missing JWKS source")
+	}
+
+	ks := &KeySet{
+		source:          source,
+		client:          &http.Client{Timeout: defaultJWKSTimeout},
+		refreshInterval: defaultJWKSRefreshInterval,
+	}
+
+	if err := ks.refresh(ctx); err != nil {
+		return nil, err
+	}
+
+	return ks, nil
+}
+
+// Key returns the public key for the key id. An empty key id is only allowed when the set has a single key.
+func (ks *KeySet) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	if key, ok := ks.lookup(kid); ok {
+		return key, nil
+	}
+
+	// Keys may have been rotated, refresh remote key sets
+	if ks.isRemote() && ks.canRefresh() {
+		if err := ks.refresh(ctx); err != nil {
+			return nil, err
+		}
+
+		if key, ok := ks.lookup(kid); ok {
+			return key, nil
+		}
+	}
+
+	return nil, errors.Wrapf(ErrKeyNotFound, "kid: %q", kid)
+}
+
+func (ks *KeySet) lookup(kid string) (crypto.PublicKey, bool) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	if kid == "" && len(ks.keys) == 1 {
+		for _, key := range ks.keys {
+			return key, true
+		}
+	}
+
+	key, ok := ks.keys[kid]
+	return key, ok
+}
+
+func (ks *KeySet) canRefresh() bool {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	return time.Since(ks.lastRefresh) > ks.refreshInterval
+}
+
+func (ks *KeySet) isRemote() bool {
+	return strings.HasPrefix(ks.source, "http://") || strings.HasPrefix(ks.source, "https://")
+}
+
+func (ks *KeySet) refresh(ctx context.Context) error {
+	data, err := ks.read(ctx)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read JWKS from %q", ks.source)
+	}
+
+	keys, err := parseKeySet(data)
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse JWKS from %q", ks.source)
+	}
+
+	ks.mu.Lock()
+	ks.keys = keys
+	ks.lastRefresh = time.Now()
+	ks.mu.Unlock()
+
+	log.G(ctx).Infof("Loaded %d signing keys from %q", len(keys), ks.source)
+	return nil
+}
+
+func (ks *KeySet) read(ctx context.Context) ([]byte, error) {
+	if !ks.isRemote() {
+		return ioutil.ReadFile(ks.source)
+	}
+
+	req, err := http.NewRequest("GET", ks.source, nil)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	resp, err := ks.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("JWKS endpoint returned non 200 status code: '%d'", resp.StatusCode)
+	}
+
+	return ioutil.ReadAll(resp.Body)
+}
+
+// parseKeySet parses the RSA and EC signing keys from a JWKS document, keys with an unsupported type,
+// algorithm or size are skipped
+func parseKeySet(data []byte) (map[string]crypto.PublicKey, error) {
+	var jwks jsonWebKeySet
+	if err := json.Unmarshal(data, &jwks); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	keys := map[string]crypto.PublicKey{}
+	for _, jwk := range jwks.Keys {
+		// Skip keys only meant for encryption
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		// Skip the key types and algorithms that aren't supported, ex: OKP or oct keys
+		key, err := jwk.publicKey()
+		if errors.Is(err, errUnsupportedKey) {
+			continue
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid key %q", jwk.Kid)
+		}
+
+		keys[jwk.Kid] = key
+	}
+
+	if len(keys) == 0 {
+		return nil, errors.New("no signing keys found")
+	}
+
+	return keys, nil
+}
+
+func (jwk jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch jwk.Kty {
+	case "RSA":
+		n, err := decodeBigInt(jwk.N)
+		if err != nil {
+			return nil, err
+		}
+
+		e, err := decodeBigInt(jwk.E)
+		if err != nil {
+			return nil, err
+		}
+
+		if jwk.Alg != "" && !strings.HasPrefix(jwk.Alg, "RS") {
+			return nil, errors.Wrapf(errUnsupportedKey, "algorithm %q does not match RSA key", jwk.Alg)
+		}
+
+		if n.BitLen() < minRSAKeyBits {
+			return nil, errors.Wrapf(errUnsupportedKey, "RSA key is smaller than %d bits", minRSAKeyBits)
+		}
+
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		switch jwk.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, errors.Wrapf(errUnsupportedKey, "unsupported curve %q", jwk.Crv)
+		}
+
+		if jwk.Alg != "" && curveForAlgorithm(jwk.Alg) != jwk.Crv {
+			return nil, errors.Wrapf(errUnsupportedKey, "algorithm %q does not match curve %s", jwk.Alg, jwk.Crv)
+		}
+
+		x, err := decodeBigInt(jwk.X)
+		if err != nil {
+			return nil, err
+		}
+
+		y, err := decodeBigInt(jwk.Y)
+		if err != nil {
+			return nil, err
+		}
+
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("point is not on curve")
+		}
+
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+
+	default:
+		return nil, errors.Wrapf(errUnsupportedKey, "unsupported key type %q", jwk.Kty)
+	}
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return new(big.Int).SetBytes(data), nil
+}
@@ -0,0 +1,152 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseKeySet(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	smallRSAKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rsaJWK := func(kid, alg string, key *rsa.PrivateKey) map[string]string {
+		return map[string]string{
+			"kty": "RSA",
+			"kid": kid,
+			"alg": alg,
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}
+	}
+	ecJWK := func(kid, alg string) map[string]string {
+		return map[string]string{
+			"kty": "EC",
+			"kid": kid,
+			"alg": alg,
+			"crv": "P-256",
+			"x":   base64.RawURLEncoding.EncodeToString(ecKey.X.Bytes()),
+			"y":   base64.RawURLEncoding.EncodeToString(ecKey.Y.Bytes()),
+		}
+	}
+
+	tests := []struct {
+		Name         string
+		Keys         []map[string]string
+		ExpectedKids []string
+		ExpectError  bool
+	}{
+		{
+			Name:         "Supported Keys",
+			Keys:         []map[string]string{rsaJWK("rsa", "RS256", rsaKey), ecJWK("ec", "ES256"), ecJWK("ec-no-alg", "")},
+			ExpectedKids: []string{"ec", "ec-no-alg", "rsa"},
+		},
+		{
+			Name: "Unknown Key Types Skipped",
+			Keys: []map[string]string{
+				rsaJWK("rsa", "", rsaKey),
+				{"kty": "OKP", "kid": "okp", "crv": "Ed25519", "x": "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"},
+				{"kty": "oct", "kid": "oct", "k": "c2VjcmV0"},
+			},
+			ExpectedKids: []string{"rsa"},
+		},
+		{
+			Name:         "Mismatched Algorithms Skipped",
+			Keys:         []map[string]string{rsaJWK("rsa", "ES256", rsaKey), ecJWK("ec", "ES384"), ecJWK("ec-rsa", "RS256"), ecJWK("valid", "ES256")},
+			ExpectedKids: []string{"valid"},
+		},
+		{
+			Name:         "Small RSA Keys Skipped",
+			Keys:         []map[string]string{rsaJWK("small", "RS256", smallRSAKey), rsaJWK("rsa", "RS256", rsaKey)},
+			ExpectedKids: []string{"rsa"},
+		},
+		{
+			Name:        "No Supported Keys",
+			Keys:        []map[string]string{{"kty": "oct", "kid": "oct", "k": "c2VjcmV0"}},
+			ExpectError: true,
+		},
+		{
+			Name:        "Malformed Key",
+			Keys:        []map[string]string{{"kty": "EC", "kid": "ec", "crv": "P-256", "x": "AQ", "y": "AQ"}},
+			ExpectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			data, err := json.Marshal(map[string]interface{}{"keys": tt.Keys})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			keys, err := parseKeySet(data)
+			if tt.ExpectError {
+				assert.Error(t, err)
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			kids := []string{}
+			for kid := range keys {
+				kids = append(kids, kid)
+			}
+			sort.Strings(kids)
+			assert.Equal(t, tt.ExpectedKids, kids)
+		})
+	}
+}
+
+func TestVerifySignatureCurve(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signingInput := "header.payload"
+	digest := sha512.Sum512([]byte(signingInput))
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signature := make([]byte, 64)
+	rBytes, sBytes := r.Bytes(), s.Bytes()
+	copy(signature[32-len(rBytes):32], rBytes)
+	copy(signature[64-len(sBytes):], sBytes)
+
+	assert.Error(t, verifySignature("ES512", &key.PublicKey, signingInput, signature), "Should reject ES512 with a P-256 key")
+	assert.Error(t, verifySignature("RS512", &key.PublicKey, signingInput, signature), "Should reject RSA algorithms for EC keys")
+
+	small, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaDigest := sha512.Sum512([]byte(signingInput))
+	rsaSignature, err := rsa.SignPKCS1v15(rand.Reader, small, crypto.SHA512, rsaDigest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Error(t, verifySignature("RS512", &small.PublicKey, signingInput, rsaSignature), "Should reject small RSA keys")
+}
@@ -0,0 +1,288 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"time"
+
+	// Register the hash functions used to verify signatures
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+
+	"github.com/pkg/errors"
+
+	"github.com/samkreter/devopshelper/pkg/types"
+)
+
+const (
+	defaultClockSkew = time.Minute
+)
+
+var (
+	// ErrInvalidToken the token is malformed or failed validation
+	ErrInvalidToken = errors.New("invalid token")
+)
+
+// JWTOptions configures the claims a token must have
+type JWTOptions struct {
+	Issuers   []string
+	Audiences []string
+	ClockSkew time.Duration
+}
+
+// JWTAuthenticator validates bearer tokens locally against a JWKS key set
+type JWTAuthenticator struct {
+	keys    *KeySet
+	options JWTOptions
+	now     func() time.Time
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+type jwtClaims struct {
+	Issuer     string   `json:"iss"`
+	Audience   audience `json:"aud"`
+	ExpiresAt  *int64   `json:"exp"`
+	NotBefore  *int64   `json:"nbf"`
+	ObjectID   string   `json:"oid"`
+	Name       string   `json:"name"`
+	GivenName  string   `json:"given_name"`
+	FamilyName string   `json:"family_name"`
+	Email      string   `json:"email"`
+	UPN        string   `json:"upn"`
+}
+
+// audience handles the aud claim being either a string or a list of strings
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return err
+	}
+
+	*a = multiple
+	return nil
+}
+
+// NewJWTAuthenticator creates a new authenticator that validates tokens with the key set
+func NewJWTAuthenticator(keys *KeySet, o JWTOptions) (*JWTAuthenticator, error) {
+	if keys == nil {
+		return nil, errors.New("missing key set")
+	}
+
+	if len(o.Issuers) == 0 {
+		return nil, errors.New("at least one issuer is required")
+	}
+
+	if len(o.Audiences) == 0 {
+		return nil, errors.New("at least one audience is required")
+	}
+
+	if o.ClockSkew == 0 {
+		o.ClockSkew = defaultClockSkew
+	}
+
+	return &JWTAuthenticator{
+		keys:    keys,
+		options: o,
+		now:     time.Now,
+	}, nil
+}
+
+// Authenticate implements Authenticator
+func (j *JWTAuthenticator) Authenticate(ctx context.Context, authHeader string) (*types.GraphUser, error) {
+	token, err := bearerToken(authHeader)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, err := j.validate(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	return claims.toGraphUser(), nil
+}
+
+func (j *JWTAuthenticator) validate(ctx context.Context, token string) (*jwtClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.Wrap(ErrInvalidToken, "token must have three parts")
+	}
+
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, errors.Wrap(ErrInvalidToken, "malformed header")
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errors.Wrap(ErrInvalidToken, "malformed signature")
+	}
+
+	key, err := j.keys.Key(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := verifySignature(header.Alg, key, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, err
+	}
+
+	var claims jwtClaims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, errors.Wrap(ErrInvalidToken, "malformed claims")
+	}
+
+	if err := j.validateClaims(&claims); err != nil {
+		return nil, err
+	}
+
+	return &claims, nil
+}
+
+func (j *JWTAuthenticator) validateClaims(claims *jwtClaims) error {
+	now := j.now()
+
+	if claims.ExpiresAt == nil {
+		return errors.Wrap(ErrInvalidToken, "missing exp claim")
+	}
+
+	if now.After(time.Unix(*claims.ExpiresAt, 0).Add(j.options.ClockSkew)) {
+		return errors.Wrap(ErrInvalidToken, "token is expired")
+	}
+
+	if claims.NotBefore != nil && now.Add(j.options.ClockSkew).Before(time.Unix(*claims.NotBefore, 0)) {
+		return errors.Wrap(ErrInvalidToken, "token is not valid yet")
+	}
+
+	if !containsString(j.options.Issuers, claims.Issuer) {
+		return errors.Wrapf(ErrInvalidToken, "untrusted issuer %q", claims.Issuer)
+	}
+
+	for _, aud := range claims.Audience {
+		if containsString(j.options.Audiences, aud) {
+			return nil
+		}
+	}
+
+	return errors.Wrapf(ErrInvalidToken, "untrusted audience %v", []string(claims.Audience))
+}
+
+// toGraphUser builds the user from the token's claims. Only the oid and upn claims are used as the user's
+// id and user principal name since they are the ones the tenant controls, email and preferred_username can be
+// set by the user and are only kept as the mail for display.
+func (c *jwtClaims) toGraphUser() *types.GraphUser {
+	return &types.GraphUser{
+		ID:                c.ObjectID,
+		DisplayName:       c.Name,
+		GivenName:         c.GivenName,
+		Surname:           c.FamilyName,
+		Mail:              c.Email,
+		UserPrincipalName: c.UPN,
+	}
+}
+
+// verifySignature verifies the signature for the supported RSA and ECDSA algorithms
+func verifySignature(alg string, key crypto.PublicKey, signingInput string, signature []byte) error {
+	var hash crypto.Hash
+	switch alg {
+	case "RS256", "ES256":
+		hash = crypto.SHA256
+	case "RS384", "ES384":
+		hash = crypto.SHA384
+	case "RS512", "ES512":
+		hash = crypto.SHA512
+	default:
+		return errors.Wrapf(ErrInvalidToken, "unsupported signing algorithm %q", alg)
+	}
+
+	hasher := hash.New()
+	hasher.Write([]byte(signingInput))
+	digest := hasher.Sum(nil)
+
+	switch pub := key.(type) {
+	case *rsa.PublicKey:
+		if !strings.HasPrefix(alg, "RS") {
+			return errors.Wrapf(ErrInvalidToken, "algorithm %q does not match RSA key", alg)
+		}
+
+		if pub.N.BitLen() < minRSAKeyBits {
+			return errors.Wrap(ErrInvalidToken, "RSA key is too small")
+		}
+
+		if err := rsa.VerifyPKCS1v15(pub, hash, digest, signature); err != nil {
+			return errors.Wrap(ErrInvalidToken, "invalid signature")
+		}
+
+	case *ecdsa.PublicKey:
+		if curveForAlgorithm(alg) != pub.Curve.Params().Name {
+			return errors.Wrapf(ErrInvalidToken, "algorithm %q does not match EC key curve %s", alg, pub.Curve.Params().Name)
+		}
+
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return errors.Wrap(ErrInvalidToken, "invalid signature")
+		}
+
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(pub, digest, r, s) {
+			return errors.Wrap(ErrInvalidToken, "invalid signature")
+		}
+
+	default:
+		return errors.Wrap(ErrInvalidToken, "unsupported key type")
+	}
+
+	return nil
+}
+
+// curveForAlgorithm returns the curve the ECDSA algorithm must be used with
+func curveForAlgorithm(alg string) string {
+	switch alg {
+	case "ES256":
+		return "P-256"
+	case "ES384":
+		return "P-384"
+	case "ES512":
+		return "P-521"
+	default:
+		return ""
+	}
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,243 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/samkreter/devopshelper/pkg/types"
+)
+
+const (
+	testKid      = "test-key"
+	testIssuer   = "https://login.example.com/tenant/v2.0"
+	testAudience = "api://devopshelper"
+)
+
+func TestJWTAuthenticator(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jwks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	key, keySet := newTestKeySet(t, dir)
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authenticator, err := NewJWTAuthenticator(keySet, JWTOptions{
+		Issuers:   []string{testIssuer},
+		Audiences: []string{testAudience},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	validClaims := func() map[string]interface{} {
+		return map[string]interface{}{
+			"iss":                testIssuer,
+			"aud":                testAudience,
+			"exp":                now.Add(time.Hour).Unix(),
+			"nbf":                now.Add(-time.Minute).Unix(),
+			"oid":                "00000000-0000-0000-0000-000000000001",
+			"name":               "Test User",
+			"upn":                "tester@example.com",
+			"email":              "tester.mail@example.com",
+			"preferred_username": "other@example.com",
+		}
+	}
+
+	tests := []struct {
+		Name         string
+		Token        string
+		ExpectedUser *types.GraphUser
+	}{
+		{
+			Name:  "Success Case",
+			Token: signTestToken(t, key, testKid, validClaims()),
+			ExpectedUser: &types.GraphUser{
+				ID:                "00000000-0000-0000-0000-000000000001",
+				DisplayName:       "Test User",
+				Mail:              "tester.mail@example.com",
+				UserPrincipalName: "tester@example.com",
+			},
+		},
+		{
+			Name: "Success Case - Audience List",
+			Token: signTestToken(t, key, testKid, withClaim(validClaims(), "aud",
+				[]string{"other", testAudience})),
+			ExpectedUser: &types.GraphUser{
+				ID:                "00000000-0000-0000-0000-000000000001",
+				DisplayName:       "Test User",
+				Mail:              "tester.mail@example.com",
+				UserPrincipalName: "tester@example.com",
+			},
+		},
+		{
+			Name:  "Success Case - No UPN",
+			Token: signTestToken(t, key, testKid, withClaim(validClaims(), "upn", nil)),
+			ExpectedUser: &types.GraphUser{
+				ID:          "00000000-0000-0000-0000-000000000001",
+				DisplayName: "Test User",
+				Mail:        "tester.mail@example.com",
+			},
+		},
+		{
+			Name:  "Expired Token",
+			Token: signTestToken(t, key, testKid, withClaim(validClaims(), "exp", now.Add(-time.Hour).Unix())),
+		},
+		{
+			Name:  "Not Valid Yet",
+			Token: signTestToken(t, key, testKid, withClaim(validClaims(), "nbf", now.Add(time.Hour).Unix())),
+		},
+		{
+			Name:  "Missing Expiry",
+			Token: signTestToken(t, key, testKid, withClaim(validClaims(), "exp", nil)),
+		},
+		{
+			Name:  "Untrusted Issuer",
+			Token: signTestToken(t, key, testKid, withClaim(validClaims(), "iss", "https://evil.example.com")),
+		},
+		{
+			Name:  "Untrusted Audience",
+			Token: signTestToken(t, key, testKid, withClaim(validClaims(), "aud", "api://other")),
+		},
+		{
+			Name:  "Unknown Key",
+			Token: signTestToken(t, key, "unknown-key", validClaims()),
+		},
+		{
+			Name:  "Invalid Signature",
+			Token: signTestToken(t, otherKey, testKid, validClaims()),
+		},
+		{
+			Name:  "Malformed Token",
+			Token: "not-a-token",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			user, err := authenticator.Authenticate(context.Background(), "Bearer "+tt.Token)
+			if tt.ExpectedUser == nil {
+				assert.Error(t, err, "Should fail authentication")
+				assert.Nil(t, user, "Should not return a user")
+				return
+			}
+
+			assert.NoError(t, err, "Should authenticate")
+			assert.Equal(t, tt.ExpectedUser, user, "Should build user from claims")
+		})
+	}
+}
+
+func TestCachingAuthenticator(t *testing.T) {
+	counter := &countingAuthenticator{}
+	cache := NewCachingAuthenticator(counter, time.Minute)
+
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		_, err := cache.Authenticate(context.Background(), "Bearer token")
+		assert.NoError(t, err, "Should authenticate")
+	}
+	assert.Equal(t, 1, counter.calls, "Should only authenticate once while cached")
+
+	now = now.Add(2 * time.Minute)
+	_, err := cache.Authenticate(context.Background(), "Bearer token")
+	assert.NoError(t, err, "Should authenticate")
+	assert.Equal(t, 2, counter.calls, "Should authenticate again once the entry expires")
+}
+
+type countingAuthenticator struct {
+	calls int
+}
+
+func (c *countingAuthenticator) Authenticate(ctx context.Context, authHeader string) (*types.GraphUser, error) {
+	c.calls++
+	return &types.GraphUser{Mail: "tester@example.com"}, nil
+}
+
+func newTestKeySet(t *testing.T, dir string) (*rsa.PrivateKey, *KeySet) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jwks := map[string]interface{}{
+		"keys": []map[string]string{
+			{
+				"kty": "RSA",
+				"kid": testKid,
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			},
+		},
+	}
+
+	data, err := json.Marshal(jwks)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, "jwks.json")
+	if err := ioutil.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	keySet, err := NewKeySet(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return key, keySet
+}
+
+func signTestToken(t *testing.T, key *rsa.PrivateKey, kid string, claims map[string]interface{}) string {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT", "kid": kid})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signingInput))
+
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func withClaim(claims map[string]interface{}, name string, value interface{}) map[string]interface{} {
+	if value == nil {
+		delete(claims, name)
+		return claims
+	}
+
+	claims[name] = value
+	return claims
+}
@@ -61,6 +61,28 @@ func getFileFromADO(ctx context.Context, client adogit.Client, repoID, path stri
 	return item, nil
 }
 
+// getFileAtCommit retrieves a file and its contents at the commit, an empty commit gets the default branch
+func getFileAtCommit(ctx context.Context, client adogit.Client, repoID, commitID, path string) (*adogit.GitItem, error) {
+	if commitID == "" {
+		return getFileFromADO(ctx, client, repoID, path)
+	}
+
+	item, err := client.GetItem(ctx, adogit.GetItemArgs{
+		RepositoryId:   &repoID,
+		Path:           &path,
+		IncludeContent: toBoolPtr(true),
+		VersionDescriptor: &adogit.GitVersionDescriptor{
+			Version:     &commitID,
+			VersionType: &adogit.GitVersionTypeValues.Commit,
+		},
+	})
+	if err != nil {
+		return nil, ParseADOError(err)
+	}
+
+	return item, nil
+}
+
 // getChangePaths returns a slice of paths for each change
 func getChangePaths(ctx context.Context, changes []adogit.GitPullRequestChange) ([]string, error) {
 	logger := log.G(ctx)
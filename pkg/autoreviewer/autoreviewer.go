@@ -8,7 +8,9 @@ import (
 	adoidentity "github.com/microsoft/azure-devops-go-api/azuredevops/identity"
 	"github.com/pkg/errors"
 	"github.com/samkreter/go-core/log"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/samkreter/devopshelper/pkg/store"
 	"github.com/samkreter/devopshelper/pkg/types"
@@ -16,6 +18,14 @@ import (
 
 const (
 	defaultBotIdentifier = "b03f5f7f11d50a3a"
+
+	assignmentReasonBalanced      = "balanced"
+	assignmentReasonLegacyComment = "found existing bot comment"
+	assignmentReasonClaimed       = "claimed"
+
+	// assignmentClaimTimeout is how long a claimed pull request can stay pending before another
+	// balancer treats the claim as abandoned
+	assignmentClaimTimeout = 10 * time.Minute
 )
 
 var (
@@ -27,7 +37,7 @@ var (
 )
 
 // Filter is a function returns true if a pull request should be filtered out.
-type Filter func(*PullRequest) bool
+type Filter func(context.Context, *PullRequest) bool
 
 // ReviewerTrigger is called with the reviewers that have been selected. Allows for adding custom events
 //  for each reviewer that is added to the PR. Ex: slack notification.
@@ -36,6 +46,11 @@ type ReviewerTrigger func([]*types.Reviewer, []*types.Reviewer, string) error
 type Options struct {
 	Filters []Filter
 	ReviewerTriggers []ReviewerTrigger
+
+	// DryRun previews the reviewers for every repository, see Repository.DryRun
+	DryRun bool
+	// DryRunPreviewComment posts the preview comment for every repository in dry run mode
+	DryRunPreviewComment bool
 }
 
 // AutoReviewer automaticly adds reviewers to a vsts pull request
@@ -48,7 +63,16 @@ type AutoReviewer struct {
 	RepoStore        store.RepositoryStore
 	ReviewerStore 	 store.ReviewerStore
 	TeamStore store.TeamStore
+	AssignmentStore store.AssignmentStore
 	Options Options
+
+	// reviewLoad is the open review count across all repositories, set by the manager for each run and by the
+	// balancer for each event
+	reviewLoad ReviewLoad
+	// ownersCache holds the owners files at each repository's branch heads
+	ownersCache *OwnersCache
+	// peekStore holds the previewed reviewer updates of the current run in dry run mode
+	peekStore *peekReviewerStore
 }
 
 // NewAutoReviewer creates a new autoreviewer
@@ -56,10 +80,14 @@ func NewAutoReviewer(adoGitClient adogit.Client,
 	adoIdentityClient adoidentity.Client, adoCoreClient adocore.Client,
 	botIdentifier string, repo *types.Repository,
 	repoStore store.RepositoryStore, reviewerStore store.ReviewerStore, teamStore store.TeamStore,
-	options Options) (*AutoReviewer, error) {
+	assignmentStore store.AssignmentStore, options Options) (*AutoReviewer, error) {
 
 	if options.Filters == nil {
-		options.Filters = defaultFilters
+		filters, err := CompileFilters(repo.Filters, adoGitClient)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid filters for repository %s", repo.Name)
+		}
+		options.Filters = filters
 	}
 
 	return &AutoReviewer{
@@ -67,29 +95,55 @@ func NewAutoReviewer(adoGitClient adogit.Client,
 		RepoStore:         repoStore,
 		TeamStore: teamStore,
 		ReviewerStore: reviewerStore,
+		AssignmentStore: assignmentStore,
 		Options:           options,
 		adoGitClient:      adoGitClient,
 		adoIdentityClient: adoIdentityClient,
 		adoCoreClient:     adoCoreClient,
 		botIdentifier:     botIdentifier,
+		ownersCache:       DefaultOwnersCache,
 	}, nil
 }
 
 // Run starts the autoreviewer for a single instance
 func (a *AutoReviewer) Run(ctx context.Context) error {
-	pullRequests, err := a.adoGitClient.GetPullRequests(ctx, adogit.GetPullRequestsArgs{
-		RepositoryId: &a.Repo.AdoRepoID,
-		Project: &a.Repo.ProjectName,
+	pullRequests, err := a.getPullRequests(ctx)
+	if err != nil {
+		return err
+	}
+
+	return a.balancePullRequests(ctx, pullRequests)
+}
+
+func (a *AutoReviewer) getPullRequests(ctx context.Context) ([]adogit.GitPullRequest, error) {
+	return getPullRequests(ctx, a.adoGitClient, a.Repo)
+}
+
+// getPullRequests gets the active pull requests for the repository
+func getPullRequests(ctx context.Context, adoGitClient adogit.Client, repo *types.Repository) ([]adogit.GitPullRequest, error) {
+	pullRequests, err := adoGitClient.GetPullRequests(ctx, adogit.GetPullRequestsArgs{
+		RepositoryId: &repo.AdoRepoID,
+		Project: &repo.ProjectName,
 		SearchCriteria: &adogit.GitPullRequestSearchCriteria{},
 	})
 	if err != nil {
-		return fmt.Errorf("get pull requests error: %v", err)
+		return nil, fmt.Errorf("get pull requests error: %v", err)
 	}
+	if pullRequests == nil {
+		return nil, nil
+	}
+
+	return *pullRequests, nil
+}
+
+func (a *AutoReviewer) balancePullRequests(ctx context.Context, pullRequests []adogit.GitPullRequest) error {
+	// Previews only rotate within a run, each run starts from the stored reviewers
+	a.peekStore = nil
 
-	for _, pr := range *pullRequests {
-		pullRequest := &PullRequest{pr}
+	for _, pr := range pullRequests {
+		pullRequest := &PullRequest{GitPullRequest: pr}
 
-		if a.shouldFilter(pullRequest) {
+		if a.shouldFilter(ctx, pullRequest) {
 			continue
 		}
 
@@ -101,29 +155,90 @@ func (a *AutoReviewer) Run(ctx context.Context) error {
 	return nil
 }
 
+// BalancePullRequest balances a single pull request, used when notified of pull request events
+func (a *AutoReviewer) BalancePullRequest(ctx context.Context, pullRequestID int) error {
+	pr, err := a.adoGitClient.GetPullRequestById(ctx, adogit.GetPullRequestByIdArgs{
+		PullRequestId: &pullRequestID,
+		Project:       &a.Repo.ProjectName,
+	})
+	if err != nil {
+		return errors.Wrapf(ParseADOError(err), "failed to get pull request %d", pullRequestID)
+	}
+
+	pullRequest := &PullRequest{GitPullRequest: *pr}
+
+	if pr.Status == nil || *pr.Status != adogit.PullRequestStatusValues.Active {
+		log.G(ctx).Infof("Skipping inactive pull request %d", pullRequestID)
+		return nil
+	}
+
+	if a.shouldFilter(ctx, pullRequest) {
+		log.G(ctx).Infof("Skipping filtered pull request %d", pullRequestID)
+		return nil
+	}
+
+	a.peekStore = nil
+	return a.balanceReview(ctx, pullRequest)
+}
+
 func (a *AutoReviewer) balanceReview(ctx context.Context, pr *PullRequest) error {
 	logger := log.G(ctx)
 
-	if a.ContainsReviewBalancerComment(ctx, pr.Repository.Id.String(),  *pr.PullRequestId) {
+	balanced, err := a.isBalanced(ctx, pr)
+	if err != nil {
+		return errors.Wrap(err, "failed to check if pull request was balanced")
+	}
+	if balanced {
 		return nil
 	}
 
-	requiredReviewers, optionalReviewers, err := a.getReviewers(ctx, pr)
+	// Webhooks, the polling loop and other replicas can balance the same pull request at the same time,
+	// only the one that claims the assignment adds reviewers
+	claim, err := a.claimAssignment(ctx, pr)
 	if err != nil {
+		return errors.Wrap(err, "failed to claim pull request")
+	}
+	if claim == nil {
+		logger.Infof("PR: %d is already being balanced", *pr.PullRequestId)
+		return nil
+	}
+
+	if a.dryRun() {
+		if err := a.previewReview(ctx, pr, claim); err != nil {
+			a.releaseAssignment(ctx, pr)
+			return err
+		}
+		return nil
+	}
+
+	selection, err := a.getReviewers(ctx, pr)
+	if err != nil {
+		a.releaseAssignment(ctx, pr)
 		return errors.Wrap(err, "failed to get reviewers")
 	}
+	requiredReviewers, optionalReviewers := selection.Required, selection.Optional
 
 	if err := a.AddReviewers(ctx, *pr.PullRequestId, pr.Repository.Id.String(), requiredReviewers, optionalReviewers); err != nil {
+		a.releaseAssignment(ctx, pr)
 		return errors.Wrap(err, "failed to add reviewers to PR")
 	}
 
-	if err := a.addReviewerComment(ctx, pr, requiredReviewers); err != nil {
+	if err := a.addReviewerComment(ctx, pr, selection); err != nil {
+		a.releaseAssignment(ctx, pr)
 		return errors.Wrap(err,"failed to add reviewer comment")
 	}
 
+	a.completeAssignment(ctx, claim, requiredReviewers, optionalReviewers, assignmentReasonBalanced)
+
+	if a.reviewLoad != nil {
+		a.reviewLoad.AddReviewers(requiredReviewers)
+	}
+
 	if a.Options.ReviewerTriggers != nil {
+		notifyRequired := filterNoNotify(requiredReviewers, selection.NoNotify)
+		notifyOptional := filterNoNotify(optionalReviewers, selection.NoNotify)
 		for _, rTrigger := range a.Options.ReviewerTriggers {
-			if err := rTrigger(requiredReviewers, optionalReviewers, *pr.Url); err != nil {
+			if err := rTrigger(notifyRequired, notifyOptional, *pr.Url); err != nil {
 				logger.Error(err)
 			}
 		}
@@ -139,13 +254,13 @@ func (a *AutoReviewer) balanceReview(ctx context.Context, pr *PullRequest) error
 
 
 
-func (a *AutoReviewer) shouldFilter(pr *PullRequest) bool {
+func (a *AutoReviewer) shouldFilter(ctx context.Context, pr *PullRequest) bool {
 	if a.Options.Filters == nil {
 		return false
 	}
 
 	for _, filter := range a.Options.Filters {
-		if filter(pr) {
+		if filter(ctx, pr) {
 			return true
 		}
 	}
@@ -153,29 +268,65 @@ func (a *AutoReviewer) shouldFilter(pr *PullRequest) bool {
 	return false
 }
 
-func (a *AutoReviewer) getReviewers(ctx context.Context, pr *PullRequest) ([]*types.Reviewer, []*types.Reviewer, error) {
-	reviewerGroups, err := pr.GetRequiredReviewerGroups(ctx, a.adoGitClient)
+// ReviewerSelection holds the reviewers selected for a pull request
+type ReviewerSelection struct {
+	Required []*types.Reviewer
+	Optional []*types.Reviewer
+	// Reasons explains why each reviewer was selected, keyed by alias
+	Reasons map[string]string
+	// NoNotify are the selected aliases that must not be notified by the reviewer triggers
+	NoNotify map[string]bool
+	// OwnersFiles are the owners files that matched the changed files
+	OwnersFiles []string
+}
+
+func (a *AutoReviewer) getReviewers(ctx context.Context, pr *PullRequest) (*ReviewerSelection, error) {
+	changePaths, err := pr.GetAllChanges(ctx, a.adoGitClient)
 	if err != nil {
-		return nil, nil, errors.Wrapf(err, "failed to get required reviewer groups for PR: %d", *pr.PullRequestId)
+		return nil, errors.Wrapf(err, "failed to get changes for PR: %d", *pr.PullRequestId)
+	}
+
+	reviewerGroups, err := a.getReviewerGroups(ctx, pr, changePaths)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get required reviewer groups for PR: %d", *pr.PullRequestId)
 	}
 
 	prCreator, err := a.ReviewerStore.GetReviewerByADOID(ctx, *pr.CreatedBy.Id)
 	if err != nil && !errors.Is(err, store.ErrNotFound){
-		return nil, nil, errors.Wrapf(err, "failed to get pr creator %s from store", *pr.CreatedBy.DisplayName)
+		return nil, errors.Wrapf(err, "failed to get pr creator %s from store", *pr.CreatedBy.DisplayName)
+	}
+
+	var expertise Expertise
+	if a.Repo.ExpertiseWeight > 0 {
+		expertise, err = getExpertise(ctx, a.adoGitClient, pr.Repository.Id.String(), changePaths, time.Now().UTC())
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get expertise for PR: %d", *pr.PullRequestId)
+		}
 	}
 
+	return a.selectReviewers(ctx, reviewerGroups, prCreator, expertise)
+}
+
+// selectReviewers selects the reviewers from the owners of the changed files, the pr creator is never selected
+func (a *AutoReviewer) selectReviewers(ctx context.Context, reviewerGroups []*ReviewerGroup, prCreator *types.Reviewer, expertise Expertise) (*ReviewerSelection, error) {
 	requiredOwners := map[string]bool{}
 	requiredTeamMembers := map[string]bool{}
+	noNotifyOwners := map[string]bool{}
+	ownersFiles := map[string]bool{}
 
 	for _, reviewerGroup := range reviewerGroups {
 		if reviewerGroup == nil {
 			continue
 		}
 
+		for _, file := range reviewerGroup.Files {
+			ownersFiles[file] = true
+		}
+
 		for teamName := range reviewerGroup.Teams {
 			team, err := a.TeamStore.GetTeam(ctx, teamName)
 			if err != nil {
-				return nil, nil, errors.Wrapf(err, "failed to get team %q", teamName)
+				return nil, errors.Wrapf(err, "failed to get team %q", teamName)
 			}
 
 			for _, member := range team.Members {
@@ -190,14 +341,24 @@ func (a *AutoReviewer) getReviewers(ctx context.Context, pr *PullRequest) ([]*ty
 				continue
 			}
 
+			if reviewerGroup.NoNotify[owner] {
+				noNotifyOwners[owner] = true
+				continue
+			}
 			requiredOwners[owner] = true
 		}
 	}
 
+	// No notify owners can approve but are never auto assigned, unless another owners file lists them normally
+	for owner := range requiredOwners {
+		delete(noNotifyOwners, owner)
+	}
+
 	// Remove PR creator from reviewer list
 	if prCreator != nil {
 		delete(requiredTeamMembers, prCreator.Alias)
 		delete(requiredOwners, prCreator.Alias)
+		delete(noNotifyOwners, prCreator.Alias)
 	}
 
 	// Ensure owners aren't in both groups
@@ -205,28 +366,129 @@ func (a *AutoReviewer) getReviewers(ctx context.Context, pr *PullRequest) ([]*ty
 		delete(requiredTeamMembers, owner)
 	}
 
-	var finalReviewers []*types.Reviewer
+	// No notify owners aren't auto assigned as team members or optional reviewers either
+	for owner := range noNotifyOwners {
+		delete(requiredTeamMembers, owner)
+	}
+
+	counts := a.Repo.GetReviewerCounts()
 
-	// Get least recently used reviewer for each group
-	owners := getAliases(requiredOwners)
-	owner, err := a.ReviewerStore.PopLRUReviewer(ctx, owners)
+	strategy, err := a.selectionStrategy()
+	if err != nil {
+		return nil, err
+	}
+
+	if a.Repo.ExpertiseWeight > 0 {
+		strategy = NewExpertiseStrategy(a.ReviewerStore, expertise, a.Repo.ExpertiseWeight)
+	}
+
+	// Select reviewers for each group, skipping anyone already at their open review cap
+	owners, err := filterAtCapacity(ctx, a.ReviewerStore, a.reviewLoad, getAliases(requiredOwners))
+	if err != nil {
+		return nil, err
+	}
+	ownerReviewers, err := strategy.Select(ctx, owners, counts.RequiredOwners)
 	if err != nil && !errors.Is(err, store.ErrNotFound){
-			return nil, nil, errors.Wrapf(err, "failed to get owner reviewer for owners: %v", owners)
+		return nil, errors.Wrapf(err, "failed to get owner reviewers for owners: %v", owners)
 	}
-	if owner != nil {
-		finalReviewers = append(finalReviewers, owner)
+
+	teamMembers, err := filterAtCapacity(ctx, a.ReviewerStore, a.reviewLoad, getAliases(requiredTeamMembers))
+	if err != nil {
+		return nil, err
+	}
+	teamReviewers, err := strategy.Select(ctx, teamMembers, counts.RequiredTeamMembers)
+	if err != nil && !errors.Is(err, store.ErrNotFound){
+		return nil, errors.Wrapf(err, "failed to get team reviewers for members: %v", teamMembers)
 	}
 
-	teamMembers := getAliases(requiredTeamMembers)
-	teamMember, err := a.ReviewerStore.PopLRUReviewer(ctx, teamMembers)
+	requiredReviewers := append(ownerReviewers, teamReviewers...)
+
+	// Optional reviewers come from whoever is left in either group
+	for _, reviewer := range requiredReviewers {
+		delete(requiredOwners, reviewer.Alias)
+		delete(requiredTeamMembers, reviewer.Alias)
+	}
+	remaining, err := filterAtCapacity(ctx, a.ReviewerStore, a.reviewLoad, append(getAliases(requiredOwners), getAliases(requiredTeamMembers)...))
+	if err != nil {
+		return nil, err
+	}
+	optionalReviewers, err := strategy.Select(ctx, remaining, counts.Optional)
 	if err != nil && !errors.Is(err, store.ErrNotFound){
-		return nil, nil, errors.Wrapf(err, "failed to get team reviewer for members: %v", teamMembers)
+		return nil, errors.Wrapf(err, "failed to get optional reviewers for: %v", remaining)
+	}
+
+	selection := &ReviewerSelection{
+		Required: requiredReviewers,
+		Optional: optionalReviewers,
+		Reasons:  map[string]string{},
+		NoNotify: map[string]bool{},
+	}
+	for file := range ownersFiles {
+		selection.OwnersFiles = append(selection.OwnersFiles, file)
+	}
+	sort.Strings(selection.OwnersFiles)
+	addReasons(selection.Reasons, strategy, ownerReviewers, "owner of the changed files")
+	addReasons(selection.Reasons, strategy, teamReviewers, "member of a team that owns the changed files")
+	addReasons(selection.Reasons, strategy, optionalReviewers, "optional reviewer for the changed files")
+
+	if a.Repo.NoNotifyMode == types.NoNotifyModeOptional {
+		for _, alias := range getAliases(noNotifyOwners) {
+			reviewer, err := a.ReviewerStore.GetReviewer(ctx, alias)
+			if errors.Is(err, store.ErrNotFound) {
+				continue
+			}
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to get no notify owner %q", alias)
+			}
+
+			selection.Optional = append(selection.Optional, reviewer)
+			selection.Reasons[alias] = "no notify owner of the changed files"
+			selection.NoNotify[alias] = true
+		}
 	}
-	if teamMember != nil {
-		finalReviewers = append(finalReviewers, teamMember)
+
+	return selection, nil
+}
+
+// filterNoNotify removes the no notify reviewers
+func filterNoNotify(reviewers []*types.Reviewer, noNotify map[string]bool) []*types.Reviewer {
+	var filtered []*types.Reviewer
+	for _, reviewer := range reviewers {
+		if !noNotify[reviewer.Alias] {
+			filtered = append(filtered, reviewer)
+		}
 	}
+	return filtered
+}
 
-	return finalReviewers, nil, nil
+// reasoner is implemented by selection strategies that can explain why a reviewer was selected
+type reasoner interface {
+	Reason(alias string) string
+}
+
+// addReasons adds why each reviewer was selected, including the strategy's reason when it has one
+func addReasons(reasons map[string]string, strategy SelectionStrategy, reviewers []*types.Reviewer, reason string) {
+	for _, reviewer := range reviewers {
+		reviewerReason := reason
+		if r, ok := strategy.(reasoner); ok {
+			if strategyReason := r.Reason(reviewer.Alias); strategyReason != "" {
+				reviewerReason += ", " + strategyReason
+			}
+		}
+		reasons[reviewer.Alias] = reviewerReason
+	}
+}
+
+// getReviewerGroups gets the owners of the changed paths using the repository's owners format
+func (a *AutoReviewer) getReviewerGroups(ctx context.Context, pr *PullRequest, changePaths []string) ([]*ReviewerGroup, error) {
+	switch a.Repo.OwnersFormat {
+	case "", types.OwnersFormatOwnersTxt:
+		return pr.getReviewerGroupsForPaths(ctx, a.adoGitClient, a.ownersCache, changePaths)
+	case types.OwnersFormatCodeOwners:
+		return pr.getReviewerGroupsFromCodeOwners(ctx, a.adoGitClient, a.ownersCache, changePaths)
+	default:
+		return nil, fmt.Errorf("unknown owners format %q", a.Repo.OwnersFormat)
+	}
 }
 
 func getAliases(reviewers map[string]bool) []string {
@@ -244,19 +506,17 @@ func getAliases(reviewers map[string]bool) []string {
 	return aliases
 }
 
-func (a *AutoReviewer) addReviewerComment(ctx context.Context, pr *PullRequest, required []*types.Reviewer) error {
-	comment := fmt.Sprintf(
-		"Hello %s,\r\n\r\n"+
-			"You are randomly selected as the **required** code reviewers of this change. \r\n\r\n"+
-			"Your responsibility is to review **each** iteration of this CR until signoff. You should provide no more than 48 hour SLA for each iteration.\r\n\r\n"+
-			"Thank you.\r\n\r\n"+
-			"CR Balancer\r\n"+
-			"%s",
-		strings.Join(GetReviewersAlias(required), ","),
-		a.botIdentifier)
+// addReviewerComment posts the repository's comment template, the bot identifier is always appended so the
+// pull request is known to be balanced
+func (a *AutoReviewer) addReviewerComment(ctx context.Context, pr *PullRequest, selection *ReviewerSelection) error {
+	comment, err := renderComment(a.Repo.CommentTemplate, a.newCommentData(pr, selection))
+	if err != nil {
+		return err
+	}
+	comment += a.botIdentifier
 
 	repoID := pr.Repository.Id.String()
-	_, err := a.adoGitClient.CreateThread(ctx, adogit.CreateThreadArgs{
+	_, err = a.adoGitClient.CreateThread(ctx, adogit.CreateThreadArgs{
 		RepositoryId: &repoID,
 		PullRequestId: pr.PullRequestId,
 		CommentThread: &adogit.GitPullRequestCommentThread{
@@ -274,29 +534,156 @@ func (a *AutoReviewer) addReviewerComment(ctx context.Context, pr *PullRequest,
 	return nil
 }
 
+// newCommentData gets the data the repository's comment template is executed with
+func (a *AutoReviewer) newCommentData(pr *PullRequest, selection *ReviewerSelection) CommentData {
+	var noNotify []string
+	for _, reviewer := range selection.Optional {
+		if selection.NoNotify[reviewer.Alias] {
+			noNotify = append(noNotify, reviewer.Alias)
+		}
+	}
+
+	return CommentData{
+		Required:    newCommentReviewers(selection.Required, selection.Reasons),
+		Optional:    newCommentReviewers(filterNoNotify(selection.Optional, selection.NoNotify), selection.Reasons),
+		NoNotify:    noNotify,
+		PullRequest: newCommentPullRequest(pr),
+		Repository:  a.Repo.Name,
+		Project:     a.Repo.ProjectName,
+		OwnersFiles: selection.OwnersFiles,
+	}
+}
+
+// isBalanced checks the assignment store for the pull request, falling back to the bot comment for
+// pull requests balanced before assignments were recorded.
+func (a *AutoReviewer) isBalanced(ctx context.Context, pr *PullRequest) (bool, error) {
+	repositoryID := pr.Repository.Id.String()
+
+	assignment, err := a.AssignmentStore.GetAssignment(ctx, repositoryID, *pr.PullRequestId)
+	switch {
+	case err == nil && assignment.Pending:
+		// Abandoned claims are replaced when the pull request is claimed again
+		return !assignment.Created.Before(claimStaleBefore()), nil
+	case err == nil && assignment.DryRun && !a.dryRun():
+		// Previewed pull requests are balanced for real once dry run is turned off
+		if err := a.AssignmentStore.DeleteAssignment(ctx, repositoryID, *pr.PullRequestId); err != nil && !errors.Is(err, store.ErrNotFound) {
+			return false, errors.Wrapf(err, "failed to delete dry run assignment for PR: %d", *pr.PullRequestId)
+		}
+		return false, nil
+	case err == nil:
+		return true, nil
+	case !errors.Is(err, store.ErrNotFound):
+		return false, errors.Wrapf(err, "failed to get assignment for PR: %d", *pr.PullRequestId)
+	}
+
+	hasComment, err := a.ContainsReviewBalancerComment(ctx, repositoryID, *pr.PullRequestId)
+	if err != nil {
+		return false, err
+	}
+
+	// Backfill the assignment so the comments don't need to be scanned again
+	if hasComment {
+		a.recordAssignment(ctx, pr, nil, nil, assignmentReasonLegacyComment)
+	}
+
+	return hasComment, nil
+}
+
+// recordAssignment adds the assignment to the store. Failures are only logged since the bot comment
+// still marks the pull request as balanced.
+func (a *AutoReviewer) recordAssignment(ctx context.Context, pr *PullRequest, required, optional []*types.Reviewer, reason string) {
+	assignment := &types.Assignment{
+		RepositoryID:      pr.Repository.Id.String(),
+		RepositoryName:    a.Repo.Name,
+		ProjectName:       a.Repo.ProjectName,
+		PullRequestID:     *pr.PullRequestId,
+		RequiredReviewers: GetReviewersAlias(required),
+		OptionalReviewers: GetReviewersAlias(optional),
+		Reason:            reason,
+		DryRun:            reason == assignmentReasonDryRun,
+		Created:           time.Now().UTC(),
+	}
+
+	if err := a.AssignmentStore.AddAssignment(ctx, assignment); err != nil && !errors.Is(err, store.ErrAlreadyExists) {
+		log.G(ctx).WithError(err).Errorf("failed to record assignment for PR: %d", *pr.PullRequestId)
+	}
+}
+
+// claimAssignment records a pending assignment for the pull request, nil is returned when the pull request
+// was already claimed
+func (a *AutoReviewer) claimAssignment(ctx context.Context, pr *PullRequest) (*types.Assignment, error) {
+	claim := &types.Assignment{
+		RepositoryID:   pr.Repository.Id.String(),
+		RepositoryName: a.Repo.Name,
+		ProjectName:    a.Repo.ProjectName,
+		PullRequestID:  *pr.PullRequestId,
+		Reason:         assignmentReasonClaimed,
+		DryRun:         a.dryRun(),
+		Pending:        true,
+		Created:        time.Now().UTC(),
+	}
+
+	if err := a.AssignmentStore.ClaimAssignment(ctx, claim, claimStaleBefore()); err != nil {
+		if errors.Is(err, store.ErrAlreadyExists) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return claim, nil
+}
+
+// completeAssignment records the reviewers on the claimed assignment. Failures are only logged since the
+// bot comment still marks the pull request as balanced.
+func (a *AutoReviewer) completeAssignment(ctx context.Context, claim *types.Assignment, required, optional []*types.Reviewer, reason string) {
+	claim.RequiredReviewers = GetReviewersAlias(required)
+	claim.OptionalReviewers = GetReviewersAlias(optional)
+	claim.Reason = reason
+	claim.Pending = false
+
+	if err := a.AssignmentStore.UpdateAssignment(ctx, claim); err != nil {
+		log.G(ctx).WithError(err).Errorf("failed to record assignment for PR: %d", claim.PullRequestID)
+	}
+}
+
+// releaseAssignment removes the claim after a failure so the pull request is balanced again
+func (a *AutoReviewer) releaseAssignment(ctx context.Context, pr *PullRequest) {
+	if err := a.AssignmentStore.DeleteAssignment(ctx, pr.Repository.Id.String(), *pr.PullRequestId); err != nil && !errors.Is(err, store.ErrNotFound) {
+		log.G(ctx).WithError(err).Errorf("failed to release the claim for PR: %d", *pr.PullRequestId)
+	}
+}
+
+// claimStaleBefore returns the time pending claims must be created after to still be held
+func claimStaleBefore() time.Time {
+	return time.Now().UTC().Add(-assignmentClaimTimeout)
+}
+
 // ContainsReviewBalancerComment checks if the passed in review has had a bot comment added.
-func (a *AutoReviewer) ContainsReviewBalancerComment(ctx context.Context, repositoryID string, pullRequestID int) bool {
+func (a *AutoReviewer) ContainsReviewBalancerComment(ctx context.Context, repositoryID string, pullRequestID int) (bool, error) {
 	threads, err := a.adoGitClient.GetThreads(ctx, adogit.GetThreadsArgs{
 		RepositoryId: &repositoryID,
 		PullRequestId: &pullRequestID,
 	})
 	if err != nil {
-		panic(err)
+		return false, errors.Wrapf(ParseADOError(err), "failed to get threads for PR: %d", pullRequestID)
 	}
 
 	if threads != nil {
 		for _, thread := range *threads {
+			if thread.Comments == nil {
+				continue
+			}
 			for _, comment := range *thread.Comments {
 				if comment.Content == nil {
 					continue
 				}
 				if strings.Contains(*comment.Content, a.botIdentifier) {
-					return true
+					return true, nil
 				}
 			}
 		}
 	}
-	return false
+	return false, nil
 }
 
 // AddReviewers adds the passing in reviewers to the pull requests for the passed in review.
@@ -343,7 +730,7 @@ func GetReviewersAlias(reviewers []*types.Reviewer) []string {
 	return aliases
 }
 
-func filterWIP(pr *PullRequest) bool {
+func filterWIP(ctx context.Context, pr *PullRequest) bool {
 	if strings.Contains(*pr.Title, "WIP") {
 		return true
 	}
@@ -351,7 +738,7 @@ func filterWIP(pr *PullRequest) bool {
 	return false
 }
 
-func filterDraft(pr *PullRequest) bool {
+func filterDraft(ctx context.Context, pr *PullRequest) bool {
 	if pr.IsDraft != nil && *pr.IsDraft {
 		return true
 	}
@@ -359,20 +746,7 @@ func filterDraft(pr *PullRequest) bool {
 	return false
 }
 
-func filterBotV2PRs(pr *PullRequest) bool {
-	if pr == nil {
-		return true
-	}
-
-	prTitle := strings.ToLower(*pr.Title)
-	if strings.Contains(prTitle, "botv2") {
-		return false
-	}
-
-	return true
-}
-
-func filterMasterBranchOnly(pr *PullRequest) bool {
+func filterMasterBranchOnly(ctx context.Context, pr *PullRequest) bool {
 	if strings.EqualFold(*pr.TargetRefName, "refs/heads/master") {
 		return false
 	}
@@ -0,0 +1,63 @@
+package autoreviewer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/samkreter/devopshelper/pkg/store/memory"
+	"github.com/samkreter/devopshelper/pkg/types"
+)
+
+func TestSelectReviewersSkipsNoNotifyTeamMembers(t *testing.T) {
+	ctx := context.Background()
+	s := memory.NewStore()
+	for _, alias := range []string{"alice", "bob"} {
+		if err := s.AddReviewer(ctx, &types.Reviewer{Alias: alias, AdoID: alias + "-id"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.AddTeam(ctx, &types.Team{Name: "Platform", Members: []string{"alice", "bob"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	a := &AutoReviewer{
+		Repo: &types.Repository{
+			ReviewerCounts: &types.ReviewerCounts{RequiredOwners: 1, RequiredTeamMembers: 2, Optional: 2},
+		},
+		ReviewerStore: s,
+		TeamStore:     s,
+	}
+	groups := []*ReviewerGroup{{
+		Owners:   map[string]bool{"alice": true},
+		Teams:    map[string]bool{"Platform": true},
+		NoNotify: map[string]bool{"alice": true},
+	}}
+
+	selection, err := a.selectReviewers(ctx, groups, nil, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, []string{"bob"}, GetReviewersAlias(selection.Required), "Should not assign the no notify owner as a team member")
+	assert.Empty(t, selection.Optional, "Should not assign the no notify owner as an optional reviewer")
+}
+
+func TestNewCommentDataNoNotify(t *testing.T) {
+	a := &AutoReviewer{Repo: &types.Repository{Name: "repo", ProjectName: "project"}}
+	selection := &ReviewerSelection{
+		Required: []*types.Reviewer{{Alias: "alice", AdoID: "alice-id"}},
+		Optional: []*types.Reviewer{{Alias: "bob", AdoID: "bob-id"}, {Alias: "carol", AdoID: "carol-id"}},
+		Reasons:  map[string]string{},
+		NoNotify: map[string]bool{"carol": true},
+	}
+
+	data := a.newCommentData(&PullRequest{}, selection)
+
+	var optional []string
+	for _, reviewer := range data.Optional {
+		optional = append(optional, reviewer.Alias)
+	}
+	assert.Equal(t, []string{"bob"}, optional, "Should leave the no notify owners out of the optional reviewers")
+	assert.Equal(t, []string{"carol"}, data.NoNotify, "Should list the no notify owners by alias")
+}
@@ -0,0 +1,85 @@
+package autoreviewer
+
+import (
+	"context"
+
+	adocore "github.com/microsoft/azure-devops-go-api/azuredevops/core"
+	adogit "github.com/microsoft/azure-devops-go-api/azuredevops/git"
+	adoidentity "github.com/microsoft/azure-devops-go-api/azuredevops/identity"
+	"github.com/pkg/errors"
+	"github.com/samkreter/go-core/log"
+
+	"github.com/samkreter/devopshelper/pkg/store"
+	"github.com/samkreter/devopshelper/pkg/types"
+)
+
+// Balancer balances individual pull requests for any repository, used for event driven reviewer assignment
+type Balancer struct {
+	adoGitClient      adogit.Client
+	adoIdentityClient adoidentity.Client
+	adoCoreClient     adocore.Client
+	repoStore         store.RepositoryStore
+	reviewerStore     store.ReviewerStore
+	teamStore         store.TeamStore
+	assignmentStore   store.AssignmentStore
+	options           Options
+}
+
+// NewBalancer creates a new pull request balancer
+func NewBalancer(repoStore store.RepositoryStore, reviewerStore store.ReviewerStore, teamStore store.TeamStore,
+	assignmentStore store.AssignmentStore, adoGitClient adogit.Client, adoIdentityClient adoidentity.Client, adoCoreClient adocore.Client,
+	options Options) *Balancer {
+	return &Balancer{
+		adoGitClient:      adoGitClient,
+		adoIdentityClient: adoIdentityClient,
+		adoCoreClient:     adoCoreClient,
+		repoStore:         repoStore,
+		reviewerStore:     reviewerStore,
+		teamStore:         teamStore,
+		assignmentStore:   assignmentStore,
+		options:           options,
+	}
+}
+
+// BalancePullRequest balances the pull request in the repository
+func (b *Balancer) BalancePullRequest(ctx context.Context, repo *types.Repository, pullRequestID int) error {
+	aReviewer, err := NewAutoReviewer(b.adoGitClient, b.adoIdentityClient, b.adoCoreClient, defaultBotIdentifier,
+		repo, b.repoStore, b.reviewerStore, b.teamStore, b.assignmentStore, b.options)
+	if err != nil {
+		return err
+	}
+
+	reviewLoad, err := b.reviewLoad(ctx)
+	if err != nil {
+		return err
+	}
+	aReviewer.reviewLoad = reviewLoad
+
+	return aReviewer.BalancePullRequest(ctx, pullRequestID)
+}
+
+// reviewLoad counts the open reviews across every enabled repository, the same load the manager uses for its
+// runs, so the open review caps and the least open reviews strategy apply to event driven assignments
+func (b *Balancer) reviewLoad(ctx context.Context) (ReviewLoad, error) {
+	repos, err := b.repoStore.GetAllRepositories(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get repositories")
+	}
+
+	reviewLoad := ReviewLoad{}
+	for _, repo := range repos {
+		if !repo.Enabled {
+			continue
+		}
+
+		prs, err := getPullRequests(ctx, b.adoGitClient, repo)
+		if err != nil {
+			// Like the manager, one unreachable repo shouldn't stop the pull request from being balanced
+			log.G(ctx).Errorf("failed to get pull requests for repo %s/%s: %v", repo.ProjectName, repo.Name, err)
+			continue
+		}
+		reviewLoad.AddPullRequests(prs)
+	}
+
+	return reviewLoad, nil
+}
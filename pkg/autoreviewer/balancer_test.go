@@ -0,0 +1,258 @@
+package autoreviewer
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/microsoft/azure-devops-go-api/azuredevops"
+	adogit "github.com/microsoft/azure-devops-go-api/azuredevops/git"
+	"github.com/microsoft/azure-devops-go-api/azuredevops/webapi"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/samkreter/devopshelper/pkg/store"
+	"github.com/samkreter/devopshelper/pkg/store/memory"
+	"github.com/samkreter/devopshelper/pkg/types"
+)
+
+// balanceGitClient fakes the ADO calls made while balancing a pull request that changes /src/main.go
+type balanceGitClient struct {
+	adogit.Client
+	owners string
+	// pullRequests are the active pull requests of each repository by ADO repository id
+	pullRequests map[string][]adogit.GitPullRequest
+	// pullRequest is returned when the balancer gets a pull request by id
+	pullRequest *adogit.GitPullRequest
+
+	mu         sync.Mutex
+	reviewers  []string
+	threads    int
+	comments   []string
+	threadErr  error
+	iterations int
+}
+
+func (c *balanceGitClient) GetPullRequests(ctx context.Context, args adogit.GetPullRequestsArgs) (*[]adogit.GitPullRequest, error) {
+	pullRequests := c.pullRequests[*args.RepositoryId]
+	return &pullRequests, nil
+}
+
+func (c *balanceGitClient) GetPullRequestById(ctx context.Context, args adogit.GetPullRequestByIdArgs) (*adogit.GitPullRequest, error) {
+	return c.pullRequest, nil
+}
+
+func (c *balanceGitClient) GetThreads(ctx context.Context, args adogit.GetThreadsArgs) (*[]adogit.GitPullRequestCommentThread, error) {
+	return &[]adogit.GitPullRequestCommentThread{}, nil
+}
+
+func (c *balanceGitClient) GetPullRequestIterations(ctx context.Context, args adogit.GetPullRequestIterationsArgs) (*[]adogit.GitPullRequestIteration, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.iterations++
+	return &[]adogit.GitPullRequestIteration{{}}, nil
+}
+
+func (c *balanceGitClient) GetPullRequestIterationChanges(ctx context.Context, args adogit.GetPullRequestIterationChangesArgs) (*adogit.GitPullRequestIterationChanges, error) {
+	changes := []adogit.GitPullRequestChange{{Item: map[string]interface{}{"path": "/src/main.go"}}}
+	return &adogit.GitPullRequestIterationChanges{ChangeEntries: &changes}, nil
+}
+
+func (c *balanceGitClient) GetBranch(ctx context.Context, args adogit.GetBranchArgs) (*adogit.GitBranchStats, error) {
+	head := "head"
+	return &adogit.GitBranchStats{Name: args.Name, Commit: &adogit.GitCommitRef{CommitId: &head}}, nil
+}
+
+func (c *balanceGitClient) GetItem(ctx context.Context, args adogit.GetItemArgs) (*adogit.GitItem, error) {
+	if *args.Path != "/owners.txt" {
+		statusCode := 404
+		return nil, azuredevops.WrappedError{StatusCode: &statusCode}
+	}
+	return &adogit.GitItem{Path: args.Path, Content: &c.owners}, nil
+}
+
+func (c *balanceGitClient) CreatePullRequestReviewer(ctx context.Context, args adogit.CreatePullRequestReviewerArgs) (*adogit.IdentityRefWithVote, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.reviewers = append(c.reviewers, *args.ReviewerId)
+	return args.Reviewer, nil
+}
+
+func (c *balanceGitClient) CreateThread(ctx context.Context, args adogit.CreateThreadArgs) (*adogit.GitPullRequestCommentThread, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.threadErr != nil {
+		return nil, c.threadErr
+	}
+	c.threads++
+	for _, comment := range *args.CommentThread.Comments {
+		c.comments = append(c.comments, *comment.Content)
+	}
+	return args.CommentThread, nil
+}
+
+func newBalanceTest(t *testing.T) (*AutoReviewer, *balanceGitClient, *memory.Store, *PullRequest) {
+	ctx := context.Background()
+	s := memory.NewStore()
+	for _, alias := range []string{"alice", "bob"} {
+		if err := s.AddReviewer(ctx, &types.Reviewer{Alias: alias, AdoID: alias + "-id"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	client := &balanceGitClient{owners: "alice\nbob\n"}
+	a, err := NewAutoReviewer(client, nil, nil, defaultBotIdentifier, &types.Repository{Name: "repo", ProjectName: "project"},
+		s, s, s, s, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.ownersCache = NewOwnersCache()
+
+	repoID := uuid.New()
+	pullRequestID := 1
+	creatorID := "creator-id"
+	targetRef := "refs/heads/master"
+	url := "https://dev.azure.com/pr/1"
+	pr := &PullRequest{GitPullRequest: adogit.GitPullRequest{
+		Repository:    &adogit.GitRepository{Id: &repoID},
+		PullRequestId: &pullRequestID,
+		CreatedBy:     &webapi.IdentityRef{Id: &creatorID},
+		TargetRefName: &targetRef,
+		Url:           &url,
+	}}
+
+	return a, client, s, pr
+}
+
+func TestBalanceReviewClaim(t *testing.T) {
+	ctx := context.Background()
+	a, client, s, pr := newBalanceTest(t)
+
+	// Duplicate deliveries balancing at the same time must only add the reviewers once
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, a.balanceReview(ctx, pr))
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 1, client.threads, "Should only comment once")
+
+	assignment, err := s.GetAssignment(ctx, pr.Repository.Id.String(), *pr.PullRequestId)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.False(t, assignment.Pending, "Should complete the claim")
+	assert.Equal(t, assignmentReasonBalanced, assignment.Reason)
+	assert.Len(t, assignment.RequiredReviewers, 1)
+	assert.Len(t, client.reviewers, len(assignment.RequiredReviewers)+len(assignment.OptionalReviewers), "Should only add the reviewers once")
+}
+
+func TestBalanceReviewReleasesClaim(t *testing.T) {
+	ctx := context.Background()
+	a, client, s, pr := newBalanceTest(t)
+
+	client.threadErr = errors.New("failed")
+	assert.Error(t, a.balanceReview(ctx, pr))
+
+	_, err := s.GetAssignment(ctx, pr.Repository.Id.String(), *pr.PullRequestId)
+	assert.True(t, errors.Is(err, store.ErrNotFound), "Should release the claim after a failure")
+
+	client.threadErr = nil
+	assert.NoError(t, a.balanceReview(ctx, pr))
+	assert.Equal(t, 1, client.threads, "Should balance the pull request again")
+}
+
+func TestBalanceReviewAbandonedClaim(t *testing.T) {
+	ctx := context.Background()
+	a, client, s, pr := newBalanceTest(t)
+
+	claim := &types.Assignment{
+		RepositoryID:  pr.Repository.Id.String(),
+		PullRequestID: *pr.PullRequestId,
+		Pending:       true,
+		Created:       time.Now().UTC(),
+	}
+	assert.NoError(t, s.ClaimAssignment(ctx, claim, claimStaleBefore()))
+
+	assert.NoError(t, a.balanceReview(ctx, pr))
+	assert.Equal(t, 0, client.threads, "Should skip pull requests claimed by another balancer")
+
+	claim.Created = time.Now().UTC().Add(-2 * assignmentClaimTimeout)
+	assert.NoError(t, s.UpdateAssignment(ctx, claim))
+
+	assert.NoError(t, a.balanceReview(ctx, pr))
+	assert.Equal(t, 1, client.threads, "Should take over an abandoned claim")
+}
+
+// newBalancerTest creates a balancer for an enabled repository using the selection strategy with alice and bob
+// as its owners. alice is a required reviewer on an active pull request in another enabled repository.
+func newBalancerTest(t *testing.T, selectionStrategy string) (*Balancer, *balanceGitClient, *memory.Store, *types.Repository) {
+	ctx := context.Background()
+	_, client, s, pr := newBalanceTest(t)
+
+	title := "Add feature"
+	active := adogit.PullRequestStatusValues.Active
+	pr.Title = &title
+	pr.Status = &active
+	client.pullRequest = &pr.GitPullRequest
+
+	required := true
+	aliceID := "alice-id"
+	client.pullRequests = map[string][]adogit.GitPullRequest{
+		"other-id": {{Status: &active, Reviewers: &[]adogit.IdentityRefWithVote{{Id: &aliceID, IsRequired: &required}}}},
+	}
+
+	repo := &types.Repository{Name: "repo", ProjectName: "project", AdoRepoID: "repo-id", Enabled: true, SelectionStrategy: selectionStrategy}
+	for _, r := range []*types.Repository{repo, {Name: "other", ProjectName: "project", AdoRepoID: "other-id", Enabled: true}} {
+		if err := s.AddRepository(ctx, r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return NewBalancer(s, s, s, s, client, nil, nil, Options{}), client, s, repo
+}
+
+// balancedReviewers balances the test pull request through the balancer and returns the required reviewers
+func balancedReviewers(t *testing.T, b *Balancer, client *balanceGitClient, s *memory.Store, repo *types.Repository) []string {
+	ctx := context.Background()
+	if err := b.BalancePullRequest(ctx, repo, *client.pullRequest.PullRequestId); err != nil {
+		t.Fatal(err)
+	}
+
+	assignment, err := s.GetAssignment(ctx, client.pullRequest.Repository.Id.String(), *client.pullRequest.PullRequestId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return assignment.RequiredReviewers
+}
+
+func TestBalancerLeastOpenReviews(t *testing.T) {
+	b, client, s, repo := newBalancerTest(t, types.SelectionStrategyLeastOpenReviews)
+
+	assert.Equal(t, []string{"bob"}, balancedReviewers(t, b, client, s, repo), "Should count the open reviews in every repository")
+}
+
+func TestBalancerSkipsAtCapacity(t *testing.T) {
+	ctx := context.Background()
+	b, client, s, repo := newBalancerTest(t, types.SelectionStrategyLRU)
+
+	alice, err := s.GetReviewer(ctx, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	alice.MaxOpenReviews = 1
+	if err := s.UpdateReviewer(ctx, alice); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, []string{"bob"}, balancedReviewers(t, b, client, s, repo), "Should skip reviewers at their open review cap")
+}
@@ -0,0 +1,216 @@
+package autoreviewer
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	adogit "github.com/microsoft/azure-devops-go-api/azuredevops/git"
+	"github.com/pkg/errors"
+)
+
+var (
+	// CodeOwnersPaths are the locations searched for the CODEOWNERS file, in order
+	CodeOwnersPaths = []string{"/.github/CODEOWNERS", "/CODEOWNERS", "/docs/CODEOWNERS"}
+)
+
+// CodeOwners is a parsed CODEOWNERS file, the last rule matching a path wins
+type CodeOwners struct {
+	rules []codeOwnersRule
+}
+
+type codeOwnersRule struct {
+	pattern string
+	line    int
+	re      *regexp.Regexp
+	group   *ReviewerGroup
+}
+
+// ParseCodeOwners parses a CODEOWNERS file. Each line is a gitignore style path pattern followed by owners,
+// @org/team owners are teams and @alias or alias@domain owners are reviewers.
+func ParseCodeOwners(content string) (*CodeOwners, error) {
+	codeOwners := &CodeOwners{}
+
+	for i, line := range strings.Split(content, "\n") {
+		if idx := strings.Index(line, "#"); idx >= 0 {
+			line = line[:idx]
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		re, err := codeOwnersPatternToRegexp(fields[0])
+		if err != nil {
+			return nil, errors.Wrapf(err, "line %d: invalid pattern %q", i+1, fields[0])
+		}
+
+		group := &ReviewerGroup{
+			Owners:   map[string]bool{},
+			Teams:    map[string]bool{},
+			NoNotify: map[string]bool{},
+		}
+		for _, owner := range fields[1:] {
+			switch {
+			case strings.HasPrefix(owner, "@") && strings.Contains(owner, "/"):
+				group.Teams[owner[strings.Index(owner, "/")+1:]] = true
+			case strings.HasPrefix(owner, "@"):
+				group.Owners[strings.TrimPrefix(owner, "@")] = true
+			default:
+				alias := parseEmailToAlias(owner)
+				if alias == "" {
+					return nil, errors.Errorf("line %d: invalid owner %q", i+1, owner)
+				}
+				group.Owners[alias] = true
+			}
+		}
+
+		codeOwners.rules = append(codeOwners.rules, codeOwnersRule{
+			pattern: fields[0],
+			line:    i + 1,
+			re:      re,
+			group:   group,
+		})
+	}
+
+	return codeOwners, nil
+}
+
+// Match returns the owners of the path from the last matching rule, nil when no rule matches
+func (c *CodeOwners) Match(path string) *ReviewerGroup {
+	path = strings.TrimPrefix(path, "/")
+
+	for i := len(c.rules) - 1; i >= 0; i-- {
+		if c.rules[i].re.MatchString(path) {
+			return c.rules[i].group
+		}
+	}
+
+	return nil
+}
+
+// setPath records the file and line of each rule as the source of its owners
+func (c *CodeOwners) setPath(path string) {
+	for _, rule := range c.rules {
+		rule.group.Files = []string{fmt.Sprintf("%s:%d", path, rule.line)}
+	}
+}
+
+// codeOwnersPatternToRegexp converts a gitignore style pattern to a regexp matching paths without a leading slash.
+// Patterns containing a slash are relative to the root, other patterns match at any depth. A pattern matching a
+// directory also matches everything under it, a trailing * only matches the directory's direct children.
+func codeOwnersPatternToRegexp(pattern string) (*regexp.Regexp, error) {
+	dirOnly := strings.HasSuffix(pattern, "/")
+	pattern = strings.TrimSuffix(pattern, "/")
+	anchored := strings.Contains(pattern, "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+
+	if pattern == "" {
+		return nil, errors.New("empty pattern")
+	}
+
+	var expr strings.Builder
+	if anchored {
+		expr.WriteString("^")
+	} else {
+		expr.WriteString("^(?:.*/)?")
+	}
+
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			expr.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			expr.WriteString(".*")
+			i++
+		case c == '*':
+			expr.WriteString("[^/]*")
+		case c == '?':
+			expr.WriteString("[^/]")
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	switch {
+	case dirOnly:
+		expr.WriteString("/.*$")
+	case strings.HasSuffix(pattern, "*"):
+		expr.WriteString("$")
+	default:
+		expr.WriteString("(?:/.*)?$")
+	}
+
+	return regexp.Compile(expr.String())
+}
+
+// loadCodeOwners reads and parses the first CODEOWNERS file found, readFile returns nil when a file doesn't exist
+func loadCodeOwners(ctx context.Context, readFile func(ctx context.Context, path string) (*string, error)) (*CodeOwners, error) {
+	for _, path := range CodeOwnersPaths {
+		content, err := readFile(ctx, path)
+		if err != nil {
+			return nil, err
+		}
+		if content == nil {
+			continue
+		}
+
+		codeOwners, err := ParseCodeOwners(*content)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse %s", path)
+		}
+		codeOwners.setPath(path)
+		return codeOwners, nil
+	}
+
+	return nil, errors.Wrap(errNotFound, "no CODEOWNERS file found")
+}
+
+// getCodeOwners gets and parses the repository's CODEOWNERS file at the commit, reading through the cache
+func getCodeOwners(ctx context.Context, client adogit.Client, cache *OwnersCache, repoID, commitID string) (*CodeOwners, error) {
+	return loadCodeOwners(ctx, newOwnersLoader(client, cache, repoID, commitID).readFile)
+}
+
+// getReviewerGroupsFromCodeOwners gets the owners of each changed path from the CODEOWNERS file at the target branch head
+func (pr *PullRequest) getReviewerGroupsFromCodeOwners(ctx context.Context, client adogit.Client, cache *OwnersCache, changePaths []string) ([]*ReviewerGroup, error) {
+	repoID := pr.Repository.Id.String()
+	commitID, err := getBranchHead(ctx, client, repoID, *pr.TargetRefName)
+	if err != nil {
+		return nil, err
+	}
+
+	codeOwners, err := getCodeOwners(ctx, client, cache, repoID, commitID)
+	if err != nil {
+		return nil, err
+	}
+
+	return codeOwners.reviewerGroups(changePaths), nil
+}
+
+// groups gets the owners of every rule
+func (c *CodeOwners) groups() []*ReviewerGroup {
+	groups := make([]*ReviewerGroup, 0, len(c.rules))
+	for _, rule := range c.rules {
+		groups = append(groups, rule.group)
+	}
+	return groups
+}
+
+// reviewerGroups gets the distinct owners of the changed paths
+func (c *CodeOwners) reviewerGroups(changePaths []string) []*ReviewerGroup {
+	seen := map[*ReviewerGroup]bool{}
+	var reviewerGroups []*ReviewerGroup
+	for _, path := range changePaths {
+		group := c.Match(path)
+		if group == nil || seen[group] {
+			continue
+		}
+		seen[group] = true
+		reviewerGroups = append(reviewerGroups, group)
+	}
+
+	return reviewerGroups
+}
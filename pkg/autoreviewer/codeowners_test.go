@@ -0,0 +1,116 @@
+package autoreviewer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCodeOwnersMatch(t *testing.T) {
+	codeOwners, err := ParseCodeOwners(`
+# Default owners
+*                   @alice
+*.js                @bob carol@example.com   # frontend
+/docs/              @org/Docs
+build/              @dave
+/src/**/test/*.go   @erin
+/src/vendor
+/scripts/*          @frank
+`)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	tests := []struct {
+		Name           string
+		Path           string
+		ExpectedOwners []string
+		ExpectedTeams  []string
+	}{
+		{
+			Name:           "Default Owner",
+			Path:           "/main.go",
+			ExpectedOwners: []string{"alice"},
+		},
+		{
+			Name:           "Extension At Any Depth",
+			Path:           "/web/app/index.js",
+			ExpectedOwners: []string{"bob", "carol"},
+		},
+		{
+			Name:          "Anchored Directory Team",
+			Path:          "/docs/guide/readme.md",
+			ExpectedTeams: []string{"Docs"},
+		},
+		{
+			Name:          "Last Match Wins",
+			Path:          "/docs/app.js",
+			ExpectedTeams: []string{"Docs"},
+		},
+		{
+			Name:           "Anchored Directory Does Not Match Nested",
+			Path:           "/src/docs/readme.md",
+			ExpectedOwners: []string{"alice"},
+		},
+		{
+			Name:           "Unanchored Directory",
+			Path:           "/tools/build/make.sh",
+			ExpectedOwners: []string{"dave"},
+		},
+		{
+			Name:           "Double Star",
+			Path:           "/src/pkg/a/test/a_test.go",
+			ExpectedOwners: []string{"erin"},
+		},
+		{
+			Name:           "Double Star Matches No Directories",
+			Path:           "/src/test/a_test.go",
+			ExpectedOwners: []string{"erin"},
+		},
+		{
+			Name:           "Single Star Matches Children",
+			Path:           "/scripts/build.sh",
+			ExpectedOwners: []string{"frank"},
+		},
+		{
+			Name:           "Single Star Does Not Match Nested",
+			Path:           "/scripts/ci/build.sh",
+			ExpectedOwners: []string{"alice"},
+		},
+		{
+			Name: "Unowned",
+			Path: "/src/vendor/lib.go",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			group := codeOwners.Match(tt.Path)
+			if !assert.NotNil(t, group, "Should match a rule") {
+				return
+			}
+
+			assert.Equal(t, len(tt.ExpectedOwners), len(group.Owners), "Should have expected number of owners")
+			for _, owner := range tt.ExpectedOwners {
+				assert.True(t, group.Owners[owner], "Should have owner %q", owner)
+			}
+
+			assert.Equal(t, len(tt.ExpectedTeams), len(group.Teams), "Should have expected number of teams")
+			for _, team := range tt.ExpectedTeams {
+				assert.True(t, group.Teams[team], "Should have team %q", team)
+			}
+		})
+	}
+}
+
+func TestParseCodeOwnersErrors(t *testing.T) {
+	_, err := ParseCodeOwners("*.go @alice\n/ @bob\n")
+	assert.Error(t, err, "Should reject an empty pattern")
+
+	_, err = ParseCodeOwners("*.go not-an-owner\n")
+	assert.Error(t, err, "Should reject an invalid owner")
+
+	codeOwners, err := ParseCodeOwners("/src/ @alice\n")
+	assert.NoError(t, err)
+	assert.Nil(t, codeOwners.Match("/main.go"), "Should not match paths without a rule")
+}
@@ -0,0 +1,177 @@
+package autoreviewer
+
+import (
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+
+	"github.com/samkreter/devopshelper/pkg/types"
+)
+
+// DefaultCommentTemplate is the reviewer comment used when the repository doesn't configure one
+const DefaultCommentTemplate = `Hello {{ aliases .Required }},
+
+You are selected as the **required** code reviewers of this change.
+
+{{ range .Required }}{{ if .Reason }}- {{ .Alias }}: {{ .Reason }}
+{{ end }}{{ end }}{{ if hasReasons .Required }}
+{{ end }}Your responsibility is to review **each** iteration of this CR until signoff. You should provide no more than 48 hour SLA for each iteration.
+
+Thank you.
+
+CR Balancer
+`
+
+// CommentData is the data the reviewer comment template is executed with
+type CommentData struct {
+	Required []CommentReviewer
+	// Optional are the optional reviewers that can be mentioned, no notify owners are left out
+	Optional []CommentReviewer
+	// NoNotify are the aliases of the no notify owners added as optional reviewers, they must not be mentioned
+	NoNotify    []string
+	PullRequest CommentPullRequest
+	Repository  string
+	Project     string
+	// OwnersFiles are the owners files that matched the changed files
+	OwnersFiles []string
+}
+
+// CommentReviewer is a selected reviewer in the comment template
+type CommentReviewer struct {
+	Alias string
+	AdoID string
+	// Mention notifies the reviewer using the ADO identity syntax, the alias when the ADO ID isn't known
+	Mention string
+	Reason  string
+}
+
+// CommentPullRequest is the pull request in the comment template
+type CommentPullRequest struct {
+	ID           int
+	Title        string
+	URL          string
+	Author       string
+	SourceBranch string
+	TargetBranch string
+}
+
+var commentFuncs = template.FuncMap{
+	"aliases": func(reviewers []CommentReviewer) string {
+		aliases := make([]string, 0, len(reviewers))
+		for _, reviewer := range reviewers {
+			aliases = append(aliases, reviewer.Alias)
+		}
+		return strings.Join(aliases, ",")
+	},
+	"mentions": func(reviewers []CommentReviewer) string {
+		mentions := make([]string, 0, len(reviewers))
+		for _, reviewer := range reviewers {
+			mentions = append(mentions, reviewer.Mention)
+		}
+		return strings.Join(mentions, " ")
+	},
+	"hasReasons": func(reviewers []CommentReviewer) bool {
+		for _, reviewer := range reviewers {
+			if reviewer.Reason != "" {
+				return true
+			}
+		}
+		return false
+	},
+	"join": strings.Join,
+}
+
+// ParseCommentTemplate parses a reviewer comment template, an empty template is the default comment
+func ParseCommentTemplate(text string) (*template.Template, error) {
+	if text == "" {
+		text = DefaultCommentTemplate
+	}
+
+	tmpl, err := template.New("comment").Funcs(commentFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid comment template")
+	}
+
+	return tmpl, nil
+}
+
+// ValidateCommentTemplate checks the template parses and executes with example data
+func ValidateCommentTemplate(text string) error {
+	tmpl, err := ParseCommentTemplate(text)
+	if err != nil {
+		return err
+	}
+
+	example := CommentData{
+		Required:    []CommentReviewer{newCommentReviewer(&types.Reviewer{Alias: "alias", AdoID: "ado-id"}, "owner")},
+		PullRequest: CommentPullRequest{ID: 1},
+		OwnersFiles: []string{"/owners.txt"},
+	}
+	if err := tmpl.Execute(&strings.Builder{}, example); err != nil {
+		return errors.Wrap(err, "invalid comment template")
+	}
+
+	return nil
+}
+
+// renderComment executes the template, windows line endings are used since that's what ADO expects
+func renderComment(text string, data CommentData) (string, error) {
+	tmpl, err := ParseCommentTemplate(text)
+	if err != nil {
+		return "", err
+	}
+
+	var comment strings.Builder
+	if err := tmpl.Execute(&comment, data); err != nil {
+		return "", errors.Wrap(err, "failed to execute comment template")
+	}
+
+	content := strings.Replace(comment.String(), "\r\n", "\n", -1)
+	return strings.Replace(content, "\n", "\r\n", -1), nil
+}
+
+func newCommentReviewer(reviewer *types.Reviewer, reason string) CommentReviewer {
+	mention := "@" + reviewer.Alias
+	if reviewer.AdoID != "" {
+		mention = "@<" + reviewer.AdoID + ">"
+	}
+
+	return CommentReviewer{
+		Alias:   reviewer.Alias,
+		AdoID:   reviewer.AdoID,
+		Mention: mention,
+		Reason:  reason,
+	}
+}
+
+func newCommentReviewers(reviewers []*types.Reviewer, reasons map[string]string) []CommentReviewer {
+	commentReviewers := make([]CommentReviewer, 0, len(reviewers))
+	for _, reviewer := range reviewers {
+		commentReviewers = append(commentReviewers, newCommentReviewer(reviewer, reasons[reviewer.Alias]))
+	}
+	return commentReviewers
+}
+
+func newCommentPullRequest(pr *PullRequest) CommentPullRequest {
+	commentPR := CommentPullRequest{}
+	if pr.PullRequestId != nil {
+		commentPR.ID = *pr.PullRequestId
+	}
+	if pr.Title != nil {
+		commentPR.Title = *pr.Title
+	}
+	if pr.Url != nil {
+		commentPR.URL = *pr.Url
+	}
+	if pr.CreatedBy != nil && pr.CreatedBy.DisplayName != nil {
+		commentPR.Author = *pr.CreatedBy.DisplayName
+	}
+	if pr.SourceRefName != nil {
+		commentPR.SourceBranch = strings.TrimPrefix(*pr.SourceRefName, "refs/heads/")
+	}
+	if pr.TargetRefName != nil {
+		commentPR.TargetBranch = strings.TrimPrefix(*pr.TargetRefName, "refs/heads/")
+	}
+	return commentPR
+}
@@ -0,0 +1,60 @@
+package autoreviewer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/samkreter/devopshelper/pkg/types"
+)
+
+func TestRenderComment(t *testing.T) {
+	reviewers := []*types.Reviewer{
+		{Alias: "alice", AdoID: "alice-id"},
+		{Alias: "bob"},
+	}
+	data := CommentData{
+		Required:    newCommentReviewers(reviewers, map[string]string{"alice": "owner of the changed files"}),
+		PullRequest: CommentPullRequest{ID: 7, Title: "Fix the build"},
+		OwnersFiles: []string{"/owners.txt", "/src/owners.txt"},
+	}
+
+	tests := []struct {
+		Name            string
+		Template        string
+		ExpectedComment string
+	}{
+		{
+			Name:     "Default",
+			Template: "",
+			ExpectedComment: "Hello alice,bob,\r\n\r\n" +
+				"You are selected as the **required** code reviewers of this change.\r\n\r\n" +
+				"- alice: owner of the changed files\r\n\r\n" +
+				"Your responsibility is to review **each** iteration of this CR until signoff. You should provide no more than 48 hour SLA for each iteration.\r\n\r\n" +
+				"Thank you.\r\n\r\n" +
+				"CR Balancer\r\n",
+		},
+		{
+			Name: "Custom",
+			Template: "{{ mentions .Required }} please review !{{ .PullRequest.ID }} {{ .PullRequest.Title }}\n" +
+				"Matched: {{ join .OwnersFiles \", \" }}\n",
+			ExpectedComment: "@<alice-id> @bob please review !7 Fix the build\r\n" +
+				"Matched: /owners.txt, /src/owners.txt\r\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			comment, err := renderComment(tt.Template, data)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.ExpectedComment, comment)
+		})
+	}
+}
+
+func TestValidateCommentTemplate(t *testing.T) {
+	assert.NoError(t, ValidateCommentTemplate(""), "Should accept the default template")
+	assert.NoError(t, ValidateCommentTemplate("{{ range .Required }}{{ .Mention }} {{ end }}"))
+	assert.Error(t, ValidateCommentTemplate("{{ .Required"), "Should reject templates that don't parse")
+	assert.Error(t, ValidateCommentTemplate("{{ .Reviewers }}"), "Should reject unknown fields")
+}
@@ -0,0 +1,172 @@
+package autoreviewer
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	adogit "github.com/microsoft/azure-devops-go-api/azuredevops/git"
+	"github.com/pkg/errors"
+	"github.com/samkreter/go-core/log"
+
+	"github.com/samkreter/devopshelper/pkg/store"
+	"github.com/samkreter/devopshelper/pkg/types"
+)
+
+const (
+	assignmentReasonDryRun = "dry run"
+
+	previewCommentHeader = "Reviewer assignment preview, no reviewers have been added. " +
+		"This comment will be posted once CR Balancer is enabled for this repository:\r\n\r\n"
+)
+
+// peekReviewerStore reads through to the reviewer store but keeps updates in memory, so selecting reviewers
+// doesn't change the rotation
+type peekReviewerStore struct {
+	store.ReviewerStore
+
+	mu      sync.Mutex
+	updated map[string]*types.Reviewer
+}
+
+func newPeekReviewerStore(reviewerStore store.ReviewerStore) *peekReviewerStore {
+	return &peekReviewerStore{
+		ReviewerStore: reviewerStore,
+		updated:       map[string]*types.Reviewer{},
+	}
+}
+
+// GetReviewer gets the reviewer with any peeked updates
+func (s *peekReviewerStore) GetReviewer(ctx context.Context, alias string) (*types.Reviewer, error) {
+	s.mu.Lock()
+	reviewer, ok := s.updated[alias]
+	s.mu.Unlock()
+
+	if ok {
+		peeked := *reviewer
+		return &peeked, nil
+	}
+	return s.ReviewerStore.GetReviewer(ctx, alias)
+}
+
+// UpdateReviewer keeps the update in memory
+func (s *peekReviewerStore) UpdateReviewer(ctx context.Context, reviewer *types.Reviewer) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	peeked := *reviewer
+	s.updated[reviewer.Alias] = &peeked
+	return nil
+}
+
+// RecordReview records the review in memory
+func (s *peekReviewerStore) RecordReview(ctx context.Context, alias string, now time.Time) (*types.Reviewer, error) {
+	reviewer, err := s.GetReviewer(ctx, alias)
+	if err != nil {
+		return nil, err
+	}
+
+	reviewer.RecordReview(now)
+	if err := s.UpdateReviewer(ctx, reviewer); err != nil {
+		return nil, err
+	}
+	return reviewer, nil
+}
+
+// PopLRUReviewer selects the least recently used available reviewers, only updating them in memory
+func (s *peekReviewerStore) PopLRUReviewer(ctx context.Context, aliases []string, count int) ([]*types.Reviewer, error) {
+	if count <= 0 {
+		return nil, nil
+	}
+
+	candidates, err := getCandidates(ctx, s, aliases)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].LastReviewTime.Before(candidates[j].LastReviewTime)
+	})
+	if len(candidates) > count {
+		candidates = candidates[:count]
+	}
+
+	return markReviewed(ctx, s, candidates, count)
+}
+
+// dryRun returns true when reviewers should only be previewed for the repository
+func (a *AutoReviewer) dryRun() bool {
+	return a.Options.DryRun || a.Repo.DryRun
+}
+
+// previewReview selects the reviewers without adding them or changing the rotation, the would be assignment is
+// logged and recorded on the claim so the pull request is only previewed once
+func (a *AutoReviewer) previewReview(ctx context.Context, pr *PullRequest, claim *types.Assignment) error {
+	logger := log.G(ctx)
+
+	if a.peekStore == nil {
+		a.peekStore = newPeekReviewerStore(a.ReviewerStore)
+	}
+
+	preview := *a
+	preview.ReviewerStore = a.peekStore
+
+	selection, err := preview.getReviewers(ctx, pr)
+	if err != nil {
+		return errors.Wrap(err, "failed to get reviewers")
+	}
+
+	if a.Options.DryRunPreviewComment || a.Repo.DryRunPreviewComment {
+		if err := a.addPreviewComment(ctx, pr, selection); err != nil {
+			return errors.Wrap(err, "failed to add preview comment")
+		}
+	}
+
+	a.completeAssignment(ctx, claim, selection.Required, selection.Optional, assignmentReasonDryRun)
+
+	logger.Infof("Dry run: would add %s as required reviewers and %s as observer to PR: %d",
+		GetReviewersAlias(selection.Required),
+		GetReviewersAlias(selection.Optional),
+		*pr.PullRequestId)
+
+	return nil
+}
+
+// addPreviewComment posts the repository's reviewer comment for the would be reviewers as a closed thread. The
+// reviewers are named by alias instead of mentioned so the preview doesn't notify them, and the bot identifier is
+// left out so the pull request is still balanced once dry run is turned off.
+func (a *AutoReviewer) addPreviewComment(ctx context.Context, pr *PullRequest, selection *ReviewerSelection) error {
+	data := a.newCommentData(pr, selection)
+	for _, reviewers := range [][]CommentReviewer{data.Required, data.Optional} {
+		for i := range reviewers {
+			reviewers[i].Mention = reviewers[i].Alias
+		}
+	}
+
+	content, err := renderComment(a.Repo.CommentTemplate, data)
+	if err != nil {
+		return err
+	}
+	comment := previewCommentHeader + content
+
+	repoID := pr.Repository.Id.String()
+	closed := adogit.CommentThreadStatusValues.Closed
+	_, err = a.adoGitClient.CreateThread(ctx, adogit.CreateThreadArgs{
+		RepositoryId:  &repoID,
+		PullRequestId: pr.PullRequestId,
+		CommentThread: &adogit.GitPullRequestCommentThread{
+			Status: &closed,
+			Comments: &[]adogit.Comment{
+				{
+					Content: &comment,
+				},
+			},
+		},
+	})
+	if err != nil {
+		return errors.Wrap(ParseADOError(err), "failed to create preview thread")
+	}
+
+	return nil
+}
@@ -0,0 +1,141 @@
+package autoreviewer
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	adogit "github.com/microsoft/azure-devops-go-api/azuredevops/git"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/samkreter/devopshelper/pkg/store"
+	"github.com/samkreter/devopshelper/pkg/store/memory"
+	"github.com/samkreter/devopshelper/pkg/types"
+)
+
+func TestPeekReviewerStore(t *testing.T) {
+	ctx := context.Background()
+	base := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	reviewerStore := memory.NewStore()
+	for i, alias := range []string{"alice", "bob", "carol"} {
+		assert.NoError(t, reviewerStore.AddReviewer(ctx, &types.Reviewer{Alias: alias, LastReviewTime: base.Add(time.Duration(i) * time.Hour)}))
+	}
+	assert.NoError(t, reviewerStore.AddReviewer(ctx, &types.Reviewer{Alias: "dave", Paused: true}))
+
+	peek := newPeekReviewerStore(reviewerStore)
+	aliases := []string{"alice", "bob", "carol", "dave"}
+
+	reviewers, err := peek.PopLRUReviewer(ctx, aliases, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"alice", "bob"}, GetReviewersAlias(reviewers))
+
+	reviewers, err = peek.PopLRUReviewer(ctx, aliases, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"carol", "alice"}, GetReviewersAlias(reviewers), "Should rotate using the peeked updates")
+
+	stored, err := reviewerStore.GetReviewer(ctx, "alice")
+	assert.NoError(t, err)
+	assert.Equal(t, base, stored.LastReviewTime, "Should not update the reviewer store")
+
+	_, err = peek.PopLRUReviewer(ctx, []string{"dave"}, 1)
+	assert.True(t, errors.Is(err, store.ErrNotFound), "Should skip unavailable reviewers")
+}
+
+func TestIsBalancedDryRun(t *testing.T) {
+	ctx := context.Background()
+	repoID := uuid.New()
+	pullRequestID := 1
+	pr := &PullRequest{GitPullRequest: adogit.GitPullRequest{
+		Repository:    &adogit.GitRepository{Id: &repoID},
+		PullRequestId: &pullRequestID,
+	}}
+
+	assignmentStore := memory.NewStore()
+	a := &AutoReviewer{
+		Repo:            &types.Repository{DryRun: true},
+		AssignmentStore: assignmentStore,
+	}
+
+	a.recordAssignment(ctx, pr, nil, nil, assignmentReasonDryRun)
+
+	balanced, err := a.isBalanced(ctx, pr)
+	assert.NoError(t, err)
+	assert.True(t, balanced, "Should only preview a pull request once")
+
+	a.Repo.DryRun = false
+	balanced, err = a.isBalanced(ctx, pr)
+	assert.NoError(t, err)
+	assert.False(t, balanced, "Should balance previewed pull requests once dry run is off")
+
+	_, err = assignmentStore.GetAssignment(ctx, repoID.String(), pullRequestID)
+	assert.True(t, errors.Is(err, store.ErrNotFound), "Should remove the dry run assignment")
+}
+
+func TestPreviewReview(t *testing.T) {
+	ctx := context.Background()
+	a, client, s, pr := newBalanceTest(t)
+	a.Repo.DryRun = true
+	a.Repo.DryRunPreviewComment = true
+	a.Repo.SelectionStrategy = types.SelectionStrategyRandom
+	a.Repo.SelectionSeed = 7
+	a.Repo.CommentTemplate = "Would assign {{ mentions .Required }} to {{ .Repository }}"
+
+	if !assert.NoError(t, a.balanceReview(ctx, pr)) {
+		return
+	}
+	assert.Empty(t, client.reviewers, "Should not add reviewers")
+
+	assignment, err := s.GetAssignment(ctx, pr.Repository.Id.String(), *pr.PullRequestId)
+	if !assert.NoError(t, err) || !assert.Len(t, assignment.RequiredReviewers, 1) {
+		return
+	}
+	previewed := assignment.RequiredReviewers[0]
+
+	if assert.Len(t, client.comments, 1, "Should post the preview comment") {
+		comment := client.comments[0]
+		assert.True(t, strings.HasPrefix(comment, previewCommentHeader), "Should say the comment is a preview")
+		assert.True(t, strings.HasSuffix(comment, "Would assign "+previewed+" to repo"), "Should render the comment template without mentions, got: %s", comment)
+	}
+
+	for _, alias := range []string{"alice", "bob"} {
+		reviewer, err := s.GetReviewer(ctx, alias)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, reviewer.Reviews, "Should not record reviews for %q", alias)
+	}
+
+	// The real run starts from the same stored state so it selects the previewed reviewer
+	a.Repo.DryRun = false
+	if !assert.NoError(t, a.balanceReview(ctx, pr)) {
+		return
+	}
+	assert.Equal(t, []string{previewed + "-id"}, client.reviewers, "Should add the previewed reviewer")
+}
+
+func TestPreviewReviewEachRun(t *testing.T) {
+	ctx := context.Background()
+	a, _, s, pr := newBalanceTest(t)
+	a.Repo.DryRun = true
+
+	title := "Add feature"
+	newPullRequest := func(id int) adogit.GitPullRequest {
+		pullRequest := pr.GitPullRequest
+		pullRequest.PullRequestId = &id
+		pullRequest.Title = &title
+		return pullRequest
+	}
+
+	for _, id := range []int{1, 2} {
+		if !assert.NoError(t, a.balancePullRequests(ctx, []adogit.GitPullRequest{newPullRequest(id)})) {
+			return
+		}
+
+		assignment, err := s.GetAssignment(ctx, pr.Repository.Id.String(), id)
+		if assert.NoError(t, err) {
+			assert.Equal(t, []string{"alice"}, assignment.RequiredReviewers, "Should preview each run from the stored reviewers")
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package autoreviewer
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	adogit "github.com/microsoft/azure-devops-go-api/azuredevops/git"
+	"github.com/pkg/errors"
+
+	"github.com/samkreter/devopshelper/pkg/store"
+	"github.com/samkreter/devopshelper/pkg/types"
+)
+
+const (
+	// expertiseLookback is how far back commits count towards expertise
+	expertiseLookback = 90 * 24 * time.Hour
+	// expertiseHalfLife is how long until a commit counts half as much
+	expertiseHalfLife = 30 * 24 * time.Hour
+
+	// Bound the commit history requests made for large pull requests
+	maxExpertisePaths = 25
+	maxCommitsPerPath = 100
+)
+
+// ExpertiseScore is a reviewer's recent authorship of the changed files
+type ExpertiseScore struct {
+	Score   float64
+	Commits int
+}
+
+// Expertise holds the expertise scores keyed by alias
+type Expertise map[string]*ExpertiseScore
+
+// getExpertise scores the authors of recent commits to the paths, newer commits score higher
+func getExpertise(ctx context.Context, client adogit.Client, repoID string, paths []string, now time.Time) (Expertise, error) {
+	if len(paths) > maxExpertisePaths {
+		paths = paths[:maxExpertisePaths]
+	}
+
+	fromDate := now.Add(-expertiseLookback).Format(time.RFC3339)
+	top := maxCommitsPerPath
+
+	expertise := Expertise{}
+	seen := map[string]bool{}
+	for _, path := range paths {
+		itemPath := path
+		commits, err := client.GetCommits(ctx, adogit.GetCommitsArgs{
+			RepositoryId: &repoID,
+			SearchCriteria: &adogit.GitQueryCommitsCriteria{
+				ItemPath: &itemPath,
+				FromDate: &fromDate,
+				Top:      &top,
+			},
+		})
+		if err != nil {
+			return nil, errors.Wrapf(ParseADOError(err), "failed to get commits for %s", path)
+		}
+		if commits == nil {
+			continue
+		}
+
+		for _, commit := range *commits {
+			if commit.CommitId == nil || commit.Author == nil || commit.Author.Email == nil || commit.Author.Date == nil {
+				continue
+			}
+			expertise.addCommit(seen, *commit.CommitId, *commit.Author.Email, commit.Author.Date.Time, now)
+		}
+	}
+
+	return expertise, nil
+}
+
+// addCommit scores the commit for its author, a commit touching several of the changed files only counts once
+func (e Expertise) addCommit(seen map[string]bool, commitID, email string, date, now time.Time) {
+	if seen[commitID] {
+		return
+	}
+	seen[commitID] = true
+
+	alias := parseEmailToAlias(email)
+	if alias == "" {
+		return
+	}
+
+	score, ok := e[alias]
+	if !ok {
+		score = &ExpertiseScore{}
+		e[alias] = score
+	}
+
+	age := now.Sub(date)
+	if age < 0 {
+		age = 0
+	}
+	score.Score += math.Pow(0.5, float64(age)/float64(expertiseHalfLife))
+	score.Commits++
+}
+
+// ExpertiseStrategy selects reviewers by blending their expertise in the changed files with least recently used
+// fairness. A weight of 1 only uses expertise and a weight of 0 is the same as LRU.
+type ExpertiseStrategy struct {
+	reviewerStore store.ReviewerStore
+	expertise     Expertise
+	weight        float64
+}
+
+// NewExpertiseStrategy creates a new expertise selection strategy
+func NewExpertiseStrategy(reviewerStore store.ReviewerStore, expertise Expertise, weight float64) *ExpertiseStrategy {
+	return &ExpertiseStrategy{
+		reviewerStore: reviewerStore,
+		expertise:     expertise,
+		weight:        weight,
+	}
+}
+
+// Select selects the reviewers with the highest blended score
+func (s *ExpertiseStrategy) Select(ctx context.Context, aliases []string, count int) ([]*types.Reviewer, error) {
+	candidates, err := getCandidates(ctx, s.reviewerStore, aliases)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fairness ranks from 1 for the least recently used reviewer down to 0 for the most recent
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].LastReviewTime.Before(candidates[j].LastReviewTime)
+	})
+
+	maxExpertise := 0.0
+	for _, candidate := range candidates {
+		if score := s.expertise[candidate.Alias]; score != nil && score.Score > maxExpertise {
+			maxExpertise = score.Score
+		}
+	}
+
+	scores := make(map[string]float64, len(candidates))
+	for i, candidate := range candidates {
+		fairness := 1.0
+		if len(candidates) > 1 {
+			fairness = 1 - float64(i)/float64(len(candidates)-1)
+		}
+
+		expertise := 0.0
+		if score := s.expertise[candidate.Alias]; score != nil && maxExpertise > 0 {
+			expertise = score.Score / maxExpertise
+		}
+
+		scores[candidate.Alias] = s.weight*expertise + (1-s.weight)*fairness
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return scores[candidates[i].Alias] > scores[candidates[j].Alias]
+	})
+
+	if len(candidates) > count {
+		candidates = candidates[:count]
+	}
+
+	return markReviewed(ctx, s.reviewerStore, candidates, count)
+}
+
+// Reason explains the reviewer's expertise, empty when they have none
+func (s *ExpertiseStrategy) Reason(alias string) string {
+	score := s.expertise[alias]
+	if score == nil || score.Commits == 0 {
+		return ""
+	}
+
+	if score.Commits == 1 {
+		return "authored 1 recent commit to the changed files"
+	}
+	return fmt.Sprintf("authored %d recent commits to the changed files", score.Commits)
+}
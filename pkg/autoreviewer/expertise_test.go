@@ -0,0 +1,126 @@
+package autoreviewer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/microsoft/azure-devops-go-api/azuredevops"
+	adogit "github.com/microsoft/azure-devops-go-api/azuredevops/git"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/samkreter/devopshelper/pkg/types"
+)
+
+// fakeGitClient returns the commits and file contents for each item path
+type fakeGitClient struct {
+	adogit.Client
+	commits map[string][]adogit.GitCommitRef
+	items   map[string]string
+	head    string
+	// itemGets counts the GetItem calls
+	itemGets int
+}
+
+func (c *fakeGitClient) GetBranch(ctx context.Context, args adogit.GetBranchArgs) (*adogit.GitBranchStats, error) {
+	return &adogit.GitBranchStats{Name: args.Name, Commit: &adogit.GitCommitRef{CommitId: &c.head}}, nil
+}
+
+func (c *fakeGitClient) GetItem(ctx context.Context, args adogit.GetItemArgs) (*adogit.GitItem, error) {
+	c.itemGets++
+	content, ok := c.items[*args.Path]
+	if !ok {
+		statusCode := 404
+		return nil, azuredevops.WrappedError{StatusCode: &statusCode}
+	}
+	return &adogit.GitItem{Path: args.Path, Content: &content}, nil
+}
+
+func (c *fakeGitClient) GetCommits(ctx context.Context, args adogit.GetCommitsArgs) (*[]adogit.GitCommitRef, error) {
+	commits := c.commits[*args.SearchCriteria.ItemPath]
+	return &commits, nil
+}
+
+func newCommit(id, email string, date time.Time) adogit.GitCommitRef {
+	return adogit.GitCommitRef{
+		CommitId: &id,
+		Author: &adogit.GitUserDate{
+			Email: &email,
+			Date:  &azuredevops.Time{Time: date},
+		},
+	}
+}
+
+func TestGetExpertise(t *testing.T) {
+	now := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+	client := &fakeGitClient{commits: map[string][]adogit.GitCommitRef{
+		"/a.go": {
+			newCommit("1", "alice@example.com", now),
+			newCommit("2", "bob@example.com", now.Add(-expertiseHalfLife)),
+		},
+		"/b.go": {
+			newCommit("1", "alice@example.com", now),
+			newCommit("3", "alice@example.com", now.Add(-2*expertiseHalfLife)),
+			newCommit("4", "invalid", now),
+		},
+	}}
+
+	expertise, err := getExpertise(context.Background(), client, "repo-id", []string{"/a.go", "/b.go"}, now)
+	assert.NoError(t, err)
+
+	assert.Equal(t, 2, len(expertise))
+	assert.Equal(t, 2, expertise["alice"].Commits, "Should count a commit to several changed files once")
+	assert.InDelta(t, 1.25, expertise["alice"].Score, 0.001)
+	assert.Equal(t, 1, expertise["bob"].Commits)
+	assert.InDelta(t, 0.5, expertise["bob"].Score, 0.001, "Should halve the score after the half life")
+}
+
+func TestExpertiseStrategy(t *testing.T) {
+	base := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	expertise := Expertise{
+		"carol": {Score: 2, Commits: 3},
+		"bob":   {Score: 0.5, Commits: 1},
+	}
+
+	tests := []struct {
+		Name             string
+		Weight           float64
+		ExpectedSelected []string
+	}{
+		{
+			Name:             "Only Fairness",
+			Weight:           0,
+			ExpectedSelected: []string{"alice", "bob", "carol"},
+		},
+		{
+			Name:             "Only Expertise",
+			Weight:           1,
+			ExpectedSelected: []string{"carol", "bob", "alice"},
+		},
+		{
+			Name:             "Blended",
+			Weight:           0.6,
+			ExpectedSelected: []string{"carol", "alice", "bob"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			s := newFakeReviewerStore(
+				&types.Reviewer{Alias: "alice", LastReviewTime: base},
+				&types.Reviewer{Alias: "bob", LastReviewTime: base.Add(time.Hour)},
+				&types.Reviewer{Alias: "carol", LastReviewTime: base.Add(2 * time.Hour)},
+			)
+			strategy := NewExpertiseStrategy(s, expertise, tt.Weight)
+
+			reviewers, err := strategy.Select(context.Background(), []string{"alice", "bob", "carol"}, 3)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.ExpectedSelected, GetReviewersAlias(reviewers))
+		})
+	}
+
+	strategy := NewExpertiseStrategy(newFakeReviewerStore(), expertise, 1)
+	assert.Equal(t, "authored 3 recent commits to the changed files", strategy.Reason("carol"))
+	assert.Equal(t, "authored 1 recent commit to the changed files", strategy.Reason("bob"))
+	assert.Equal(t, "", strategy.Reason("alice"))
+}
@@ -0,0 +1,209 @@
+package autoreviewer
+
+import (
+	"context"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	adogit "github.com/microsoft/azure-devops-go-api/azuredevops/git"
+	"github.com/pkg/errors"
+
+	"github.com/samkreter/devopshelper/pkg/types"
+)
+
+const (
+	// changedFilesTimeout bounds getting the changes for the minimum changed files filter
+	changedFilesTimeout = 30 * time.Second
+)
+
+// CompileFilters builds the filters for the repository's filter config, the default filters are
+// returned when the repository doesn't configure any. The client is only used for the minimum changed
+// files filter.
+func CompileFilters(config *types.PullRequestFilters, client adogit.Client) ([]Filter, error) {
+	if config == nil {
+		return defaultFilters, nil
+	}
+
+	if config.MinChangedFiles < 0 {
+		return nil, errors.New("minimum changed files must not be negative")
+	}
+
+	filters := []Filter{}
+
+	if !config.IncludeDrafts {
+		filters = append(filters, filterDraft)
+	}
+
+	if len(config.TargetBranches) > 0 {
+		for _, pattern := range config.TargetBranches {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, errors.Wrapf(err, "invalid target branch pattern '%s'", pattern)
+			}
+		}
+		filters = append(filters, filterTargetBranches(config.TargetBranches))
+	}
+
+	if len(config.IncludeTitles) > 0 {
+		include, err := compileRegexps(config.IncludeTitles)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid include title")
+		}
+		filters = append(filters, func(ctx context.Context, pr *PullRequest) bool {
+			return !matchAny(include, pullRequestTitle(pr))
+		})
+	}
+
+	if len(config.ExcludeTitles) > 0 {
+		exclude, err := compileRegexps(config.ExcludeTitles)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid exclude title")
+		}
+		filters = append(filters, func(ctx context.Context, pr *PullRequest) bool {
+			return matchAny(exclude, pullRequestTitle(pr))
+		})
+	}
+
+	if len(config.AllowAuthors) > 0 {
+		allow := config.AllowAuthors
+		filters = append(filters, func(ctx context.Context, pr *PullRequest) bool {
+			return !containsFold(allow, pullRequestAuthor(pr))
+		})
+	}
+
+	if len(config.DenyAuthors) > 0 {
+		deny := config.DenyAuthors
+		filters = append(filters, func(ctx context.Context, pr *PullRequest) bool {
+			return containsFold(deny, pullRequestAuthor(pr))
+		})
+	}
+
+	if len(config.RequireLabels) > 0 {
+		require := config.RequireLabels
+		filters = append(filters, func(ctx context.Context, pr *PullRequest) bool {
+			for _, label := range pullRequestLabels(pr) {
+				if containsFold(require, label) {
+					return false
+				}
+			}
+			return true
+		})
+	}
+
+	if len(config.ExcludeLabels) > 0 {
+		exclude := config.ExcludeLabels
+		filters = append(filters, func(ctx context.Context, pr *PullRequest) bool {
+			for _, label := range pullRequestLabels(pr) {
+				if containsFold(exclude, label) {
+					return true
+				}
+			}
+			return false
+		})
+	}
+
+	// Getting the changes calls ADO so it's checked after all the other filters
+	if config.MinChangedFiles > 0 {
+		filters = append(filters, filterMinChangedFiles(client, config.MinChangedFiles))
+	}
+
+	return filters, nil
+}
+
+// ValidateFilters checks the filter config compiles
+func ValidateFilters(config *types.PullRequestFilters) error {
+	_, err := CompileFilters(config, nil)
+	return err
+}
+
+func filterTargetBranches(patterns []string) Filter {
+	return func(ctx context.Context, pr *PullRequest) bool {
+		if pr.TargetRefName == nil {
+			return true
+		}
+
+		branch := strings.TrimPrefix(*pr.TargetRefName, "refs/heads/")
+		for _, pattern := range patterns {
+			if matched, _ := path.Match(pattern, branch); matched {
+				return false
+			}
+		}
+
+		return true
+	}
+}
+
+func filterMinChangedFiles(client adogit.Client, minChangedFiles int) Filter {
+	return func(ctx context.Context, pr *PullRequest) bool {
+		ctx, cancel := context.WithTimeout(ctx, changedFilesTimeout)
+		defer cancel()
+
+		// Let the pull request through on errors, getting the reviewers reports the failure. The changes are
+		// kept on the pull request so getting the reviewers doesn't read them again.
+		changes, err := pr.GetAllChanges(ctx, client)
+		if err != nil {
+			return false
+		}
+
+		return len(changes) < minChangedFiles
+	}
+}
+
+func compileRegexps(patterns []string) ([]*regexp.Regexp, error) {
+	regexps := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+		regexps = append(regexps, re)
+	}
+	return regexps, nil
+}
+
+func matchAny(regexps []*regexp.Regexp, value string) bool {
+	for _, re := range regexps {
+		if re.MatchString(value) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func pullRequestTitle(pr *PullRequest) string {
+	if pr.Title == nil {
+		return ""
+	}
+	return *pr.Title
+}
+
+func pullRequestAuthor(pr *PullRequest) string {
+	if pr.CreatedBy == nil || pr.CreatedBy.UniqueName == nil {
+		return ""
+	}
+	return parseEmailToAlias(*pr.CreatedBy.UniqueName)
+}
+
+func pullRequestLabels(pr *PullRequest) []string {
+	if pr.Labels == nil {
+		return nil
+	}
+
+	labels := make([]string, 0, len(*pr.Labels))
+	for _, label := range *pr.Labels {
+		if label.Name != nil {
+			labels = append(labels, *label.Name)
+		}
+	}
+	return labels
+}
@@ -0,0 +1,192 @@
+package autoreviewer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	adocore "github.com/microsoft/azure-devops-go-api/azuredevops/core"
+	adogit "github.com/microsoft/azure-devops-go-api/azuredevops/git"
+	"github.com/microsoft/azure-devops-go-api/azuredevops/webapi"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/samkreter/devopshelper/pkg/types"
+)
+
+func TestCompileFilters(t *testing.T) {
+	ctx := context.Background()
+	newPR := func(title, target, author string, isDraft bool, labels ...string) *PullRequest {
+		tags := []adocore.WebApiTagDefinition{}
+		for i := range labels {
+			tags = append(tags, adocore.WebApiTagDefinition{Name: &labels[i]})
+		}
+		return &PullRequest{GitPullRequest: adogit.GitPullRequest{
+			Title:         &title,
+			TargetRefName: &target,
+			IsDraft:       &isDraft,
+			CreatedBy:     &webapi.IdentityRef{UniqueName: &author},
+			Labels:        &tags,
+		}}
+	}
+
+	tests := []struct {
+		Name           string
+		Config         *types.PullRequestFilters
+		PR             *PullRequest
+		ExpectedFilter bool
+	}{
+		{
+			Name:           "Default Filters Main Branch",
+			PR:             newPR("Fix", "refs/heads/main", "alice@contoso.com", false),
+			ExpectedFilter: true,
+		},
+		{
+			Name:           "Target Branch Glob",
+			Config:         &types.PullRequestFilters{TargetBranches: []string{"main", "release/*"}},
+			PR:             newPR("Fix", "refs/heads/release/1.0", "alice@contoso.com", false),
+			ExpectedFilter: false,
+		},
+		{
+			Name:           "Target Branch Not Matched",
+			Config:         &types.PullRequestFilters{TargetBranches: []string{"main", "release/*"}},
+			PR:             newPR("Fix", "refs/heads/feature/x", "alice@contoso.com", false),
+			ExpectedFilter: true,
+		},
+		{
+			Name:           "Drafts Filtered",
+			Config:         &types.PullRequestFilters{},
+			PR:             newPR("Fix", "refs/heads/main", "alice@contoso.com", true),
+			ExpectedFilter: true,
+		},
+		{
+			Name:           "Drafts Included",
+			Config:         &types.PullRequestFilters{IncludeDrafts: true},
+			PR:             newPR("Fix", "refs/heads/main", "alice@contoso.com", true),
+			ExpectedFilter: false,
+		},
+		{
+			Name:           "Include Title",
+			Config:         &types.PullRequestFilters{IncludeTitles: []string{`^\[[A-Z]+-\d+\]`}},
+			PR:             newPR("Fix", "refs/heads/main", "alice@contoso.com", false),
+			ExpectedFilter: true,
+		},
+		{
+			Name:           "Exclude Title",
+			Config:         &types.PullRequestFilters{ExcludeTitles: []string{`(?i)wip`}},
+			PR:             newPR("wip: Fix", "refs/heads/main", "alice@contoso.com", false),
+			ExpectedFilter: true,
+		},
+		{
+			Name:           "Allowed Author",
+			Config:         &types.PullRequestFilters{AllowAuthors: []string{"Alice"}},
+			PR:             newPR("Fix", "refs/heads/main", "alice@contoso.com", false),
+			ExpectedFilter: false,
+		},
+		{
+			Name:           "Denied Author",
+			Config:         &types.PullRequestFilters{DenyAuthors: []string{"build-bot"}},
+			PR:             newPR("Fix", "refs/heads/main", "build-bot@contoso.com", false),
+			ExpectedFilter: true,
+		},
+		{
+			Name:           "Required Label Missing",
+			Config:         &types.PullRequestFilters{RequireLabels: []string{"needs-review"}},
+			PR:             newPR("Fix", "refs/heads/main", "alice@contoso.com", false, "docs"),
+			ExpectedFilter: true,
+		},
+		{
+			Name:           "Excluded Label",
+			Config:         &types.PullRequestFilters{ExcludeLabels: []string{"no-review"}},
+			PR:             newPR("Fix", "refs/heads/main", "alice@contoso.com", false, "No-Review"),
+			ExpectedFilter: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			filters, err := CompileFilters(tt.Config, nil)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			a := &AutoReviewer{Options: Options{Filters: filters}}
+			assert.Equal(t, tt.ExpectedFilter, a.shouldFilter(ctx, tt.PR))
+		})
+	}
+}
+
+// changesGitClient returns a single iteration with the changed paths
+type changesGitClient struct {
+	adogit.Client
+	paths []string
+}
+
+func (c *changesGitClient) GetPullRequestIterations(ctx context.Context, args adogit.GetPullRequestIterationsArgs) (*[]adogit.GitPullRequestIteration, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return &[]adogit.GitPullRequestIteration{{}}, nil
+}
+
+func (c *changesGitClient) GetPullRequestIterationChanges(ctx context.Context, args adogit.GetPullRequestIterationChangesArgs) (*adogit.GitPullRequestIterationChanges, error) {
+	changes := []adogit.GitPullRequestChange{}
+	for _, path := range c.paths {
+		changes = append(changes, adogit.GitPullRequestChange{Item: map[string]interface{}{"path": path}})
+	}
+	return &adogit.GitPullRequestIterationChanges{ChangeEntries: &changes}, nil
+}
+
+func TestCompileFiltersMinChangedFiles(t *testing.T) {
+	ctx := context.Background()
+	repoID := uuid.New()
+	pullRequestID := 1
+	newPullRequest := func() *PullRequest {
+		return &PullRequest{GitPullRequest: adogit.GitPullRequest{
+			Repository:    &adogit.GitRepository{Id: &repoID},
+			PullRequestId: &pullRequestID,
+		}}
+	}
+	client := &changesGitClient{paths: []string{"/a.go", "/b.go"}}
+
+	filters, err := CompileFilters(&types.PullRequestFilters{IncludeDrafts: true, MinChangedFiles: 3}, client)
+	assert.NoError(t, err)
+	a := &AutoReviewer{Options: Options{Filters: filters}}
+	assert.True(t, a.shouldFilter(ctx, newPullRequest()), "Should filter pull requests with too few changes")
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	assert.False(t, a.shouldFilter(canceled, newPullRequest()), "Should get the changes with the caller's context")
+
+	client.paths = append(client.paths, "/c.go")
+	assert.False(t, a.shouldFilter(ctx, newPullRequest()))
+
+	_, err = CompileFilters(&types.PullRequestFilters{MinChangedFiles: -1}, nil)
+	assert.Error(t, err, "Should reject a negative minimum")
+}
+
+func TestValidateFilters(t *testing.T) {
+	assert.NoError(t, ValidateFilters(nil))
+	assert.Error(t, ValidateFilters(&types.PullRequestFilters{TargetBranches: []string{"release/["}}))
+	assert.Error(t, ValidateFilters(&types.PullRequestFilters{IncludeTitles: []string{"("}}))
+	assert.Error(t, ValidateFilters(&types.PullRequestFilters{ExcludeTitles: []string{"("}}))
+}
+
+func TestMinChangedFilesGetsChangesOnce(t *testing.T) {
+	ctx := context.Background()
+	a, client, _, pr := newBalanceTest(t)
+
+	filters, err := CompileFilters(&types.PullRequestFilters{IncludeDrafts: true, MinChangedFiles: 1}, client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.Options.Filters = filters
+
+	title := "Add feature"
+	pr.Title = &title
+	if !assert.NoError(t, a.balancePullRequests(ctx, []adogit.GitPullRequest{pr.GitPullRequest})) {
+		return
+	}
+
+	assert.Len(t, client.reviewers, 1, "Should balance the pull request")
+	assert.Equal(t, 1, client.iterations, "Should reuse the changes read by the filter")
+}
@@ -0,0 +1,83 @@
+package autoreviewer
+
+import (
+	"context"
+
+	adogit "github.com/microsoft/azure-devops-go-api/azuredevops/git"
+	"github.com/pkg/errors"
+	"github.com/samkreter/go-core/log"
+
+	"github.com/samkreter/devopshelper/pkg/store"
+	"github.com/samkreter/devopshelper/pkg/types"
+)
+
+// ReviewLoad counts the active pull requests each reviewer is a required reviewer on, keyed by ADO ID
+type ReviewLoad map[string]int
+
+// AddPullRequests counts the required reviewers of the active pull requests
+func (l ReviewLoad) AddPullRequests(pullRequests []adogit.GitPullRequest) {
+	for _, pr := range pullRequests {
+		if pr.Status != nil && *pr.Status != adogit.PullRequestStatusValues.Active {
+			continue
+		}
+		if pr.Reviewers == nil {
+			continue
+		}
+
+		for _, reviewer := range *pr.Reviewers {
+			if reviewer.Id == nil || reviewer.IsRequired == nil || !*reviewer.IsRequired {
+				continue
+			}
+			l[*reviewer.Id]++
+		}
+	}
+}
+
+// AddReviewers counts a new review for each of the reviewers
+func (l ReviewLoad) AddReviewers(reviewers []*types.Reviewer) {
+	for _, reviewer := range reviewers {
+		if reviewer.AdoID != "" {
+			l[reviewer.AdoID]++
+		}
+	}
+}
+
+// AtCapacity returns true if the reviewer already has their maximum number of open reviews
+func (l ReviewLoad) AtCapacity(reviewer *types.Reviewer) bool {
+	if reviewer.MaxOpenReviews <= 0 {
+		return false
+	}
+	return l[reviewer.AdoID] >= reviewer.MaxOpenReviews
+}
+
+// OpenReviewCounts returns the counted reviews, so the load can be used when pull requests can't be listed
+func (l ReviewLoad) OpenReviewCounts(ctx context.Context) (map[string]int, error) {
+	return l, nil
+}
+
+// filterAtCapacity removes the reviewers that are at their open review cap so the next candidate is selected.
+// Nothing is removed when the review load is unknown.
+func filterAtCapacity(ctx context.Context, reviewerStore store.ReviewerStore, load ReviewLoad, aliases []string) ([]string, error) {
+	if load == nil {
+		return aliases, nil
+	}
+
+	available := make([]string, 0, len(aliases))
+	for _, alias := range aliases {
+		reviewer, err := reviewerStore.GetReviewer(ctx, alias)
+		if errors.Is(err, store.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get reviewer %q", alias)
+		}
+
+		if load.AtCapacity(reviewer) {
+			log.G(ctx).Infof("Skipping reviewer %s with %d open reviews", alias, load[reviewer.AdoID])
+			continue
+		}
+		available = append(available, alias)
+	}
+
+	return available, nil
+}
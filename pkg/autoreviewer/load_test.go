@@ -0,0 +1,117 @@
+package autoreviewer
+
+import (
+	"context"
+	"testing"
+
+	adogit "github.com/microsoft/azure-devops-go-api/azuredevops/git"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/samkreter/devopshelper/pkg/store/memory"
+	"github.com/samkreter/devopshelper/pkg/types"
+)
+
+func TestReviewLoad(t *testing.T) {
+	required, optional := true, false
+	active, completed := adogit.PullRequestStatusValues.Active, adogit.PullRequestStatusValues.Completed
+	alice, bob := "alice-id", "bob-id"
+
+	pullRequests := []adogit.GitPullRequest{
+		{
+			Status: &active,
+			Reviewers: &[]adogit.IdentityRefWithVote{
+				{Id: &alice, IsRequired: &required},
+				{Id: &bob, IsRequired: &optional},
+			},
+		},
+		{
+			Status:    &active,
+			Reviewers: &[]adogit.IdentityRefWithVote{{Id: &alice, IsRequired: &required}},
+		},
+		{
+			Status:    &completed,
+			Reviewers: &[]adogit.IdentityRefWithVote{{Id: &bob, IsRequired: &required}},
+		},
+		{
+			Status: &active,
+		},
+	}
+
+	load := ReviewLoad{}
+	load.AddPullRequests(pullRequests)
+	assert.Equal(t, ReviewLoad{alice: 2}, load, "Should only count required reviewers on active pull requests")
+
+	load.AddReviewers([]*types.Reviewer{{Alias: "bob", AdoID: bob}})
+	assert.Equal(t, 1, load[bob], "Should count newly added reviewers")
+}
+
+func TestFilterAtCapacity(t *testing.T) {
+	ctx := context.Background()
+	s := newFakeReviewerStore(
+		&types.Reviewer{Alias: "alice", AdoID: "alice-id", MaxOpenReviews: 2},
+		&types.Reviewer{Alias: "bob", AdoID: "bob-id", MaxOpenReviews: 3},
+		&types.Reviewer{Alias: "carol", AdoID: "carol-id"},
+	)
+	aliases := []string{"alice", "bob", "carol", "unknown"}
+
+	tests := []struct {
+		Name              string
+		Load              ReviewLoad
+		ExpectedAvailable []string
+	}{
+		{
+			Name:              "Unknown Load",
+			Load:              nil,
+			ExpectedAvailable: aliases,
+		},
+		{
+			Name:              "Under Capacity",
+			Load:              ReviewLoad{"alice-id": 1, "bob-id": 2},
+			ExpectedAvailable: []string{"alice", "bob", "carol"},
+		},
+		{
+			Name:              "At Capacity",
+			Load:              ReviewLoad{"alice-id": 2, "bob-id": 5, "carol-id": 20},
+			ExpectedAvailable: []string{"carol"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			available, err := filterAtCapacity(ctx, s, tt.Load, aliases)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.ExpectedAvailable, available)
+		})
+	}
+}
+
+func TestSelectReviewersSkipsAtCapacity(t *testing.T) {
+	ctx := context.Background()
+	s := memory.NewStore()
+	for _, reviewer := range []*types.Reviewer{
+		{Alias: "alice", AdoID: "alice-id", MaxOpenReviews: 1},
+		{Alias: "bob", AdoID: "bob-id"},
+		{Alias: "carol", AdoID: "carol-id", MaxOpenReviews: 1},
+	} {
+		if err := s.AddReviewer(ctx, reviewer); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	a := &AutoReviewer{
+		Repo: &types.Repository{
+			ReviewerCounts: &types.ReviewerCounts{RequiredOwners: 1, Optional: 2},
+		},
+		ReviewerStore: s,
+		TeamStore:     s,
+		reviewLoad:    ReviewLoad{"alice-id": 1, "carol-id": 1},
+	}
+	groups := []*ReviewerGroup{{Owners: map[string]bool{"alice": true, "bob": true, "carol": true}}}
+
+	selection, err := a.selectReviewers(ctx, groups, nil, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, []string{"bob"}, GetReviewersAlias(selection.Required), "Should skip required reviewers at capacity")
+	assert.Empty(t, selection.Optional, "Should skip optional reviewers at capacity")
+}
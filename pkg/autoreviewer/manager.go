@@ -23,9 +23,9 @@ type Manager struct {
 }
 
 func NewDefaultManager(ctx context.Context, repoStore store.RepositoryStore,
-	reviewerStore store.ReviewerStore, teamStore store.TeamStore,
+	reviewerStore store.ReviewerStore, teamStore store.TeamStore, assignmentStore store.AssignmentStore,
 	adoGitClient adogit.Client, aodIdentityClient adoidentity.Client,
-	adoCoreClient adocore.Client) (*Manager, error) {
+	adoCoreClient adocore.Client, options Options) (*Manager, error) {
 	repos, err := repoStore.GetAllRepositories(ctx)
 	if err != nil {
 		return nil, err
@@ -41,9 +41,10 @@ func NewDefaultManager(ctx context.Context, repoStore store.RepositoryStore,
 	aReviewers := make([]*AutoReviewer, 0, len(repos))
 	for _, repo := range enabledRepos {
 		aReviewer, err := NewAutoReviewer(adoGitClient, aodIdentityClient, adoCoreClient, defaultBotIdentifier,
-			repo, repoStore,reviewerStore, teamStore, Options{})
+			repo, repoStore,reviewerStore, teamStore, assignmentStore, options)
 		if err != nil {
-			return nil, err
+			log.G(ctx).Errorf("Skipping repo %s: %v", repo.Name, err)
+			continue
 		}
 
 		aReviewers = append(aReviewers, aReviewer)
@@ -66,12 +67,39 @@ func (m *Manager) Run(ctx context.Context) error {
 			}
 			logger.Infof("Successfully reconciled repo: %s", aReviewer.Repo.Name)
 		}
+	}
+
+	// Count open reviews across every repo so reviewers at their cap are skipped everywhere
+	reviewLoad := ReviewLoad{}
+	pullRequests := make([][]adogit.GitPullRequest, len(m.AutoReviewers))
+	failed := make([]bool, len(m.AutoReviewers))
+	for i, aReviewer := range m.AutoReviewers {
+		prs, err := aReviewer.getPullRequests(ctx)
+		if err != nil {
+			// One unreachable repo shouldn't stop the others from being balanced
+			logger.Errorf("failed to get pull requests for repo %s/%s: %v", aReviewer.Repo.ProjectName, aReviewer.Repo.Name, err)
+			failed[i] = true
+			continue
+		}
+
+		reviewLoad.AddPullRequests(prs)
+		pullRequests[i] = prs
+	}
+
+	for i, aReviewer := range m.AutoReviewers {
+		if failed[i] {
+			continue
+		}
+		aReviewer.reviewLoad = reviewLoad
 
 		logger.Infof("Starting Reviewer for repo: %s/%s", aReviewer.Repo.ProjectName, aReviewer.Repo.Name)
-		if err := aReviewer.Run(ctx); err != nil {
+		if err := aReviewer.balancePullRequests(ctx, pullRequests[i]); err != nil {
 			return err
 		}
 		logger.Infof("Finished Balancing Cycle for: %s/%s", aReviewer.Repo.ProjectName, aReviewer.Repo.Name)
 	}
+
+	stats := DefaultOwnersCache.Stats()
+	logger.Infof("Owners cache hits: %d, misses: %d", stats.Hits, stats.Misses)
 	return nil
 }
@@ -0,0 +1,160 @@
+package autoreviewer
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	adogit "github.com/microsoft/azure-devops-go-api/azuredevops/git"
+	"github.com/pkg/errors"
+)
+
+const (
+	// maxOwnersCacheCommits is how many commits are cached per repository. Once a branch head moves the
+	// old commit is no longer used and is evicted as new commits are cached.
+	maxOwnersCacheCommits = 4
+)
+
+var (
+	// DefaultOwnersCache is shared by all the autoreviewers
+	DefaultOwnersCache = NewOwnersCache()
+)
+
+// OwnersCache caches the owners file contents of a repository at a commit, since the files at a
+// commit never change entries only need to be evicted when newer commits are cached.
+type OwnersCache struct {
+	mu    sync.Mutex
+	repos map[string][]*ownersCacheEntry
+
+	hits   uint64
+	misses uint64
+}
+
+type ownersCacheEntry struct {
+	commitID string
+	// files holds the file contents by path, nil for files known not to exist
+	files map[string]*string
+	// complete is set when files holds every owners file in the repository at the commit
+	complete bool
+}
+
+// OwnersCacheStats are the cache hit and miss counts
+type OwnersCacheStats struct {
+	Hits   uint64
+	Misses uint64
+}
+
+// NewOwnersCache creates a new owners cache
+func NewOwnersCache() *OwnersCache {
+	return &OwnersCache{
+		repos: map[string][]*ownersCacheEntry{},
+	}
+}
+
+// Get gets the cached file content, the content is nil when the file is known not to exist
+func (c *OwnersCache) Get(repoID, commitID, path string) (*string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if entry := c.getEntry(repoID, commitID); entry != nil {
+		if content, ok := entry.files[path]; ok {
+			atomic.AddUint64(&c.hits, 1)
+			return content, true
+		}
+
+		// Only owners.txt files are listed when warming, other included files still have to be fetched
+		if entry.complete && filepath.Base(path) == ownersFileName {
+			atomic.AddUint64(&c.hits, 1)
+			return nil, true
+		}
+	}
+
+	atomic.AddUint64(&c.misses, 1)
+	return nil, false
+}
+
+// Set caches the file content, nil content caches that the file doesn't exist
+func (c *OwnersCache) Set(repoID, commitID, path string, content *string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := c.getEntry(repoID, commitID)
+	if entry == nil {
+		entry = c.addEntry(repoID, commitID)
+	}
+	entry.files[path] = content
+}
+
+// Warm caches every owners file in the repository at the commit
+func (c *OwnersCache) Warm(repoID, commitID string, files map[string]string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := c.getEntry(repoID, commitID)
+	if entry == nil {
+		entry = c.addEntry(repoID, commitID)
+	}
+
+	for path, content := range files {
+		content := content
+		entry.files[path] = &content
+	}
+	entry.complete = true
+}
+
+// Stats returns the cache hit and miss counts
+func (c *OwnersCache) Stats() OwnersCacheStats {
+	return OwnersCacheStats{
+		Hits:   atomic.LoadUint64(&c.hits),
+		Misses: atomic.LoadUint64(&c.misses),
+	}
+}
+
+// getEntry gets the entry for the commit and marks it as most recently used. The lock must be held by the caller.
+func (c *OwnersCache) getEntry(repoID, commitID string) *ownersCacheEntry {
+	entries := c.repos[repoID]
+	for i, entry := range entries {
+		if entry.commitID == commitID {
+			copy(entries[1:i+1], entries[:i])
+			entries[0] = entry
+			return entry
+		}
+	}
+	return nil
+}
+
+// addEntry adds an entry for the commit, evicting the least recently used commit. The lock must be held by the caller.
+func (c *OwnersCache) addEntry(repoID, commitID string) *ownersCacheEntry {
+	entry := &ownersCacheEntry{
+		commitID: commitID,
+		files:    map[string]*string{},
+	}
+
+	entries := append([]*ownersCacheEntry{entry}, c.repos[repoID]...)
+	if len(entries) > maxOwnersCacheCommits {
+		entries = entries[:maxOwnersCacheCommits]
+	}
+	c.repos[repoID] = entries
+
+	return entry
+}
+
+// getBranchHead gets the commit id at the head of the branch
+func getBranchHead(ctx context.Context, client adogit.Client, repoID, refName string) (string, error) {
+	name := strings.TrimPrefix(refName, "refs/heads/")
+	branch, err := client.GetBranch(ctx, adogit.GetBranchArgs{
+		RepositoryId: &repoID,
+		Name:         &name,
+	})
+	if err != nil {
+		return "", errors.Wrapf(ParseADOError(err), "failed to get branch %s", name)
+	}
+
+	if branch.Commit == nil || branch.Commit.CommitId == nil {
+		return "", errors.Errorf("branch %s has no commit", name)
+	}
+
+	return *branch.Commit.CommitId, nil
+}
@@ -0,0 +1,72 @@
+package autoreviewer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOwnersCache(t *testing.T) {
+	cache := NewOwnersCache()
+
+	_, ok := cache.Get("repo", "commit1", "/owners.txt")
+	assert.False(t, ok, "Should miss an empty cache")
+
+	cache.Warm("repo", "commit1", map[string]string{"/owners.txt": "alice"})
+
+	content, ok := cache.Get("repo", "commit1", "/owners.txt")
+	if assert.True(t, ok, "Should hit a warmed file") {
+		assert.Equal(t, "alice", *content)
+	}
+
+	content, ok = cache.Get("repo", "commit1", "/src/owners.txt")
+	assert.True(t, ok, "Should know an owners file missing from a warmed commit doesn't exist")
+	assert.Nil(t, content)
+
+	_, ok = cache.Get("repo", "commit1", "/shared/team.txt")
+	assert.False(t, ok, "Should miss included files that weren't warmed")
+
+	_, ok = cache.Get("repo", "commit2", "/owners.txt")
+	assert.False(t, ok, "Should miss once the branch head moves")
+
+	_, ok = cache.Get("other", "commit1", "/owners.txt")
+	assert.False(t, ok, "Should key the cache by repository")
+
+	for i := 0; i < maxOwnersCacheCommits; i++ {
+		cache.Set("repo", string(rune('a'+i)), "/owners.txt", nil)
+	}
+	_, ok = cache.Get("repo", "commit1", "/owners.txt")
+	assert.False(t, ok, "Should evict old commits")
+
+	assert.Equal(t, OwnersCacheStats{Hits: 2, Misses: 5}, cache.Stats())
+}
+
+func TestOwnersLoaderUsesCache(t *testing.T) {
+	client := &fakeGitClient{
+		head: "commit1",
+		items: map[string]string{
+			"/owners.txt":     "rootOwner",
+			"/src/owners.txt": "srcOwner",
+		},
+	}
+	cache := NewOwnersCache()
+
+	for i := 0; i < 2; i++ {
+		loader := newOwnersLoader(client, cache, "repo-id", client.head)
+		reviewerGroup, err := loader.getInheritedOwners(context.Background(), "/src/lib")
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, map[string]bool{"rootOwner": true, "srcOwner": true}, reviewerGroup.Owners)
+	}
+	assert.Equal(t, 3, client.itemGets, "Should only read each file once per commit")
+
+	client.head = "commit2"
+	client.items["/src/owners.txt"] = "newOwner"
+	loader := newOwnersLoader(client, cache, "repo-id", client.head)
+	reviewerGroup, err := loader.getInheritedOwners(context.Background(), "/src/lib")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]bool{"rootOwner": true, "newOwner": true}, reviewerGroup.Owners, "Should reread files when the head moves")
+	assert.Equal(t, 6, client.itemGets)
+}
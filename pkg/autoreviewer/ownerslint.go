@@ -0,0 +1,231 @@
+package autoreviewer
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	adocore "github.com/microsoft/azure-devops-go-api/azuredevops/core"
+	adoidentity "github.com/microsoft/azure-devops-go-api/azuredevops/identity"
+	"github.com/pkg/errors"
+
+	"github.com/samkreter/devopshelper/pkg/utils"
+)
+
+// Owners lint rules
+const (
+	LintRuleUnknownAlias         = "unknown-alias"
+	LintRuleUnknownTeam          = "unknown-team"
+	LintRuleDuplicateEntry       = "duplicate-entry"
+	LintRuleEmptyFile            = "empty-file"
+	LintRuleInvalidInclude       = "invalid-include"
+	LintRuleUnreachableDirectory = "unreachable-directory"
+)
+
+// LintIssue is a problem found in an owners file
+type LintIssue struct {
+	Rule    string `json:"rule"`
+	Path    string `json:"path"`
+	Line    int    `json:"line,omitempty"`
+	Message string `json:"message"`
+}
+
+// OwnersLinter checks the owners files of a tree. The alias and team checks are skipped when their func is nil.
+type OwnersLinter struct {
+	// KnownAlias returns if the alias is a known identity
+	KnownAlias func(ctx context.Context, alias string) (bool, error)
+	// KnownTeam returns if the team exists
+	KnownTeam func(ctx context.Context, team string) (bool, error)
+}
+
+// Lint checks every owners file in the tree, issues are sorted by path and line
+func (l *OwnersLinter) Lint(ctx context.Context, tree *OwnersTree) ([]LintIssue, error) {
+	var issues []LintIssue
+	knownAliases := map[string]bool{}
+	knownTeams := map[string]bool{}
+	loader := tree.newLoader()
+
+	for _, ownersPath := range tree.OwnersFiles {
+		content, err := tree.ReadFile(ctx, ownersPath)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read %s", ownersPath)
+		}
+		if content == nil {
+			continue
+		}
+
+		reviewerGroup := newReviewerGroupFromOwnersFile(*content)
+		if len(reviewerGroup.Owners) == 0 && len(reviewerGroup.Teams) == 0 && len(reviewerGroup.Includes) == 0 {
+			issues = append(issues, LintIssue{
+				Rule:    LintRuleEmptyFile,
+				Path:    ownersPath,
+				Message: "owners file has no owners, teams or includes",
+			})
+			continue
+		}
+
+		// Parse each line on its own to know where every entry is
+		seenOwners := map[string]int{}
+		seenTeams := map[string]int{}
+		seenIncludes := map[string]int{}
+		for i, line := range strings.Split(*content, "\n") {
+			lineNum := i + 1
+			lineGroup := newReviewerGroupFromOwnersFile(line)
+
+			for owner := range lineGroup.Owners {
+				if first, ok := seenOwners[owner]; ok {
+					issues = append(issues, duplicateIssue(ownersPath, lineNum, "owner", owner, first))
+					continue
+				}
+				seenOwners[owner] = lineNum
+
+				if l.KnownAlias == nil {
+					continue
+				}
+				known, ok := knownAliases[owner]
+				if !ok {
+					if known, err = l.KnownAlias(ctx, owner); err != nil {
+						return nil, errors.Wrapf(err, "failed to check alias %s", owner)
+					}
+					knownAliases[owner] = known
+				}
+				if !known {
+					issues = append(issues, LintIssue{
+						Rule:    LintRuleUnknownAlias,
+						Path:    ownersPath,
+						Line:    lineNum,
+						Message: fmt.Sprintf("unknown alias %q", owner),
+					})
+				}
+			}
+
+			for team := range lineGroup.Teams {
+				if first, ok := seenTeams[team]; ok {
+					issues = append(issues, duplicateIssue(ownersPath, lineNum, "team", team, first))
+					continue
+				}
+				seenTeams[team] = lineNum
+
+				if l.KnownTeam == nil {
+					continue
+				}
+				known, ok := knownTeams[team]
+				if !ok {
+					if known, err = l.KnownTeam(ctx, team); err != nil {
+						return nil, errors.Wrapf(err, "failed to check team %s", team)
+					}
+					knownTeams[team] = known
+				}
+				if !known {
+					issues = append(issues, LintIssue{
+						Rule:    LintRuleUnknownTeam,
+						Path:    ownersPath,
+						Line:    lineNum,
+						Message: fmt.Sprintf("unknown team %q", team),
+					})
+				}
+			}
+
+			for _, include := range lineGroup.Includes {
+				if first, ok := seenIncludes[include]; ok {
+					issues = append(issues, duplicateIssue(ownersPath, lineNum, "include", include, first))
+					continue
+				}
+				seenIncludes[include] = lineNum
+			}
+		}
+
+		if _, err := loader.getOwnersFile(ctx, ownersPath, map[string]bool{}); err != nil {
+			issues = append(issues, LintIssue{
+				Rule:    LintRuleInvalidInclude,
+				Path:    ownersPath,
+				Message: errors.Cause(err).Error(),
+			})
+		}
+	}
+
+	// Report the top directories where no owners apply, nobody can be assigned for changes under them
+	var unreachable []string
+	for _, dir := range tree.Dirs() {
+		if isUnderAny(dir, unreachable) {
+			continue
+		}
+
+		reviewerGroup, err := loader.getInheritedOwners(ctx, dir)
+		if err != nil {
+			// Already reported for the owners file
+			continue
+		}
+		if len(reviewerGroup.Owners) == 0 && len(reviewerGroup.Teams) == 0 {
+			unreachable = append(unreachable, dir)
+			issues = append(issues, LintIssue{
+				Rule:    LintRuleUnreachableDirectory,
+				Path:    dir,
+				Message: "no owners apply to files in this directory",
+			})
+		}
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].Path != issues[j].Path {
+			return issues[i].Path < issues[j].Path
+		}
+		return issues[i].Line < issues[j].Line
+	})
+
+	return issues, nil
+}
+
+func duplicateIssue(ownersPath string, line int, kind, value string, first int) LintIssue {
+	return LintIssue{
+		Rule:    LintRuleDuplicateEntry,
+		Path:    ownersPath,
+		Line:    line,
+		Message: fmt.Sprintf("duplicate %s %q, first listed on line %d", kind, value, first),
+	}
+}
+
+// isUnderAny returns if the directory is one of the parents or under one of them
+func isUnderAny(dir string, parents []string) bool {
+	for _, parent := range parents {
+		if dir == parent || strings.HasPrefix(dir, strings.TrimSuffix(parent, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// NewADOAliasChecker checks aliases against the ADO identities
+func NewADOAliasChecker(client adoidentity.Client) func(ctx context.Context, alias string) (bool, error) {
+	return func(ctx context.Context, alias string) (bool, error) {
+		_, err := utils.GetDevOpsIdentity(ctx, alias, client)
+		switch {
+		case err == nil:
+			return true, nil
+		case errors.Is(err, utils.ErrIdentityNotFound):
+			return false, nil
+		default:
+			return false, err
+		}
+	}
+}
+
+// NewADOTeamChecker checks teams exist in the ADO project
+func NewADOTeamChecker(client adocore.Client, project string) func(ctx context.Context, team string) (bool, error) {
+	return func(ctx context.Context, team string) (bool, error) {
+		_, err := client.GetTeam(ctx, adocore.GetTeamArgs{
+			ProjectId: &project,
+			TeamId:    &team,
+		})
+		if err == nil {
+			return true, nil
+		}
+
+		err = ParseADOError(err)
+		if errors.Is(err, errNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+}
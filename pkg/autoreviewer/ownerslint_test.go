@@ -0,0 +1,52 @@
+package autoreviewer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOwnersLinterLint(t *testing.T) {
+	tree := NewOwnersTree(map[string]string{
+		"/owners.txt":         "alice\n" + PrefixGroup + "Known Team\n" + PrefixGroup + "Typo Team\nalcie\nalice\n",
+		"/main.go":            "",
+		"/empty/owners.txt":   "; only a comment\n",
+		"/empty/file.go":      "",
+		"/include/owners.txt": "file: /none/owners.txt\n" + PrefixNoNotify + "alice\n",
+		"/island/owners.txt":  DirectiveNoParent + "\nfile: team.txt\n",
+		"/island/team.txt":    "; no owners here\n",
+		"/island/sub/main.go": "",
+	})
+
+	linter := &OwnersLinter{
+		KnownAlias: func(ctx context.Context, alias string) (bool, error) {
+			return alias == "alice", nil
+		},
+		KnownTeam: func(ctx context.Context, team string) (bool, error) {
+			return team == "Known Team", nil
+		},
+	}
+
+	issues, err := linter.Lint(context.Background(), tree)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []LintIssue{
+		{Rule: LintRuleEmptyFile, Path: "/empty/owners.txt", Message: "owners file has no owners, teams or includes"},
+		{Rule: LintRuleInvalidInclude, Path: "/include/owners.txt", Message: "owners file /include/owners.txt includes missing file /none/owners.txt"},
+		{Rule: LintRuleUnreachableDirectory, Path: "/island", Message: "no owners apply to files in this directory"},
+		{Rule: LintRuleUnknownTeam, Path: "/owners.txt", Line: 3, Message: `unknown team "Typo Team"`},
+		{Rule: LintRuleUnknownAlias, Path: "/owners.txt", Line: 4, Message: `unknown alias "alcie"`},
+		{Rule: LintRuleDuplicateEntry, Path: "/owners.txt", Line: 5, Message: `duplicate owner "alice", first listed on line 1`},
+	}, issues)
+}
+
+func TestOwnersLinterSkipsUnsetChecks(t *testing.T) {
+	tree := NewOwnersTree(map[string]string{
+		"/owners.txt": "anyone\n" + PrefixGroup + "Any Team\n",
+	})
+
+	issues, err := (&OwnersLinter{}).Lint(context.Background(), tree)
+	assert.NoError(t, err)
+	assert.Empty(t, issues)
+}
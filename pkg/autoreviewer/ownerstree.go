@@ -0,0 +1,196 @@
+package autoreviewer
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+
+	adogit "github.com/microsoft/azure-devops-go-api/azuredevops/git"
+	"github.com/pkg/errors"
+
+	"github.com/samkreter/devopshelper/pkg/types"
+)
+
+// OwnersTree is the files of a repository outside of a pull request, such as a local checkout
+type OwnersTree struct {
+	// Paths are every file in the tree, slash separated with a leading slash
+	Paths []string
+	// OwnersFiles are the paths of the owners files in the tree
+	OwnersFiles []string
+
+	readFile func(ctx context.Context, path string) (*string, error)
+}
+
+// NewOwnersTree creates a tree from the file contents by path
+func NewOwnersTree(files map[string]string) *OwnersTree {
+	paths := make([]string, 0, len(files))
+	for filePath := range files {
+		paths = append(paths, filePath)
+	}
+
+	return newOwnersTree(paths, func(ctx context.Context, filePath string) (*string, error) {
+		content, ok := files[filePath]
+		if !ok {
+			return nil, nil
+		}
+		return &content, nil
+	})
+}
+
+// LoadLocalOwnersTree loads the tree of a local checkout, the .git directory is skipped
+func LoadLocalOwnersTree(root string) (*OwnersTree, error) {
+	var paths []string
+	err := filepath.Walk(root, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			if info.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, filePath)
+		if err != nil {
+			return err
+		}
+		paths = append(paths, "/"+filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to walk %s", root)
+	}
+
+	return newOwnersTree(paths, func(ctx context.Context, filePath string) (*string, error) {
+		content, err := ioutil.ReadFile(filepath.Join(root, filepath.FromSlash(filePath)))
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		contentStr := string(content)
+		return &contentStr, nil
+	}), nil
+}
+
+// LoadADOOwnersTree loads the tree of the repository's default branch from ADO
+func LoadADOOwnersTree(ctx context.Context, client adogit.Client, repoID string) (*OwnersTree, error) {
+	items, err := client.GetItems(ctx, adogit.GetItemsArgs{
+		RepositoryId:   &repoID,
+		RecursionLevel: &adogit.VersionControlRecursionTypeValues.Full,
+	})
+	if err != nil {
+		return nil, errors.Wrap(ParseADOError(err), "failed to get items")
+	}
+
+	var commitID string
+	var paths []string
+	for _, item := range *items {
+		if item.IsFolder != nil && *item.IsFolder {
+			continue
+		}
+		if item.CommitId != nil {
+			commitID = *item.CommitId
+		}
+		paths = append(paths, *item.Path)
+	}
+
+	return newOwnersTree(paths, func(ctx context.Context, filePath string) (*string, error) {
+		item, err := getFileAtCommit(ctx, client, repoID, commitID, filePath)
+		if errors.Is(err, errNotFound) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return item.Content, nil
+	}), nil
+}
+
+func newOwnersTree(paths []string, readFile func(ctx context.Context, path string) (*string, error)) *OwnersTree {
+	sort.Strings(paths)
+
+	tree := &OwnersTree{
+		Paths:    paths,
+		readFile: readFile,
+	}
+	for _, filePath := range paths {
+		if path.Base(filePath) == ownersFileName {
+			tree.OwnersFiles = append(tree.OwnersFiles, filePath)
+		}
+	}
+
+	return tree
+}
+
+// Dirs returns the directories holding files in the tree
+func (t *OwnersTree) Dirs() []string {
+	seen := map[string]bool{}
+	var dirs []string
+	for _, filePath := range t.Paths {
+		dir := path.Dir(filePath)
+		if !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+
+	sort.Strings(dirs)
+	return dirs
+}
+
+// ReadFile gets the file's content, nil when the file doesn't exist
+func (t *OwnersTree) ReadFile(ctx context.Context, filePath string) (*string, error) {
+	return t.readFile(ctx, filePath)
+}
+
+func (t *OwnersTree) newLoader() *ownersLoader {
+	return newReaderOwnersLoader(t.readFile)
+}
+
+// GetReviewerGroups gets the owners of the changed paths using the owners format
+func (t *OwnersTree) GetReviewerGroups(ctx context.Context, ownersFormat string, changePaths []string) ([]*ReviewerGroup, error) {
+	switch ownersFormat {
+	case "", types.OwnersFormatOwnersTxt:
+	case types.OwnersFormatCodeOwners:
+		return t.getCodeOwnersReviewerGroups(ctx, changePaths)
+	default:
+		return nil, fmt.Errorf("unknown owners format %q", ownersFormat)
+	}
+
+	loader := t.newLoader()
+	seen := map[string]bool{}
+	var reviewerGroups []*ReviewerGroup
+	for _, changePath := range changePaths {
+		dir := path.Dir(changePath)
+		if seen[dir] {
+			continue
+		}
+		seen[dir] = true
+
+		reviewerGroup, err := loader.getInheritedOwners(ctx, dir)
+		if err != nil {
+			return nil, err
+		}
+		reviewerGroups = append(reviewerGroups, reviewerGroup)
+	}
+
+	return reviewerGroups, nil
+}
+
+func (t *OwnersTree) getCodeOwnersReviewerGroups(ctx context.Context, changePaths []string) ([]*ReviewerGroup, error) {
+	codeOwners, err := loadCodeOwners(ctx, t.readFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return codeOwners.reviewerGroups(changePaths), nil
+}
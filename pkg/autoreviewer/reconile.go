@@ -4,7 +4,7 @@ import (
 	"context"
 	"fmt"
 	"github.com/samkreter/devopshelper/pkg/types"
-	"strings"
+	"path/filepath"
 	"time"
 
 	"github.com/pkg/errors"
@@ -67,37 +67,32 @@ func (a *AutoReviewer) ensureReviewers(ctx context.Context) error {
 		return errors.Wrap(err, "failed to get ownersfiles")
 	}
 
+	// All items are listed at the default branch head, read the owners files at the same commit to warm the cache
+	var commitID string
+	if len(*items) > 0 && (*items)[0].CommitId != nil {
+		commitID = *(*items)[0].CommitId
+	}
+
 	// Get all reviewer groups for the repo
-	reviewerAliases := map[string]bool{}
-	for _, item := range *items {
-		if !strings.Contains(*item.Path, "owners.txt") {
-			continue
+	var reviewerGroups []*ReviewerGroup
+	switch a.Repo.OwnersFormat {
+	case types.OwnersFormatCodeOwners:
+		codeOwners, err := getCodeOwners(ctx, a.adoGitClient, a.ownersCache, a.Repo.AdoRepoID, commitID)
+		if err != nil {
+			return errors.Wrap(err, "failed to get CODEOWNERS")
 		}
-
-		ownersFile, err := getFileFromADO(ctx, a.adoGitClient, a.Repo.AdoRepoID, *item.Path)
+		reviewerGroups = codeOwners.groups()
+	default:
+		reviewerGroups, err = a.getOwnersFileGroups(ctx, *items, commitID)
 		if err != nil {
 			return err
 		}
+	}
 
-		reviewerGroup :=  newReviewerGroupFromOwnersFile(*ownersFile.Content)
-
-		for owner := range reviewerGroup.Owners {
-			reviewerAliases[owner] = true
-		}
-
-		for team := range reviewerGroup.Teams {
-			members, err := a.getTeamMembers(ctx, team)
-			if err != nil {
-				return errors.Wrapf(err, "failed to get team members for team: %s", team)
-			}
-
-			for _, member := range members {
-				reviewerAliases[member] = true
-			}
-
-			if err := a.ensureTeam(ctx, team, members); err != nil {
-				return err
-			}
+	reviewerAliases := map[string]bool{}
+	for _, reviewerGroup := range reviewerGroups {
+		if err := a.ensureReviewerGroup(ctx, reviewerGroup, reviewerAliases); err != nil {
+			return err
 		}
 	}
 
@@ -140,6 +135,85 @@ func (a *AutoReviewer) ensureReviewers(ctx context.Context) error {
 	return nil
 }
 
+// getOwnersFileGroups reads every owners file in the items at the commit with their includes merged in, the files
+// that were read are added to the owners cache. Owners files that can't be read are logged and skipped.
+func (a *AutoReviewer) getOwnersFileGroups(ctx context.Context, items []adogit.GitItem, commitID string) ([]*ReviewerGroup, error) {
+	logger := log.G(ctx)
+
+	// Keep the content of every file read, including the included files, to warm the cache
+	ownersFiles := map[string]string{}
+	complete := true
+	loader := newOwnersLoader(a.adoGitClient, nil, a.Repo.AdoRepoID, commitID)
+	getContent := loader.readFile
+	loader.readFile = func(ctx context.Context, path string) (*string, error) {
+		content, err := getContent(ctx, path)
+		switch {
+		case err != nil:
+			complete = false
+		case content != nil:
+			ownersFiles[path] = *content
+		}
+		return content, err
+	}
+
+	var reviewerGroups []*ReviewerGroup
+	for _, item := range items {
+		if item.Path == nil || filepath.Base(*item.Path) != ownersFileName {
+			continue
+		}
+
+		reviewerGroup, err := loader.getOwnersFile(ctx, *item.Path, map[string]bool{})
+		if err != nil {
+			logger.Errorf("Skipping owners file %s for repo %s: %v", *item.Path, a.Repo.Name, err)
+			continue
+		}
+		if reviewerGroup != nil {
+			reviewerGroups = append(reviewerGroups, reviewerGroup)
+		}
+	}
+
+	if commitID == "" || a.ownersCache == nil {
+		return reviewerGroups, nil
+	}
+
+	// Missing owners files are only known not to exist when every owners file was fetched
+	if complete {
+		a.ownersCache.Warm(a.Repo.AdoRepoID, commitID, ownersFiles)
+	} else {
+		for path, content := range ownersFiles {
+			content := content
+			a.ownersCache.Set(a.Repo.AdoRepoID, commitID, path, &content)
+		}
+	}
+	logger.Infof("Cached %d owners files at commit %s for repo: %s", len(ownersFiles), commitID, a.Repo.Name)
+
+	return reviewerGroups, nil
+}
+
+// ensureReviewerGroup adds the group's owners and team members to the aliases and makes sure its teams are stored
+func (a *AutoReviewer) ensureReviewerGroup(ctx context.Context, reviewerGroup *ReviewerGroup, reviewerAliases map[string]bool) error {
+	for owner := range reviewerGroup.Owners {
+		reviewerAliases[owner] = true
+	}
+
+	for team := range reviewerGroup.Teams {
+		members, err := a.getTeamMembers(ctx, team)
+		if err != nil {
+			return errors.Wrapf(err, "failed to get team members for team: %s", team)
+		}
+
+		for _, member := range members {
+			reviewerAliases[member] = true
+		}
+
+		if err := a.ensureTeam(ctx, team, members); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (a *AutoReviewer) ensureTeam(ctx context.Context, teamName string, members []string) error {
 	logger := log.G(ctx)
 
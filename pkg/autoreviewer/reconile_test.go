@@ -0,0 +1,206 @@
+package autoreviewer
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/microsoft/azure-devops-go-api/azuredevops"
+	adocore "github.com/microsoft/azure-devops-go-api/azuredevops/core"
+	adogit "github.com/microsoft/azure-devops-go-api/azuredevops/git"
+	adoidentity "github.com/microsoft/azure-devops-go-api/azuredevops/identity"
+	"github.com/microsoft/azure-devops-go-api/azuredevops/webapi"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/samkreter/devopshelper/pkg/store/memory"
+	"github.com/samkreter/devopshelper/pkg/types"
+)
+
+const (
+	reconcileCommitID = "head"
+)
+
+// reconcileGitClient fakes a repository with the files at a single commit
+type reconcileGitClient struct {
+	adogit.Client
+	files map[string]string
+	// failures are the files that fail to be read
+	failures map[string]bool
+}
+
+func (c *reconcileGitClient) GetItems(ctx context.Context, args adogit.GetItemsArgs) (*[]adogit.GitItem, error) {
+	commitID := reconcileCommitID
+	items := []adogit.GitItem{}
+	for path := range c.files {
+		path := path
+		items = append(items, adogit.GitItem{Path: &path, CommitId: &commitID})
+	}
+	sort.Slice(items, func(i, j int) bool { return *items[i].Path < *items[j].Path })
+	return &items, nil
+}
+
+func (c *reconcileGitClient) GetItem(ctx context.Context, args adogit.GetItemArgs) (*adogit.GitItem, error) {
+	if c.failures[*args.Path] {
+		statusCode := 500
+		return nil, azuredevops.WrappedError{StatusCode: &statusCode}
+	}
+
+	content, ok := c.files[*args.Path]
+	if !ok {
+		statusCode := 404
+		return nil, azuredevops.WrappedError{StatusCode: &statusCode}
+	}
+	return &adogit.GitItem{Path: args.Path, Content: &content}, nil
+}
+
+// reconcileIdentityClient finds an identity for every alias
+type reconcileIdentityClient struct {
+	adoidentity.Client
+}
+
+func (c *reconcileIdentityClient) ReadIdentities(ctx context.Context, args adoidentity.ReadIdentitiesArgs) (*[]adoidentity.Identity, error) {
+	id := uuid.New()
+	return &[]adoidentity.Identity{{Id: &id}}, nil
+}
+
+// reconcileCoreClient returns the members of the teams
+type reconcileCoreClient struct {
+	adocore.Client
+	teams map[string][]string
+}
+
+func (c *reconcileCoreClient) GetTeamMembersWithExtendedProperties(ctx context.Context, args adocore.GetTeamMembersWithExtendedPropertiesArgs) (*[]webapi.TeamMember, error) {
+	members := []webapi.TeamMember{}
+	for _, member := range c.teams[*args.TeamId] {
+		uniqueName := member + "@contoso.com"
+		members = append(members, webapi.TeamMember{Identity: &webapi.IdentityRef{UniqueName: &uniqueName}})
+	}
+	return &members, nil
+}
+
+func TestEnsureReviewers(t *testing.T) {
+	tests := []struct {
+		Name              string
+		OwnersFormat      string
+		Files             map[string]string
+		ExpectedReviewers []string
+		ExpectedTeams     []string
+	}{
+		{
+			Name: "Owners Files",
+			Files: map[string]string{
+				"/owners.txt":         "alice\n",
+				"/src/owners.txt":     "bob\n; TEAM: Platform\n",
+				"/src/main.go":        "package main\n",
+				"/old/owners.txt.bak": "dave\n",
+			},
+			ExpectedReviewers: []string{"alice", "bob", "carol"},
+			ExpectedTeams:     []string{"Platform"},
+		},
+		{
+			Name: "Owners File Includes",
+			Files: map[string]string{
+				"/owners.txt":       "alice\n",
+				"/src/owners.txt":   "file:../shared/reviewers\n",
+				"/shared/reviewers": "erin\n; TEAM: Platform\n",
+			},
+			ExpectedReviewers: []string{"alice", "carol", "erin"},
+			ExpectedTeams:     []string{"Platform"},
+		},
+		{
+			Name:         "Code Owners",
+			OwnersFormat: types.OwnersFormatCodeOwners,
+			Files: map[string]string{
+				"/.github/CODEOWNERS": "* @alice\n/src/ @bob @org/Platform\n",
+				"/owners.txt":         "dave\n",
+			},
+			ExpectedReviewers: []string{"alice", "bob", "carol"},
+			ExpectedTeams:     []string{"Platform"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			ctx := context.Background()
+			s := memory.NewStore()
+
+			repo := &types.Repository{Name: "repo", ProjectName: "project", AdoRepoID: "repo-id", OwnersFormat: tt.OwnersFormat}
+			if err := s.AddRepository(ctx, repo); err != nil {
+				t.Fatal(err)
+			}
+
+			client := &reconcileGitClient{files: tt.Files}
+			coreClient := &reconcileCoreClient{teams: map[string][]string{"Platform": {"carol"}}}
+			a, err := NewAutoReviewer(client, &reconcileIdentityClient{}, coreClient, defaultBotIdentifier, repo,
+				s, s, s, s, Options{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			a.ownersCache = NewOwnersCache()
+
+			if !assert.NoError(t, a.ensureReviewers(ctx)) {
+				return
+			}
+
+			reviewers, err := s.GetAllReviewers(ctx)
+			assert.NoError(t, err)
+			var aliases []string
+			for _, reviewer := range reviewers {
+				aliases = append(aliases, reviewer.Alias)
+				assert.NotEmpty(t, reviewer.AdoID, "Should set the ADO ID for %q", reviewer.Alias)
+			}
+			assert.Equal(t, tt.ExpectedReviewers, aliases)
+
+			teams, err := s.GetAllTeams(ctx)
+			assert.NoError(t, err)
+			var names []string
+			for _, team := range teams {
+				names = append(names, team.Name)
+			}
+			assert.Equal(t, tt.ExpectedTeams, names)
+		})
+	}
+}
+
+func TestEnsureReviewersPartialCache(t *testing.T) {
+	ctx := context.Background()
+	s := memory.NewStore()
+
+	repo := &types.Repository{Name: "repo", ProjectName: "project", AdoRepoID: "repo-id"}
+	if err := s.AddRepository(ctx, repo); err != nil {
+		t.Fatal(err)
+	}
+
+	client := &reconcileGitClient{
+		files: map[string]string{
+			"/owners.txt":     "alice\n",
+			"/src/owners.txt": "bob\n",
+		},
+		failures: map[string]bool{"/src/owners.txt": true},
+	}
+	a, err := NewAutoReviewer(client, &reconcileIdentityClient{}, &reconcileCoreClient{}, defaultBotIdentifier, repo,
+		s, s, s, s, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.ownersCache = NewOwnersCache()
+
+	if !assert.NoError(t, a.ensureReviewers(ctx), "Should skip the owners file that can't be read") {
+		return
+	}
+
+	_, err = s.GetReviewer(ctx, "alice")
+	assert.NoError(t, err, "Should register the owners of the other files")
+
+	content, ok := a.ownersCache.Get(repo.AdoRepoID, reconcileCommitID, "/owners.txt")
+	if assert.True(t, ok, "Should cache the owners files that were read") {
+		assert.Equal(t, "alice\n", *content)
+	}
+
+	_, ok = a.ownersCache.Get(repo.AdoRepoID, reconcileCommitID, "/src/owners.txt")
+	assert.False(t, ok, "Should not cache the owners file that failed")
+
+	_, ok = a.ownersCache.Get(repo.AdoRepoID, reconcileCommitID, "/docs/owners.txt")
+	assert.False(t, ok, "Should not treat missing owners files as known when a file failed")
+}
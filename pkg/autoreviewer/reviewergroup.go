@@ -5,6 +5,7 @@ import (
 	"github.com/samkreter/go-core/log"
 	"path/filepath"
 	"strings"
+	"sync"
 
 	adogit "github.com/microsoft/azure-devops-go-api/azuredevops/git"
 	"github.com/pkg/errors"
@@ -14,6 +15,12 @@ const (
 	PrefixComment = ";"
 	PrefixNoNotify = "*"
 	PrefixGroup = "; TEAM: "
+	PrefixInclude = "file:"
+
+	// DirectiveNoParent stops owners from being inherited from parent directories
+	DirectiveNoParent = "set noparent"
+
+	ownersFileName = "owners.txt"
 )
 
 var (
@@ -22,37 +29,62 @@ var (
 
 type PullRequest struct {
 	adogit.GitPullRequest
+
+	// changes caches the changed paths so the filters and the reviewer selection only get them from ADO once
+	changesMu  sync.Mutex
+	changes    []string
+	hasChanges bool
 }
 
 type ReviewerGroup struct {
 	Owners map[string]bool
 	Teams  map[string]bool
+	// NoNotify flags the owners listed with the no notify prefix, they can approve but are never auto assigned
+	NoNotify map[string]bool
+
+	// NoParent is set when the owners file stops inheriting owners from its parent directories
+	NoParent bool
+	// Includes are the other owners files to include, relative paths are from the including file's directory
+	Includes []string
+	// Files are the owners files the owners were read from
+	Files []string
 }
 
 // GetRequiredReviewerGroups gets all required reviewers from the owners files based on changes made in the PR.
-// TODO: Use cache for finding the owners files.
 func (pr *PullRequest) GetRequiredReviewerGroups(ctx context.Context,  client adogit.Client) ([]*ReviewerGroup, error) {
-	ownerFilesMap  := map[string]*ReviewerGroup{}
-
 	changePaths, err := pr.GetAllChanges(ctx, client)
 	if err != nil {
 		return nil, err
 	}
 
+	return pr.getReviewerGroupsForPaths(ctx, client, DefaultOwnersCache, changePaths)
+}
+
+// getReviewerGroupsForPaths gets the required reviewers from the owners files at the target branch head for the changed paths
+func (pr *PullRequest) getReviewerGroupsForPaths(ctx context.Context, client adogit.Client, cache *OwnersCache, changePaths []string) ([]*ReviewerGroup, error) {
+	repoID := pr.Repository.Id.String()
+	commitID, err := getBranchHead(ctx, client, repoID, *pr.TargetRefName)
+	if err != nil {
+		return nil, err
+	}
+
+	loader := newOwnersLoader(client, cache, repoID, commitID)
+	ownerFilesMap := map[string]*ReviewerGroup{}
+
 	for _, path := range changePaths {
 		pathDir := filepath.Dir(path)
 
 		if ownerFilesMap[pathDir] == nil {
-			ownersFile, err := getRelatedOwnersFile(ctx, client, pr.Repository.Id.String(), path)
+			reviewerGroup, err := loader.getInheritedOwners(ctx, pathDir)
 			if err != nil {
 				return nil, err
 			}
 
-			ownerFilesMap[pathDir] = newReviewerGroupFromOwnersFile(*ownersFile.Content)
+			ownerFilesMap[pathDir] = reviewerGroup
 		}
 	}
 
-	reviewerGroups := make([]*ReviewerGroup, len(ownerFilesMap))
+	reviewerGroups := make([]*ReviewerGroup, 0, len(ownerFilesMap))
 	for _, reviewerGroup := range ownerFilesMap {
 		reviewerGroups = append(reviewerGroups, reviewerGroup)
 	}
@@ -60,30 +92,196 @@ func (pr *PullRequest) GetRequiredReviewerGroups(ctx context.Context,  client ad
 	return reviewerGroups, nil
 }
 
-// getRelatedOwnersFile returns the owners file that impacts this change. This is the owners file that is
-// the closest directory moving upwards.
-func getRelatedOwnersFile(ctx context.Context, client adogit.Client, repoID, path string) (*adogit.GitItem, error) {
-	dirPath := filepath.Dir(path)
-	ownersPath := filepath.Join(dirPath, "owners.txt")
+// ownersLoader reads and parses the owners files of a repository at a commit, each file is only read once.
+// File contents are shared across pull requests through the cache when it is set.
+type ownersLoader struct {
+	client   adogit.Client
+	cache    *OwnersCache
+	repoID   string
+	commitID string
+	files    map[string]*ReviewerGroup
+
+	// readFile gets a file's content, nil when the file doesn't exist
+	readFile func(ctx context.Context, path string) (*string, error)
+}
 
-	ownersFile, err := getFileFromADO(ctx, client, repoID, ownersPath)
-	if err != nil {
-		switch {
-		case errors.Is(err, errNotFound):
-			if dirPath == "/" {
-				return nil, errNotFound
+func newOwnersLoader(client adogit.Client, cache *OwnersCache, repoID, commitID string) *ownersLoader {
+	l := &ownersLoader{
+		client:   client,
+		cache:    cache,
+		repoID:   repoID,
+		commitID: commitID,
+		files:    map[string]*ReviewerGroup{},
+	}
+	l.readFile = l.getContent
+	return l
+}
+
+// newReaderOwnersLoader creates an owners loader reading files with readFile, used for trees outside of a pull request
+func newReaderOwnersLoader(readFile func(ctx context.Context, path string) (*string, error)) *ownersLoader {
+	return &ownersLoader{
+		files:    map[string]*ReviewerGroup{},
+		readFile: readFile,
+	}
+}
+
+// getInheritedOwners merges the owners files from the directory up to the root, stopping at a file with
+// "set noparent". Directories without an owners file are skipped.
+func (l *ownersLoader) getInheritedOwners(ctx context.Context, dirPath string) (*ReviewerGroup, error) {
+	merged := &ReviewerGroup{
+		Owners:   map[string]bool{},
+		Teams:    map[string]bool{},
+		NoNotify: map[string]bool{},
+	}
+
+	for {
+		reviewerGroup, err := l.getOwnersFile(ctx, filepath.Join(dirPath, ownersFileName), map[string]bool{})
+		if err != nil {
+			return nil, err
+		}
+
+		if reviewerGroup != nil {
+			merged.merge(reviewerGroup)
+			if reviewerGroup.NoParent {
+				break
 			}
-			return getRelatedOwnersFile(ctx, client, repoID, dirPath)
-		default:
+		}
+
+		if dirPath == "/" || dirPath == "." {
+			break
+		}
+		dirPath = filepath.Dir(dirPath)
+	}
+
+	return merged, nil
+}
+
+// getOwnersFile gets the owners file with its includes merged in, nil when the file doesn't exist.
+// visiting holds the files being included to stop include cycles.
+func (l *ownersLoader) getOwnersFile(ctx context.Context, path string, visiting map[string]bool) (*ReviewerGroup, error) {
+	if reviewerGroup, ok := l.files[path]; ok {
+		return reviewerGroup, nil
+	}
+
+	if visiting[path] {
+		return nil, errors.Errorf("owners file %s includes itself", path)
+	}
+	visiting[path] = true
+	defer delete(visiting, path)
+
+	content, err := l.readFile(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	if content == nil {
+		l.files[path] = nil
+		return nil, nil
+	}
+
+	reviewerGroup := newReviewerGroupFromOwnersFile(*content)
+	reviewerGroup.Files = []string{path}
+
+	// Included files only add their owners and teams, their noparent is ignored
+	for _, include := range reviewerGroup.Includes {
+		if !filepath.IsAbs(include) {
+			include = filepath.Join(filepath.Dir(path), include)
+		}
+
+		included, err := l.getOwnersFile(ctx, include, visiting)
+		if err != nil {
 			return nil, err
 		}
+		if included == nil {
+			return nil, errors.Errorf("owners file %s includes missing file %s", path, include)
+		}
+		reviewerGroup.merge(included)
+	}
+
+	l.files[path] = reviewerGroup
+	return reviewerGroup, nil
+}
+
+// getContent gets the file content from the cache or ADO, nil when the file doesn't exist
+func (l *ownersLoader) getContent(ctx context.Context, path string) (*string, error) {
+	if l.cache != nil && l.commitID != "" {
+		if content, ok := l.cache.Get(l.repoID, l.commitID, path); ok {
+			return content, nil
+		}
+	}
+
+	var content *string
+	ownersFile, err := getFileAtCommit(ctx, l.client, l.repoID, l.commitID, path)
+	switch {
+	case errors.Is(err, errNotFound):
+	case err != nil:
+		return nil, err
+	default:
+		content = ownersFile.Content
+	}
+
+	if l.cache != nil && l.commitID != "" {
+		l.cache.Set(l.repoID, l.commitID, path, content)
+	}
+	return content, nil
+}
+
+// merge adds the other group's owners and teams
+func (r *ReviewerGroup) merge(other *ReviewerGroup) {
+	for owner, ok := range other.Owners {
+		if !ok {
+			continue
+		}
+
+		// An owner listed normally in any file is notified
+		_, listed := r.Owners[owner]
+		switch {
+		case !other.NoNotify[owner]:
+			delete(r.NoNotify, owner)
+		case !listed:
+			r.NoNotify[owner] = true
+		}
+		r.Owners[owner] = true
+	}
+	for team, ok := range other.Teams {
+		if ok {
+			r.Teams[team] = true
+		}
 	}
+	for _, file := range other.Files {
+		if !containsString(r.Files, file) {
+			r.Files = append(r.Files, file)
+		}
+	}
+}
 
-	return ownersFile, nil
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
 }
 
-// GetAllChanges returns all changes from all iterations of the pull request
+// GetAllChanges returns all changes from all iterations of the pull request, they are only read from ADO once
 func (pr *PullRequest) GetAllChanges(ctx context.Context, client adogit.Client) ([]string, error) {
+	pr.changesMu.Lock()
+	defer pr.changesMu.Unlock()
+
+	if pr.hasChanges {
+		return pr.changes, nil
+	}
+
+	changes, err := pr.getAllChanges(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+
+	pr.changes, pr.hasChanges = changes, true
+	return changes, nil
+}
+
+func (pr *PullRequest) getAllChanges(ctx context.Context, client adogit.Client) ([]string, error) {
 	logger := log.G(ctx)
 	repositoryID := pr.Repository.Id.String()
 	its, err := client.GetPullRequestIterations(ctx, adogit.GetPullRequestIterationsArgs{
@@ -133,6 +331,7 @@ func newReviewerGroupFromOwnersFile(content string) *ReviewerGroup {
 	reviewerGroup := ReviewerGroup{
 		Owners: map[string]bool{},
 		Teams: map[string]bool{},
+		NoNotify: map[string]bool{},
 	}
 
 	for _, line := range lines {
@@ -143,6 +342,16 @@ func newReviewerGroupFromOwnersFile(content string) *ReviewerGroup {
 		}
 
 		switch {
+		case strings.EqualFold(line, DirectiveNoParent):
+			reviewerGroup.NoParent = true
+			continue
+
+		case strings.HasPrefix(line, PrefixInclude):
+			if include := strings.TrimSpace(strings.TrimPrefix(line, PrefixInclude)); include != "" {
+				reviewerGroup.Includes = append(reviewerGroup.Includes, include)
+			}
+			continue
+
 		// Parse the reviewer group
 		case strings.HasPrefix(line, PrefixGroup):
 			team := strings.TrimSpace(strings.TrimPrefix(line, PrefixGroup))
@@ -153,6 +362,7 @@ func newReviewerGroupFromOwnersFile(content string) *ReviewerGroup {
 		case strings.HasPrefix(line, PrefixNoNotify):
 			owner := strings.TrimSpace(strings.TrimPrefix(line, PrefixNoNotify))
 			reviewerGroup.Owners[owner] = true
+			reviewerGroup.NoNotify[owner] = true
 			continue
 
 		// Ignore comments
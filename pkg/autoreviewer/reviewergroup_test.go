@@ -3,6 +3,7 @@ package autoreviewer
 
 import (
 	"bytes"
+	"context"
 	"log"
 	"testing"
 	"text/template"
@@ -50,21 +51,25 @@ func TestParseOwnerFile(t *testing.T) {
 		Name           string
 		TestOwners     []string
 		ExpectedOwners []string
+		ExpectedNoNotify []string
 		ExpectedTeams  []string
 	}{
 		{Name: "Success Case - Single Team",
-			TestOwners:     []string{PrefixGroup+"Test Team", PrefixNoNotify+"testOwenr", "testNoNotifyOwner", ";this is a comment", ""},
-			ExpectedOwners: []string {"testOwenr", "testNoNotifyOwner"},
+			TestOwners:     []string{PrefixGroup+"Test Team", PrefixNoNotify+"testNoNotifyOwner", "testOwner", ";this is a comment", ""},
+			ExpectedOwners: []string {"testNoNotifyOwner", "testOwner"},
+			ExpectedNoNotify: []string{"testNoNotifyOwner"},
 			ExpectedTeams:  []string{"Test Team"},
 		},
 		{Name: "Success Case - Multiple Team",
-			TestOwners:     []string{PrefixGroup+"Test Team", PrefixGroup+"Test Team2", PrefixNoNotify+"testOwenr", "testNoNotifyOwner", ";this is a comment", ""},
-			ExpectedOwners: []string {"testOwenr", "testNoNotifyOwner"},
+			TestOwners:     []string{PrefixGroup+"Test Team", PrefixGroup+"Test Team2", PrefixNoNotify+"testNoNotifyOwner", "testOwner", ";this is a comment", ""},
+			ExpectedOwners: []string {"testNoNotifyOwner", "testOwner"},
+			ExpectedNoNotify: []string{"testNoNotifyOwner"},
 			ExpectedTeams:  []string{"Test Team", "Test Team2"},
 		},
 		{Name: "No Team",
-			TestOwners:     []string{"testOwenr", "testNoNotifyOwner", ";this is a comment", ""},
-			ExpectedOwners: []string {"testOwenr", "testNoNotifyOwner"},
+			TestOwners:     []string{"testOwner", PrefixNoNotify+"testNoNotifyOwner", ";this is a comment", ""},
+			ExpectedOwners: []string {"testOwner", "testNoNotifyOwner"},
+			ExpectedNoNotify: []string{"testNoNotifyOwner"},
 			ExpectedTeams:  []string{},
 		},
 		{Name: "No Owners",
@@ -93,10 +98,100 @@ func TestParseOwnerFile(t *testing.T) {
 			for _, expectOwner := range tt.ExpectedOwners {
 				assert.True(t, reviewerGroup.Owners[expectOwner], "Should have correct owners")
 			}
+
+			assert.Equal(t, len(tt.ExpectedNoNotify), len(reviewerGroup.NoNotify), "Should have expected number of no notify owners")
+			for _, expectNoNotify := range tt.ExpectedNoNotify {
+				assert.True(t, reviewerGroup.NoNotify[expectNoNotify], "Should flag no notify owners")
+			}
 		})
 	}
 }
 
+func TestGetInheritedOwners(t *testing.T) {
+	client := &fakeGitClient{items: map[string]string{
+		"/owners.txt":             "rootOwner\n" + PrefixGroup + "Root Team",
+		"/src/owners.txt":         "srcOwner\nfile: /shared/owners.txt",
+		"/src/lib/owners.txt":     DirectiveNoParent + "\nlibOwner",
+		"/src/lib/sub/owners.txt": "subOwner\nfile: ../../../shared/owners.txt",
+		"/shared/owners.txt":      "sharedOwner\n" + DirectiveNoParent,
+		"/cycle/owners.txt":       "file: /cycle/other/owners.txt",
+		"/cycle/other/owners.txt": "file: /cycle/owners.txt",
+		"/missing/owners.txt":     "file: /none/owners.txt",
+	}}
+
+	tests := []struct {
+		Name           string
+		Dir            string
+		ExpectedOwners []string
+		ExpectedTeams  []string
+		ExpectedErr    bool
+	}{
+		{
+			Name:           "Root",
+			Dir:            "/",
+			ExpectedOwners: []string{"rootOwner"},
+			ExpectedTeams:  []string{"Root Team"},
+		},
+		{
+			Name:           "Inherits From Parents And Includes",
+			Dir:            "/src/other",
+			ExpectedOwners: []string{"srcOwner", "sharedOwner", "rootOwner"},
+			ExpectedTeams:  []string{"Root Team"},
+		},
+		{
+			Name:           "No Parent",
+			Dir:            "/src/lib",
+			ExpectedOwners: []string{"libOwner"},
+		},
+		{
+			Name:           "Inherits Until No Parent",
+			Dir:            "/src/lib/sub",
+			ExpectedOwners: []string{"subOwner", "sharedOwner", "libOwner"},
+		},
+		{
+			Name:        "Include Cycle",
+			Dir:         "/cycle",
+			ExpectedErr: true,
+		},
+		{
+			Name:        "Missing Include",
+			Dir:         "/missing",
+			ExpectedErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			loader := newOwnersLoader(client, nil, "repo-id", "")
+			reviewerGroup, err := loader.getInheritedOwners(context.Background(), tt.Dir)
+			if tt.ExpectedErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, len(tt.ExpectedOwners), len(reviewerGroup.Owners), "Should have expected number owners")
+			for _, expectOwner := range tt.ExpectedOwners {
+				assert.True(t, reviewerGroup.Owners[expectOwner], "Should have owner %q", expectOwner)
+			}
+
+			assert.Equal(t, len(tt.ExpectedTeams), len(reviewerGroup.Teams), "Should have expected number of teams")
+			for _, expectTeam := range tt.ExpectedTeams {
+				assert.True(t, reviewerGroup.Teams[expectTeam], "Should have team %q", expectTeam)
+			}
+		})
+	}
+}
+
+func TestReviewerGroupMergeNoNotify(t *testing.T) {
+	reviewerGroup := newReviewerGroupFromOwnersFile(PrefixNoNotify + "onlyNoNotify\n" + PrefixNoNotify + "mixed\nnormal")
+	reviewerGroup.merge(newReviewerGroupFromOwnersFile("mixed\n" + PrefixNoNotify + "normal\n" + PrefixNoNotify + "parentNoNotify"))
+
+	assert.Equal(t, 4, len(reviewerGroup.Owners), "Should merge all owners")
+	assert.Equal(t, map[string]bool{"onlyNoNotify": true, "parentNoNotify": true}, reviewerGroup.NoNotify,
+		"Should only keep owners that are no notify in every file")
+}
+
 func generateTestOwnersFile(owners []string) string{
 	ownersFileTmpl := `
     {{range $owner := .}}
@@ -0,0 +1,240 @@
+package autoreviewer
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/samkreter/devopshelper/pkg/store"
+	"github.com/samkreter/devopshelper/pkg/types"
+)
+
+// SelectionStrategy selects up to count distinct reviewers from the aliases and marks them as reviewing.
+// store.ErrNotFound is returned when none of the aliases can be selected.
+type SelectionStrategy interface {
+	Select(ctx context.Context, aliases []string, count int) ([]*types.Reviewer, error)
+}
+
+// selectionStrategy returns the selection strategy configured for the repository. The strategies keep no state
+// of their own, so every process selects the same reviewers from the same store.
+func (a *AutoReviewer) selectionStrategy() (SelectionStrategy, error) {
+	switch a.Repo.SelectionStrategy {
+	case "", types.SelectionStrategyLRU:
+		return NewLRUStrategy(a.ReviewerStore), nil
+	case types.SelectionStrategyLeastOpenReviews:
+		// The review load counts the required reviews across every repository, ties go to the least recently
+		// used when it's unknown
+		return NewLeastOpenReviewsStrategy(a.ReviewerStore, a.reviewLoad), nil
+	case types.SelectionStrategyWeightedRoundRobin:
+		return NewWeightedRoundRobinStrategy(a.ReviewerStore), nil
+	case types.SelectionStrategyRandom:
+		return NewRandomStrategy(a.ReviewerStore, a.Repo.SelectionSeed), nil
+	default:
+		return nil, fmt.Errorf("unknown selection strategy %q", a.Repo.SelectionStrategy)
+	}
+}
+
+// LRUStrategy selects the least recently used reviewers
+type LRUStrategy struct {
+	reviewerStore store.ReviewerStore
+}
+
+// NewLRUStrategy creates a new least recently used selection strategy
+func NewLRUStrategy(reviewerStore store.ReviewerStore) *LRUStrategy {
+	return &LRUStrategy{reviewerStore: reviewerStore}
+}
+
+// Select pops the least recently used reviewers from the store
+func (s *LRUStrategy) Select(ctx context.Context, aliases []string, count int) ([]*types.Reviewer, error) {
+	return s.reviewerStore.PopLRUReviewer(ctx, aliases, count)
+}
+
+// WeightedRoundRobinStrategy selects reviewers in proportion to their weight. The rotation is derived from the
+// stored last review times, the reviewers that have waited the longest scaled by their weight are selected.
+// Reviewers without a weight have a weight of 1.
+type WeightedRoundRobinStrategy struct {
+	reviewerStore store.ReviewerStore
+}
+
+// NewWeightedRoundRobinStrategy creates a new weighted round robin selection strategy
+func NewWeightedRoundRobinStrategy(reviewerStore store.ReviewerStore) *WeightedRoundRobinStrategy {
+	return &WeightedRoundRobinStrategy{reviewerStore: reviewerStore}
+}
+
+// Select selects the reviewers with the longest weighted wait since their last review
+func (s *WeightedRoundRobinStrategy) Select(ctx context.Context, aliases []string, count int) ([]*types.Reviewer, error) {
+	candidates, err := getCandidates(ctx, s.reviewerStore, aliases)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return weightedWait(candidates[i], now) > weightedWait(candidates[j], now)
+	})
+
+	if len(candidates) > count {
+		candidates = candidates[:count]
+	}
+
+	return markReviewed(ctx, s.reviewerStore, candidates, count)
+}
+
+func weightedWait(reviewer *types.Reviewer, now time.Time) float64 {
+	return now.Sub(reviewer.LastReviewTime).Seconds() * float64(reviewerWeight(reviewer))
+}
+
+func reviewerWeight(reviewer *types.Reviewer) int {
+	if reviewer.Weight <= 0 {
+		return 1
+	}
+	return reviewer.Weight
+}
+
+// RandomStrategy selects reviewers at random. The selections are seeded from the seed and the stored review counts
+// of the candidates, so the same seed and reviews produce the same selections in every process.
+type RandomStrategy struct {
+	reviewerStore store.ReviewerStore
+	seed          int64
+}
+
+// NewRandomStrategy creates a new random selection strategy
+func NewRandomStrategy(reviewerStore store.ReviewerStore, seed int64) *RandomStrategy {
+	return &RandomStrategy{
+		reviewerStore: reviewerStore,
+		seed:          seed,
+	}
+}
+
+// Select selects random reviewers
+func (s *RandomStrategy) Select(ctx context.Context, aliases []string, count int) ([]*types.Reviewer, error) {
+	candidates, err := getCandidates(ctx, s.reviewerStore, aliases)
+	if err != nil {
+		return nil, err
+	}
+
+	rnd := rand.New(rand.NewSource(s.seed ^ reviewsHash(candidates)))
+	rnd.Shuffle(len(candidates), func(i, j int) {
+		candidates[i], candidates[j] = candidates[j], candidates[i]
+	})
+
+	if len(candidates) > count {
+		candidates = candidates[:count]
+	}
+
+	return markReviewed(ctx, s.reviewerStore, candidates, count)
+}
+
+// reviewsHash hashes the aliases and review counts of the candidates, it changes every time one is selected
+func reviewsHash(candidates []*types.Reviewer) int64 {
+	h := fnv.New64a()
+	for _, candidate := range candidates {
+		fmt.Fprintf(h, "%s:%d;", candidate.Alias, candidate.Reviews)
+	}
+	return int64(h.Sum64())
+}
+
+// OpenReviewCounter counts the active pull requests each reviewer is already reviewing, keyed by ADO ID
+type OpenReviewCounter interface {
+	OpenReviewCounts(ctx context.Context) (map[string]int, error)
+}
+
+// LeastOpenReviewsStrategy selects the reviewers with the fewest active reviews, ties go to the least recently used
+type LeastOpenReviewsStrategy struct {
+	reviewerStore store.ReviewerStore
+	counter       OpenReviewCounter
+}
+
+// NewLeastOpenReviewsStrategy creates a new least open reviews selection strategy
+func NewLeastOpenReviewsStrategy(reviewerStore store.ReviewerStore, counter OpenReviewCounter) *LeastOpenReviewsStrategy {
+	return &LeastOpenReviewsStrategy{
+		reviewerStore: reviewerStore,
+		counter:       counter,
+	}
+}
+
+// Select selects the reviewers with the fewest open reviews
+func (s *LeastOpenReviewsStrategy) Select(ctx context.Context, aliases []string, count int) ([]*types.Reviewer, error) {
+	candidates, err := getCandidates(ctx, s.reviewerStore, aliases)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(candidates) == 0 || count <= 0 {
+		return markReviewed(ctx, s.reviewerStore, nil, count)
+	}
+
+	openReviews, err := s.counter.OpenReviewCounts(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to count open reviews")
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		ci, cj := openReviews[candidates[i].AdoID], openReviews[candidates[j].AdoID]
+		if ci != cj {
+			return ci < cj
+		}
+		return candidates[i].LastReviewTime.Before(candidates[j].LastReviewTime)
+	})
+
+	if len(candidates) > count {
+		candidates = candidates[:count]
+	}
+
+	return markReviewed(ctx, s.reviewerStore, candidates, count)
+}
+
+// getCandidates gets the distinct available reviewers for the aliases sorted by alias, unknown aliases are skipped
+func getCandidates(ctx context.Context, reviewerStore store.ReviewerStore, aliases []string) ([]*types.Reviewer, error) {
+	now := time.Now().UTC()
+	sorted := append([]string{}, aliases...)
+	sort.Strings(sorted)
+
+	var candidates []*types.Reviewer
+	for i, alias := range sorted {
+		if i > 0 && sorted[i-1] == alias {
+			continue
+		}
+
+		reviewer, err := reviewerStore.GetReviewer(ctx, alias)
+		if errors.Is(err, store.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get reviewer %q", alias)
+		}
+		if !reviewer.Available(now) {
+			continue
+		}
+		candidates = append(candidates, reviewer)
+	}
+
+	return candidates, nil
+}
+
+// markReviewed records the review for the selected reviewers so the other strategies see the assignment
+func markReviewed(ctx context.Context, reviewerStore store.ReviewerStore, selected []*types.Reviewer, count int) ([]*types.Reviewer, error) {
+	if len(selected) == 0 {
+		if count > 0 {
+			return nil, errors.WithStack(store.ErrNotFound)
+		}
+		return nil, nil
+	}
+
+	now := time.Now().UTC()
+	reviewed := make([]*types.Reviewer, 0, len(selected))
+	for _, reviewer := range selected {
+		recorded, err := reviewerStore.RecordReview(ctx, reviewer.Alias, now)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to record review for %q", reviewer.Alias)
+		}
+		reviewed = append(reviewed, recorded)
+	}
+
+	return reviewed, nil
+}
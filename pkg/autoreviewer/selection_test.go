@@ -0,0 +1,245 @@
+package autoreviewer
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/samkreter/devopshelper/pkg/store"
+	"github.com/samkreter/devopshelper/pkg/types"
+)
+
+// fakeReviewerStore implements the parts of store.ReviewerStore used by the selection strategies
+type fakeReviewerStore struct {
+	store.ReviewerStore
+	reviewers map[string]*types.Reviewer
+}
+
+func newFakeReviewerStore(reviewers ...*types.Reviewer) *fakeReviewerStore {
+	s := &fakeReviewerStore{reviewers: map[string]*types.Reviewer{}}
+	for _, reviewer := range reviewers {
+		reviewer.ID = bson.NewObjectId()
+		s.reviewers[reviewer.Alias] = reviewer
+	}
+	return s
+}
+
+func (s *fakeReviewerStore) GetReviewer(ctx context.Context, alias string) (*types.Reviewer, error) {
+	reviewer, ok := s.reviewers[alias]
+	if !ok {
+		return nil, errors.WithStack(store.ErrNotFound)
+	}
+	c := *reviewer
+	return &c, nil
+}
+
+func (s *fakeReviewerStore) UpdateReviewer(ctx context.Context, reviewer *types.Reviewer) error {
+	if _, ok := s.reviewers[reviewer.Alias]; !ok {
+		return errors.WithStack(store.ErrNotFound)
+	}
+	c := *reviewer
+	s.reviewers[reviewer.Alias] = &c
+	return nil
+}
+
+func (s *fakeReviewerStore) RecordReview(ctx context.Context, alias string, now time.Time) (*types.Reviewer, error) {
+	reviewer, ok := s.reviewers[alias]
+	if !ok {
+		return nil, errors.WithStack(store.ErrNotFound)
+	}
+	reviewer.RecordReview(now)
+	c := *reviewer
+	return &c, nil
+}
+
+func (s *fakeReviewerStore) PopLRUReviewer(ctx context.Context, aliases []string, count int) ([]*types.Reviewer, error) {
+	candidates, err := getCandidates(ctx, s, aliases)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].LastReviewTime.Before(candidates[j].LastReviewTime)
+	})
+	if len(candidates) > count {
+		candidates = candidates[:count]
+	}
+
+	return markReviewed(ctx, s, candidates, count)
+}
+
+type fakeOpenReviewCounter map[string]int
+
+func (c fakeOpenReviewCounter) OpenReviewCounts(ctx context.Context) (map[string]int, error) {
+	return c, nil
+}
+
+func TestSelectionStrategies(t *testing.T) {
+	ctx := context.Background()
+	base := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	aliases := []string{"alice", "bob", "carol"}
+
+	newStore := func() *fakeReviewerStore {
+		return newFakeReviewerStore(
+			&types.Reviewer{Alias: "alice", AdoID: "alice-id", Weight: 2, LastReviewTime: base.Add(2 * time.Hour)},
+			&types.Reviewer{Alias: "bob", AdoID: "bob-id", LastReviewTime: base},
+			&types.Reviewer{Alias: "carol", AdoID: "carol-id", LastReviewTime: base.Add(time.Hour)},
+		)
+	}
+
+	tests := []struct {
+		Name             string
+		Strategy         func(s store.ReviewerStore) SelectionStrategy
+		Rounds           int
+		Count            int
+		ExpectedSelected []string
+	}{
+		{
+			Name: "LRU",
+			Strategy: func(s store.ReviewerStore) SelectionStrategy {
+				return NewLRUStrategy(s)
+			},
+			Rounds:           3,
+			Count:            1,
+			ExpectedSelected: []string{"bob", "carol", "alice"},
+		},
+		{
+			Name: "Weighted Round Robin",
+			Strategy: func(s store.ReviewerStore) SelectionStrategy {
+				return NewWeightedRoundRobinStrategy(s)
+			},
+			Rounds:           4,
+			Count:            1,
+			ExpectedSelected: []string{"alice", "bob", "carol", "alice"},
+		},
+		{
+			Name: "Weighted Round Robin Multiple",
+			Strategy: func(s store.ReviewerStore) SelectionStrategy {
+				return NewWeightedRoundRobinStrategy(s)
+			},
+			Rounds:           1,
+			Count:            2,
+			ExpectedSelected: []string{"alice", "bob"},
+		},
+		{
+			Name: "Least Open Reviews",
+			Strategy: func(s store.ReviewerStore) SelectionStrategy {
+				return NewLeastOpenReviewsStrategy(s, fakeOpenReviewCounter{"bob-id": 3, "carol-id": 1})
+			},
+			Rounds:           1,
+			Count:            2,
+			ExpectedSelected: []string{"alice", "carol"},
+		},
+		{
+			Name: "Least Open Reviews Ties Use LRU",
+			Strategy: func(s store.ReviewerStore) SelectionStrategy {
+				return NewLeastOpenReviewsStrategy(s, fakeOpenReviewCounter{})
+			},
+			Rounds:           1,
+			Count:            3,
+			ExpectedSelected: []string{"bob", "carol", "alice"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			s := newStore()
+			strategy := tt.Strategy(s)
+
+			var selected []string
+			for i := 0; i < tt.Rounds; i++ {
+				reviewers, err := strategy.Select(ctx, aliases, tt.Count)
+				if !assert.NoError(t, err) {
+					return
+				}
+				for _, reviewer := range reviewers {
+					selected = append(selected, reviewer.Alias)
+					assert.True(t, s.reviewers[reviewer.Alias].LastReviewTime.After(base.Add(2*time.Hour)), "Should update the last review time")
+				}
+			}
+
+			assert.Equal(t, tt.ExpectedSelected, selected)
+		})
+	}
+}
+
+func TestRandomStrategySeed(t *testing.T) {
+	ctx := context.Background()
+	aliases := []string{"alice", "bob", "carol", "dave", "erin"}
+
+	selectAll := func(seed int64, shared bool) []string {
+		s := newFakeReviewerStore()
+		for _, alias := range aliases {
+			s.reviewers[alias] = &types.Reviewer{Alias: alias, ID: bson.NewObjectId()}
+		}
+
+		strategy := NewRandomStrategy(s, seed)
+
+		var selected []string
+		for i := 0; i < 5; i++ {
+			if !shared {
+				strategy = NewRandomStrategy(s, seed)
+			}
+
+			reviewers, err := strategy.Select(ctx, aliases, 2)
+			assert.NoError(t, err)
+			assert.Equal(t, 2, len(reviewers), "Should select the requested count")
+			assert.NotEqual(t, reviewers[0].Alias, reviewers[1].Alias, "Should select distinct reviewers")
+			selected = append(selected, GetReviewersAlias(reviewers)...)
+		}
+		return selected
+	}
+
+	assert.Equal(t, selectAll(42, true), selectAll(42, true), "Should repeat the selections for the same seed")
+	assert.Equal(t, selectAll(42, true), selectAll(42, false), "Should continue the selections from the stored reviews")
+}
+
+func TestSelectionStrategyNotFound(t *testing.T) {
+	ctx := context.Background()
+
+	strategies := map[string]SelectionStrategy{
+		"LRU":                  NewLRUStrategy(newFakeReviewerStore()),
+		"Weighted Round Robin": NewWeightedRoundRobinStrategy(newFakeReviewerStore()),
+		"Random":               NewRandomStrategy(newFakeReviewerStore(), 1),
+		"Least Open Reviews":   NewLeastOpenReviewsStrategy(newFakeReviewerStore(), fakeOpenReviewCounter{}),
+	}
+
+	for name, strategy := range strategies {
+		t.Run(name, func(t *testing.T) {
+			_, err := strategy.Select(ctx, []string{"unknown"}, 1)
+			assert.True(t, errors.Is(err, store.ErrNotFound), "Should return ErrNotFound, got: %v", err)
+
+			reviewers, err := strategy.Select(ctx, []string{"unknown"}, 0)
+			assert.NoError(t, err)
+			assert.Equal(t, 0, len(reviewers), "Should not select reviewers for a zero count")
+		})
+	}
+}
+
+func TestLeastOpenReviewsUsesReviewLoad(t *testing.T) {
+	ctx := context.Background()
+	s := newFakeReviewerStore(
+		&types.Reviewer{Alias: "alice", AdoID: "alice-id"},
+		&types.Reviewer{Alias: "bob", AdoID: "bob-id"},
+	)
+
+	a := &AutoReviewer{
+		Repo:          &types.Repository{SelectionStrategy: types.SelectionStrategyLeastOpenReviews},
+		ReviewerStore: s,
+		reviewLoad:    ReviewLoad{"alice-id": 2},
+	}
+
+	strategy, err := a.selectionStrategy()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	reviewers, err := strategy.Select(ctx, []string{"alice", "bob"}, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"bob"}, GetReviewersAlias(reviewers), "Should select the reviewer with the fewest open reviews across repositories")
+}
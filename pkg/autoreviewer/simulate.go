@@ -0,0 +1,100 @@
+package autoreviewer
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/samkreter/devopshelper/pkg/store"
+	"github.com/samkreter/devopshelper/pkg/types"
+)
+
+// Simulation selects the reviewers for changes without ADO, such as from a local checkout before pushing.
+// Selecting reviewers updates the reviewer store, so it must be a snapshot and not the live store.
+type Simulation struct {
+	Repo          *types.Repository
+	ReviewerStore store.ReviewerStore
+	TeamStore     store.TeamStore
+	Tree          *OwnersTree
+
+	// Author is the alias of the changes' author, they are never selected
+	Author string
+	// Expertise is used when the repository has an expertise weight
+	Expertise Expertise
+	// ReviewLoad is the known open reviews, reviewer capacity is ignored when it is nil
+	ReviewLoad ReviewLoad
+}
+
+// Run selects the reviewers for the changed paths the same way as a pull request
+func (s *Simulation) Run(ctx context.Context, changePaths []string) (*ReviewerSelection, error) {
+	reviewerGroups, err := s.Tree.GetReviewerGroups(ctx, s.Repo.OwnersFormat, changePaths)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get owners of the changed files")
+	}
+
+	var author *types.Reviewer
+	if s.Author != "" {
+		author, err = s.ReviewerStore.GetReviewer(ctx, s.Author)
+		if errors.Is(err, store.ErrNotFound) {
+			author = &types.Reviewer{Alias: s.Author}
+		} else if err != nil {
+			return nil, errors.Wrapf(err, "failed to get author %s from store", s.Author)
+		}
+	}
+
+	a := &AutoReviewer{
+		Repo:          s.Repo,
+		ReviewerStore: s.ReviewerStore,
+		TeamStore:     s.TeamStore,
+		reviewLoad:    s.ReviewLoad,
+	}
+
+	return a.selectReviewers(ctx, reviewerGroups, author, s.Expertise)
+}
+
+// GetLocalExpertise scores the authors of recent commits to the paths from the git history of a local checkout
+func GetLocalExpertise(ctx context.Context, root string, paths []string, now time.Time) (Expertise, error) {
+	if len(paths) > maxExpertisePaths {
+		paths = paths[:maxExpertisePaths]
+	}
+
+	since := now.Add(-expertiseLookback).Format(time.RFC3339)
+
+	expertise := Expertise{}
+	seen := map[string]bool{}
+	for _, path := range paths {
+		cmd := exec.CommandContext(ctx, "git", "log",
+			"--since="+since,
+			"--max-count="+strconv.Itoa(maxCommitsPerPath),
+			"--format=%H%x09%ae%x09%aI",
+			"--", strings.TrimPrefix(path, "/"))
+		cmd.Dir = root
+
+		out, err := cmd.Output()
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get commits for %s", path)
+		}
+
+		scanner := bufio.NewScanner(bytes.NewReader(out))
+		for scanner.Scan() {
+			fields := strings.Split(scanner.Text(), "\t")
+			if len(fields) != 3 {
+				continue
+			}
+
+			date, err := time.Parse(time.RFC3339, fields[2])
+			if err != nil {
+				continue
+			}
+			expertise.addCommit(seen, fields[0], fields[1], date, now)
+		}
+	}
+
+	return expertise, nil
+}
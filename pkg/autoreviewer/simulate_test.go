@@ -0,0 +1,86 @@
+package autoreviewer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/samkreter/devopshelper/pkg/store/memory"
+	"github.com/samkreter/devopshelper/pkg/types"
+)
+
+func TestSimulationRun(t *testing.T) {
+	ctx := context.Background()
+	base := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	snapshot := memory.NewStore()
+	for i, alias := range []string{"alice", "bob", "carol", "dave"} {
+		assert.NoError(t, snapshot.AddReviewer(ctx, &types.Reviewer{Alias: alias, LastReviewTime: base.Add(time.Duration(i) * time.Hour)}))
+	}
+	assert.NoError(t, snapshot.AddTeam(ctx, &types.Team{Name: "Team", Members: []string{"carol", "dave"}}))
+
+	tree := NewOwnersTree(map[string]string{
+		"/owners.txt":     "alice\n",
+		"/src/owners.txt": "bob\n" + PrefixGroup + "Team\n",
+		"/src/main.go":    "",
+	})
+
+	tests := []struct {
+		Name             string
+		Author           string
+		Counts           *types.ReviewerCounts
+		ExpectedRequired []string
+		ExpectedOptional []string
+	}{
+		{
+			Name:             "Owner And Team Member",
+			Author:           "erin",
+			ExpectedRequired: []string{"alice", "carol"},
+		},
+		{
+			Name:             "Author Is Never Selected",
+			Author:           "alice",
+			ExpectedRequired: []string{"bob", "carol"},
+		},
+		{
+			Name:             "Optional Reviewers",
+			Author:           "erin",
+			Counts:           &types.ReviewerCounts{RequiredOwners: 1, RequiredTeamMembers: 0, Optional: 1},
+			ExpectedRequired: []string{"alice"},
+			ExpectedOptional: []string{"bob"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			simulation := &Simulation{
+				Repo:          &types.Repository{ReviewerCounts: tt.Counts},
+				ReviewerStore: snapshot,
+				TeamStore:     snapshot,
+				Tree:          tree,
+				Author:        tt.Author,
+			}
+
+			// Each run starts from the same snapshot state
+			for i, alias := range []string{"alice", "bob", "carol", "dave"} {
+				reviewer, err := snapshot.GetReviewer(ctx, alias)
+				assert.NoError(t, err)
+				reviewer.LastReviewTime = base.Add(time.Duration(i) * time.Hour)
+				assert.NoError(t, snapshot.UpdateReviewer(ctx, reviewer))
+			}
+
+			selection, err := simulation.Run(ctx, []string{"/src/main.go"})
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			assert.ElementsMatch(t, tt.ExpectedRequired, GetReviewersAlias(selection.Required))
+			assert.ElementsMatch(t, tt.ExpectedOptional, GetReviewersAlias(selection.Optional))
+			for _, alias := range tt.ExpectedRequired {
+				assert.NotEmpty(t, selection.Reasons[alias], "Should have a reason for %s", alias)
+			}
+		})
+	}
+}
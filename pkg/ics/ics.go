@@ -0,0 +1,165 @@
+// Package ics parses the events out of iCalendar (RFC 5545) files, used to import out of office time.
+package ics
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	dateLayout     = "20060102"
+	dateTimeLayout = "20060102T150405"
+
+	busyStatusProperty = "X-MICROSOFT-CDO-BUSYSTATUS"
+)
+
+// Event is a calendar event, the end is exclusive
+type Event struct {
+	Summary    string
+	Start      time.Time
+	End        time.Time
+	BusyStatus string
+}
+
+// OutOfOffice checks if the event marks the attendee as away. Events without a busy status, such as
+// exports from calendars other than outlook, are all treated as out of office.
+func (e *Event) OutOfOffice() bool {
+	return e.BusyStatus == "" || strings.EqualFold(e.BusyStatus, "OOF")
+}
+
+// Parse reads the events from the calendar
+func Parse(r io.Reader) ([]Event, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []Event
+	var event *Event
+	var allDay bool
+	for i, line := range lines {
+		name, params, value, ok := parseLine(line)
+		if !ok {
+			continue
+		}
+
+		switch {
+		case name == "BEGIN" && strings.EqualFold(value, "VEVENT"):
+			event = &Event{}
+			allDay = false
+		case name == "END" && strings.EqualFold(value, "VEVENT"):
+			if event == nil {
+				return nil, errors.Errorf("line %d: END:VEVENT without BEGIN:VEVENT", i+1)
+			}
+			if event.Start.IsZero() {
+				return nil, errors.Errorf("line %d: event %q is missing DTSTART", i+1, event.Summary)
+			}
+			if event.End.IsZero() {
+				// All day events without an end last the day, other events are instantaneous
+				event.End = event.Start
+				if allDay {
+					event.End = event.Start.AddDate(0, 0, 1)
+				}
+			}
+			events = append(events, *event)
+			event = nil
+		case event == nil:
+			continue
+		case name == "SUMMARY":
+			event.Summary = unescape(value)
+		case name == busyStatusProperty:
+			event.BusyStatus = value
+		case name == "DTSTART" || name == "DTEND":
+			t, date, err := parseTime(value, params)
+			if err != nil {
+				return nil, errors.Wrapf(err, "line %d: invalid %s", i+1, name)
+			}
+			if name == "DTSTART" {
+				event.Start = t
+				allDay = date
+			} else {
+				event.End = t
+			}
+		}
+	}
+
+	if event != nil {
+		return nil, errors.New("unterminated VEVENT")
+	}
+
+	return events, nil
+}
+
+// unfold joins the content lines that were split over multiple lines
+func unfold(r io.Reader) ([]string, error) {
+	var lines []string
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(lines) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to read calendar")
+	}
+
+	return lines, nil
+}
+
+// parseLine splits a content line into its upper case name, parameters and value
+func parseLine(line string) (string, map[string]string, string, bool) {
+	sep := strings.Index(line, ":")
+	if sep < 0 {
+		return "", nil, "", false
+	}
+
+	parts := strings.Split(line[:sep], ";")
+	params := make(map[string]string, len(parts)-1)
+	for _, param := range parts[1:] {
+		kv := strings.SplitN(param, "=", 2)
+		if len(kv) == 2 {
+			params[strings.ToUpper(kv[0])] = strings.Trim(kv[1], `"`)
+		}
+	}
+
+	return strings.ToUpper(parts[0]), params, line[sep+1:], true
+}
+
+// parseTime parses a DATE or DATE-TIME value and reports if it was a DATE
+func parseTime(value string, params map[string]string) (time.Time, bool, error) {
+	loc := time.UTC
+	if tzid, ok := params["TZID"]; ok {
+		// Unknown windows style time zone names fall back to UTC
+		if l, err := time.LoadLocation(tzid); err == nil {
+			loc = l
+		}
+	}
+
+	if params["VALUE"] == "DATE" || len(value) == len(dateLayout) {
+		t, err := time.ParseInLocation(dateLayout, value, loc)
+		return t, true, err
+	}
+
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.ParseInLocation(dateTimeLayout, strings.TrimSuffix(value, "Z"), time.UTC)
+		return t, false, err
+	}
+
+	t, err := time.ParseInLocation(dateTimeLayout, value, loc)
+	return t, false, err
+}
+
+var textReplacer = strings.NewReplacer(`\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";", `\\`, `\`)
+
+func unescape(value string) string {
+	return textReplacer.Replace(value)
+}
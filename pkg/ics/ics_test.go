@@ -0,0 +1,90 @@
+package ics
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	pacific, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Skipf("time zone database not available: %v", err)
+	}
+
+	tests := []struct {
+		Name           string
+		Calendar       string
+		ExpectedEvents []Event
+		ExpectedErr    bool
+	}{
+		{
+			Name: "UTC Event",
+			Calendar: "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nSUMMARY:Vacation\\, beach\r\n" +
+				"DTSTART:20200106T090000Z\r\nDTEND:20200110T170000Z\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n",
+			ExpectedEvents: []Event{{
+				Summary: "Vacation, beach",
+				Start:   time.Date(2020, 1, 6, 9, 0, 0, 0, time.UTC),
+				End:     time.Date(2020, 1, 10, 17, 0, 0, 0, time.UTC),
+			}},
+		},
+		{
+			Name: "All Day Event Without End",
+			Calendar: "BEGIN:VEVENT\nSUMMARY:Out\nDTSTART;VALUE=DATE:20200106\n" +
+				"X-MICROSOFT-CDO-BUSYSTATUS:OOF\nEND:VEVENT\n",
+			ExpectedEvents: []Event{{
+				Summary:    "Out",
+				Start:      time.Date(2020, 1, 6, 0, 0, 0, 0, time.UTC),
+				End:        time.Date(2020, 1, 7, 0, 0, 0, 0, time.UTC),
+				BusyStatus: "OOF",
+			}},
+		},
+		{
+			Name: "Time Zone And Folded Lines",
+			Calendar: "BEGIN:VEVENT\nSUMMARY:Conf\n erence\nDTSTART;TZID=America/Los_Angeles:20200106T090000\n" +
+				"DTEND;TZID=\"America/Los_Angeles\":20200106T100000\nEND:VEVENT\n",
+			ExpectedEvents: []Event{{
+				Summary: "Conference",
+				Start:   time.Date(2020, 1, 6, 9, 0, 0, 0, pacific),
+				End:     time.Date(2020, 1, 6, 10, 0, 0, 0, pacific),
+			}},
+		},
+		{
+			Name:        "Missing Start",
+			Calendar:    "BEGIN:VEVENT\nSUMMARY:Out\nEND:VEVENT\n",
+			ExpectedErr: true,
+		},
+		{
+			Name:        "Invalid Start",
+			Calendar:    "BEGIN:VEVENT\nDTSTART:tomorrow\nEND:VEVENT\n",
+			ExpectedErr: true,
+		},
+		{
+			Name:        "Unterminated Event",
+			Calendar:    "BEGIN:VEVENT\nDTSTART:20200106T090000Z\n",
+			ExpectedErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			events, err := Parse(strings.NewReader(tt.Calendar))
+			if tt.ExpectedErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			if assert.Equal(t, len(tt.ExpectedEvents), len(events)) {
+				for i, expected := range tt.ExpectedEvents {
+					assert.Equal(t, expected.Summary, events[i].Summary)
+					assert.Equal(t, expected.BusyStatus, events[i].BusyStatus)
+					assert.True(t, expected.Start.Equal(events[i].Start), "Start %v should equal %v", events[i].Start, expected.Start)
+					assert.True(t, expected.End.Equal(events[i].End), "End %v should equal %v", events[i].End, expected.End)
+				}
+			}
+		})
+	}
+}
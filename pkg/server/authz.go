@@ -0,0 +1,116 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/samkreter/go-core/log"
+
+	"github.com/samkreter/devopshelper/pkg/types"
+)
+
+// getUserFromContext returns the authenticated user added by the AuthMiddleware
+func getUserFromContext(ctx context.Context) *types.GraphUser {
+	user, ok := ctx.Value(userInfoContextKey).(*types.GraphUser)
+	if !ok {
+		return nil
+	}
+
+	return user
+}
+
+// isAdmin checks if the user is in the server's admin list
+func (s *Server) isAdmin(user *types.GraphUser) bool {
+	return userInList(user, s.Options.Admins)
+}
+
+// canEditRepository checks if the user is an admin or one of the repository's owners
+func (s *Server) canEditRepository(user *types.GraphUser, repo *types.Repository) bool {
+	return s.isAdmin(user) || userInList(user, repo.Owners)
+}
+
+// canEditReviewer checks if the user is an admin or the reviewer, matched by the alias part of their user principal name
+func (s *Server) canEditReviewer(user *types.GraphUser, reviewer *types.Reviewer) bool {
+	if s.isAdmin(user) {
+		return true
+	}
+	if user == nil {
+		return false
+	}
+
+	parts := strings.SplitN(user.UserPrincipalName, "@", 2)
+	return len(parts) == 2 && strings.EqualFold(parts[0], reviewer.Alias)
+}
+
+// requireAdmin only allows admins through to the handler
+func (s *Server) requireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+
+		user := getUserFromContext(ctx)
+		if !s.isAdmin(user) {
+			log.G(ctx).Warnf("denied admin access to %s %s", req.Method, req.URL.Path)
+			http.Error(w, "admin access is required", http.StatusForbidden)
+			return
+		}
+
+		next(w, req)
+	}
+}
+
+// userInList checks the user's object id and user principal name against the list of identities. The mail
+// isn't used since it isn't a verified identity for every authenticator.
+func userInList(user *types.GraphUser, identities []string) bool {
+	if user == nil {
+		return false
+	}
+
+	for _, identity := range identities {
+		identity = strings.TrimSpace(identity)
+		if identity == "" {
+			continue
+		}
+
+		if strings.EqualFold(identity, user.ID) || strings.EqualFold(identity, user.UserPrincipalName) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// normalizeIdentities trims the identities and drops empty entries
+func normalizeIdentities(identities []string) []string {
+	normalized := make([]string, 0, len(identities))
+	for _, identity := range identities {
+		identity = strings.TrimSpace(identity)
+		if identity != "" {
+			normalized = append(normalized, identity)
+		}
+	}
+
+	return normalized
+}
+
+// equalIdentities checks if both lists contain the same identities, ignoring order and case
+func equalIdentities(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	counts := map[string]int{}
+	for _, identity := range a {
+		counts[strings.ToLower(identity)]++
+	}
+
+	for _, identity := range b {
+		identity = strings.ToLower(identity)
+		if counts[identity] == 0 {
+			return false
+		}
+		counts[identity]--
+	}
+
+	return true
+}
@@ -0,0 +1,170 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/globalsign/mgo/bson"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/samkreter/devopshelper/pkg/types"
+)
+
+func TestAuthorization(t *testing.T) {
+	repoID := bson.NewObjectId().Hex()
+	repoPath := "/api/repositories/" + repoID
+
+	tests := []struct {
+		Name           string
+		Method         string
+		Path           string
+		Token          string
+		Body           string
+		ExpectedStatus int
+	}{
+		{
+			Name:           "Missing Authorization Header",
+			Method:         "GET",
+			Path:           "/api/repositories",
+			ExpectedStatus: http.StatusUnauthorized,
+		},
+		{
+			Name:           "Invalid Token",
+			Method:         "GET",
+			Path:           "/api/repositories",
+			Token:          "Bearer invalid",
+			ExpectedStatus: http.StatusUnauthorized,
+		},
+		{
+			Name:           "User Can List Repositories",
+			Method:         "GET",
+			Path:           "/api/repositories",
+			Token:          userToken,
+			ExpectedStatus: http.StatusOK,
+		},
+		{
+			Name:           "User Can't Create Repository",
+			Method:         "POST",
+			Path:           "/api/repositories",
+			Token:          userToken,
+			Body:           `{"name": "new", "projectName": "project"}`,
+			ExpectedStatus: http.StatusForbidden,
+		},
+		{
+			Name:           "Owner Can't Create Repository",
+			Method:         "POST",
+			Path:           "/api/repositories",
+			Token:          ownerToken,
+			Body:           `{"name": "new", "projectName": "project"}`,
+			ExpectedStatus: http.StatusForbidden,
+		},
+		{
+			Name:           "User Can't Update Repository",
+			Method:         "PUT",
+			Path:           repoPath,
+			Token:          userToken,
+			Body:           `{"name": "repo", "projectName": "project", "owners": ["owner@contoso.com"]}`,
+			ExpectedStatus: http.StatusForbidden,
+		},
+		{
+			Name:           "Owner Can Update Repository",
+			Method:         "PUT",
+			Path:           repoPath,
+			Token:          ownerToken,
+			Body:           `{"name": "repo", "projectName": "project", "owners": ["owner@contoso.com"]}`,
+			ExpectedStatus: http.StatusOK,
+		},
+		{
+			Name:           "Owner Can't Change Owners",
+			Method:         "PUT",
+			Path:           repoPath,
+			Token:          ownerToken,
+			Body:           `{"name": "repo", "projectName": "project", "owners": ["owner@contoso.com", "alice@contoso.com"]}`,
+			ExpectedStatus: http.StatusForbidden,
+		},
+		{
+			Name:           "Admin Can Change Owners",
+			Method:         "PUT",
+			Path:           repoPath,
+			Token:          adminToken,
+			Body:           `{"name": "repo", "projectName": "project", "owners": ["alice@contoso.com"]}`,
+			ExpectedStatus: http.StatusOK,
+		},
+		{
+			Name:           "User Can't Disable Repository",
+			Method:         "POST",
+			Path:           repoPath + "/disable",
+			Token:          userToken,
+			ExpectedStatus: http.StatusForbidden,
+		},
+		{
+			Name:           "Owner Can Disable Repository",
+			Method:         "POST",
+			Path:           repoPath + "/disable",
+			Token:          ownerToken,
+			ExpectedStatus: http.StatusOK,
+		},
+		{
+			Name:           "User Can't Delete Repository",
+			Method:         "DELETE",
+			Path:           repoPath,
+			Token:          userToken,
+			ExpectedStatus: http.StatusForbidden,
+		},
+		{
+			Name:           "Owner Can't Create Reviewer",
+			Method:         "POST",
+			Path:           "/api/reviewers",
+			Token:          ownerToken,
+			Body:           `{"alias": "bob", "adoId": "bob-id"}`,
+			ExpectedStatus: http.StatusForbidden,
+		},
+		{
+			Name:           "User Can't Update Reviewer",
+			Method:         "PUT",
+			Path:           "/api/reviewers/alice",
+			Token:          userToken,
+			Body:           `{"weight": 5}`,
+			ExpectedStatus: http.StatusForbidden,
+		},
+		{
+			Name:           "User Can't Delete Team",
+			Method:         "DELETE",
+			Path:           "/api/teams/team",
+			Token:          userToken,
+			ExpectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			ctx := context.Background()
+			server, s := newTestServer(t, nil)
+
+			repo := &types.Repository{ID: bson.ObjectIdHex(repoID), Name: "repo", ProjectName: "project", Owners: []string{"owner@contoso.com"}}
+			if err := s.AddRepository(ctx, repo); err != nil {
+				t.Fatalf("failed to add repository: %v", err)
+			}
+			if err := s.AddReviewer(ctx, &types.Reviewer{Alias: "alice", AdoID: "alice-id"}); err != nil {
+				t.Fatalf("failed to add reviewer: %v", err)
+			}
+			if err := s.AddTeam(ctx, &types.Team{Name: "team"}); err != nil {
+				t.Fatalf("failed to add team: %v", err)
+			}
+
+			rec := doRequest(server, tt.Method, tt.Path, tt.Token, tt.Body)
+			assert.Equal(t, tt.ExpectedStatus, rec.Code, strings.TrimSpace(rec.Body.String()))
+		})
+	}
+}
+
+func TestUserInList(t *testing.T) {
+	identities := []string{"admin@contoso.com", "00000000-0000-0000-0000-000000000001"}
+
+	assert.True(t, userInList(&types.GraphUser{UserPrincipalName: "Admin@contoso.com"}, identities), "Should match the user principal name")
+	assert.True(t, userInList(&types.GraphUser{ID: "00000000-0000-0000-0000-000000000001"}, identities), "Should match the object id")
+	assert.False(t, userInList(&types.GraphUser{Mail: "admin@contoso.com", UserPrincipalName: "other@contoso.com"}, identities), "Should not match the mail")
+	assert.False(t, userInList(nil, identities))
+}
@@ -0,0 +1,169 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/samkreter/go-core/log"
+
+	"github.com/samkreter/devopshelper/pkg/ics"
+	"github.com/samkreter/devopshelper/pkg/store"
+	"github.com/samkreter/devopshelper/pkg/types"
+)
+
+const (
+	// availabilitySourceICS marks the windows imported from a calendar so a new import replaces them
+	availabilitySourceICS = "ics"
+
+	maxCalendarSize = 1 << 20
+)
+
+// Availability is the part of a reviewer that they can manage themselves
+type Availability struct {
+	Paused         bool                       `json:"paused"`
+	Unavailable    []types.AvailabilityWindow `json:"unavailable"`
+	WeeklyCapacity int                        `json:"weeklyCapacity"`
+}
+
+// registerAvailabilityRoutes adds the routes for reviewers to manage their availability
+func (s *Server) registerAvailabilityRoutes(router *mux.Router) {
+	router.HandleFunc("/reviewers/{alias}/availability", s.handleGetAvailability).Methods("GET")
+	router.HandleFunc("/reviewers/{alias}/availability", s.handleUpdateAvailability).Methods("PUT")
+	router.HandleFunc("/reviewers/{alias}/availability/ics", s.handleImportAvailability).Methods("POST")
+}
+
+func (s *Server) handleGetAvailability(w http.ResponseWriter, req *http.Request) {
+	reviewer, ok := s.getReviewerFromRequest(w, req)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, getAvailability(reviewer))
+}
+
+func (s *Server) handleUpdateAvailability(w http.ResponseWriter, req *http.Request) {
+	reviewer, ok := s.getEditableReviewerFromRequest(w, req)
+	if !ok {
+		return
+	}
+
+	var availability Availability
+	if err := json.NewDecoder(req.Body).Decode(&availability); err != nil {
+		http.Error(w, fmt.Sprintf("invalid availability json: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	if err := validateAvailability(availability.Unavailable, availability.WeeklyCapacity); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	reviewer.Paused = availability.Paused
+	reviewer.Unavailable = availability.Unavailable
+	reviewer.WeeklyCapacity = availability.WeeklyCapacity
+
+	s.saveAvailability(w, req, reviewer)
+}
+
+func (s *Server) handleImportAvailability(w http.ResponseWriter, req *http.Request) {
+	reviewer, ok := s.getEditableReviewerFromRequest(w, req)
+	if !ok {
+		return
+	}
+
+	events, err := ics.Parse(io.LimitReader(req.Body, maxCalendarSize))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid calendar: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	// Replace the previously imported windows, keeping the ones that were added by hand
+	now := time.Now().UTC()
+	windows := make([]types.AvailabilityWindow, 0, len(reviewer.Unavailable)+len(events))
+	for _, window := range reviewer.Unavailable {
+		if window.Source != availabilitySourceICS {
+			windows = append(windows, window)
+		}
+	}
+
+	for _, event := range events {
+		if !event.OutOfOffice() || !event.End.After(event.Start) || !event.End.After(now) {
+			continue
+		}
+
+		windows = append(windows, types.AvailabilityWindow{
+			Start:  event.Start.UTC(),
+			End:    event.End.UTC(),
+			Reason: event.Summary,
+			Source: availabilitySourceICS,
+		})
+	}
+
+	reviewer.Unavailable = windows
+
+	s.saveAvailability(w, req, reviewer)
+}
+
+func (s *Server) saveAvailability(w http.ResponseWriter, req *http.Request, reviewer *types.Reviewer) {
+	ctx := req.Context()
+
+	// Only the availability is saved so reviews recorded since the reviewer was read aren't overwritten
+	err := s.ReviewerStore.UpdateReviewerFields(ctx, reviewer,
+		store.ReviewerFieldPaused, store.ReviewerFieldUnavailable, store.ReviewerFieldWeeklyCapacity)
+	if err != nil {
+		log.G(ctx).WithError(err).Errorf("failed to update availability for %q", reviewer.Alias)
+		http.Error(w, "failed to update availability", http.StatusInternalServerError)
+		return
+	}
+
+	log.G(ctx).Infof("Updated availability for reviewer: %s", reviewer.Alias)
+	writeJSON(w, http.StatusOK, getAvailability(reviewer))
+}
+
+// getEditableReviewerFromRequest looks up the reviewer and checks the user is the reviewer or an admin. On
+// failure the error response has already been written and false is returned.
+func (s *Server) getEditableReviewerFromRequest(w http.ResponseWriter, req *http.Request) (*types.Reviewer, bool) {
+	reviewer, ok := s.getReviewerFromRequest(w, req)
+	if !ok {
+		return nil, false
+	}
+
+	if !s.canEditReviewer(getUserFromContext(req.Context()), reviewer) {
+		http.Error(w, fmt.Sprintf("only %s or an admin can change this availability", reviewer.Alias), http.StatusForbidden)
+		return nil, false
+	}
+
+	return reviewer, true
+}
+
+func getAvailability(reviewer *types.Reviewer) *Availability {
+	availability := &Availability{
+		Paused:         reviewer.Paused,
+		Unavailable:    reviewer.Unavailable,
+		WeeklyCapacity: reviewer.WeeklyCapacity,
+	}
+	if availability.Unavailable == nil {
+		availability.Unavailable = []types.AvailabilityWindow{}
+	}
+
+	return availability
+}
+
+func validateAvailability(windows []types.AvailabilityWindow, weeklyCapacity int) error {
+	if weeklyCapacity < 0 {
+		return errors.New("weeklyCapacity must not be negative")
+	}
+
+	for _, window := range windows {
+		if !window.End.After(window.Start) {
+			return errors.Errorf("unavailable window starting %s must end after it starts", window.Start.Format(time.RFC3339))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,176 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/samkreter/devopshelper/pkg/types"
+)
+
+const (
+	testCalendar = "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nSUMMARY:Vacation\r\n" +
+		"DTSTART:20990106T090000Z\r\nDTEND:20990110T170000Z\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"
+)
+
+func TestAvailabilityHandlers(t *testing.T) {
+	tests := []struct {
+		Name           string
+		Method         string
+		Path           string
+		Token          string
+		Body           string
+		ExpectedStatus int
+	}{
+		{
+			Name:           "Get",
+			Method:         "GET",
+			Path:           "/api/reviewers/bob/availability",
+			Token:          userToken,
+			ExpectedStatus: http.StatusOK,
+		},
+		{
+			Name:           "Get Not Found",
+			Method:         "GET",
+			Path:           "/api/reviewers/missing/availability",
+			Token:          userToken,
+			ExpectedStatus: http.StatusNotFound,
+		},
+		{
+			Name:           "Update Own",
+			Method:         "PUT",
+			Path:           "/api/reviewers/alice/availability",
+			Token:          userToken,
+			Body:           `{"paused": true, "weeklyCapacity": 3}`,
+			ExpectedStatus: http.StatusOK,
+		},
+		{
+			Name:           "Update As Admin",
+			Method:         "PUT",
+			Path:           "/api/reviewers/bob/availability",
+			Token:          adminToken,
+			Body:           `{"paused": true}`,
+			ExpectedStatus: http.StatusOK,
+		},
+		{
+			Name:           "Update Other Reviewer",
+			Method:         "PUT",
+			Path:           "/api/reviewers/bob/availability",
+			Token:          userToken,
+			Body:           `{"paused": true}`,
+			ExpectedStatus: http.StatusForbidden,
+		},
+		{
+			Name:           "Update Invalid JSON",
+			Method:         "PUT",
+			Path:           "/api/reviewers/alice/availability",
+			Token:          userToken,
+			Body:           `{"paused": `,
+			ExpectedStatus: http.StatusBadRequest,
+		},
+		{
+			Name:           "Update Negative Weekly Capacity",
+			Method:         "PUT",
+			Path:           "/api/reviewers/alice/availability",
+			Token:          userToken,
+			Body:           `{"weeklyCapacity": -1}`,
+			ExpectedStatus: http.StatusBadRequest,
+		},
+		{
+			Name:           "Update Window Ends Before Start",
+			Method:         "PUT",
+			Path:           "/api/reviewers/alice/availability",
+			Token:          userToken,
+			Body:           `{"unavailable": [{"start": "2099-01-02T00:00:00Z", "end": "2099-01-01T00:00:00Z"}]}`,
+			ExpectedStatus: http.StatusBadRequest,
+		},
+		{
+			Name:           "Update Not Found",
+			Method:         "PUT",
+			Path:           "/api/reviewers/missing/availability",
+			Token:          adminToken,
+			Body:           `{"paused": true}`,
+			ExpectedStatus: http.StatusNotFound,
+		},
+		{
+			Name:           "Import",
+			Method:         "POST",
+			Path:           "/api/reviewers/alice/availability/ics",
+			Token:          userToken,
+			Body:           testCalendar,
+			ExpectedStatus: http.StatusOK,
+		},
+		{
+			Name:           "Import Invalid Calendar",
+			Method:         "POST",
+			Path:           "/api/reviewers/alice/availability/ics",
+			Token:          userToken,
+			Body:           "BEGIN:VEVENT\r\nSUMMARY:Vacation\r\n",
+			ExpectedStatus: http.StatusBadRequest,
+		},
+		{
+			Name:           "Import Other Reviewer",
+			Method:         "POST",
+			Path:           "/api/reviewers/bob/availability/ics",
+			Token:          userToken,
+			Body:           testCalendar,
+			ExpectedStatus: http.StatusForbidden,
+		},
+		{
+			Name:           "Import Not Found",
+			Method:         "POST",
+			Path:           "/api/reviewers/missing/availability/ics",
+			Token:          adminToken,
+			Body:           testCalendar,
+			ExpectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			server, s := newTestServer(t, nil)
+			for _, alias := range []string{"alice", "bob"} {
+				if err := s.AddReviewer(context.Background(), &types.Reviewer{Alias: alias, AdoID: alias + "-id"}); err != nil {
+					t.Fatalf("failed to add reviewer: %v", err)
+				}
+			}
+
+			rec := doRequest(server, tt.Method, tt.Path, tt.Token, tt.Body)
+			assert.Equal(t, tt.ExpectedStatus, rec.Code, strings.TrimSpace(rec.Body.String()))
+		})
+	}
+}
+
+func TestImportAvailabilityKeepsReviews(t *testing.T) {
+	ctx := context.Background()
+	lastReview := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	server, s := newTestServer(t, nil)
+	if err := s.AddReviewer(ctx, &types.Reviewer{
+		Alias:          "alice",
+		AdoID:          "alice-id",
+		WeeklyReviews:  2,
+		ReviewWeek:     types.ReviewWeekOf(lastReview),
+		LastReviewTime: lastReview,
+	}); err != nil {
+		t.Fatalf("failed to add reviewer: %v", err)
+	}
+
+	rec := doRequest(server, "POST", "/api/reviewers/alice/availability/ics", userToken, testCalendar)
+	if !assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String()) {
+		return
+	}
+
+	reviewer, err := s.GetReviewer(ctx, "alice")
+	assert.NoError(t, err)
+	if assert.Len(t, reviewer.Unavailable, 1, "Should import the out of office event") {
+		assert.Equal(t, "Vacation", reviewer.Unavailable[0].Reason)
+		assert.Equal(t, availabilitySourceICS, reviewer.Unavailable[0].Source)
+	}
+	assert.Equal(t, 2, reviewer.WeeklyReviews, "Should keep the weekly reviews")
+	assert.True(t, lastReview.Equal(reviewer.LastReviewTime), "Should keep the last review time")
+}
@@ -0,0 +1,321 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/samkreter/go-core/log"
+
+	"github.com/samkreter/devopshelper/pkg/autoreviewer"
+	"github.com/samkreter/devopshelper/pkg/store"
+	"github.com/samkreter/devopshelper/pkg/types"
+)
+
+const (
+	repositoryIDParam = "id"
+
+	// maxReviewersPerPullRequest caps the reviewers a repository can request for each pull request
+	maxReviewersPerPullRequest = 10
+)
+
+// registerRepositoryRoutes adds the repository management routes to the router
+func (s *Server) registerRepositoryRoutes(router *mux.Router) {
+	router.HandleFunc("/repositories", s.handleListRepositories).Methods("GET")
+	router.HandleFunc("/repositories", s.requireAdmin(s.handleCreateRepository)).Methods("POST")
+	router.HandleFunc("/repositories/{id}", s.handleGetRepository).Methods("GET")
+	router.HandleFunc("/repositories/{id}", s.handleUpdateRepository).Methods("PUT")
+	router.HandleFunc("/repositories/{id}", s.handleDeleteRepository).Methods("DELETE")
+	router.HandleFunc("/repositories/{id}/enable", s.handleSetRepositoryEnabled(true)).Methods("POST")
+	router.HandleFunc("/repositories/{id}/disable", s.handleSetRepositoryEnabled(false)).Methods("POST")
+}
+
+func (s *Server) handleListRepositories(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+
+	repos, err := s.RepoStore.GetAllRepositories(ctx)
+	if err != nil {
+		log.G(ctx).WithError(err).Error("failed to get repositories")
+		http.Error(w, "failed to get repositories", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, repos)
+}
+
+func (s *Server) handleGetRepository(w http.ResponseWriter, req *http.Request) {
+	repo, ok := s.getRepositoryFromRequest(w, req)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, repo)
+}
+
+func (s *Server) handleCreateRepository(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	logger := log.G(ctx)
+
+	var repo types.Repository
+	if err := json.NewDecoder(req.Body).Decode(&repo); err != nil {
+		http.Error(w, fmt.Sprintf("invalid repository json: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	if err := validateRepository(&repo); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	_, err := s.RepoStore.GetRepositoryByName(ctx, repo.Name, repo.ProjectName)
+	switch {
+	case err == nil:
+		http.Error(w, fmt.Sprintf("repository %s/%s already exists", repo.ProjectName, repo.Name), http.StatusConflict)
+		return
+	case !errors.Is(err, store.ErrNotFound):
+		logger.WithError(err).Errorf("failed to get repository %s/%s", repo.ProjectName, repo.Name)
+		http.Error(w, "failed to create repository", http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now().UTC()
+	repo.ID = bson.NewObjectId()
+	repo.Created = &now
+	repo.Updated = &now
+
+	if err := s.RepoStore.AddRepository(ctx, &repo); err != nil {
+		logger.WithError(err).Errorf("failed to add repository %s/%s", repo.ProjectName, repo.Name)
+		http.Error(w, "failed to create repository", http.StatusInternalServerError)
+		return
+	}
+
+	logger.Infof("Added repository: %s/%s", repo.ProjectName, repo.Name)
+	writeJSON(w, http.StatusCreated, &repo)
+}
+
+func (s *Server) handleUpdateRepository(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	logger := log.G(ctx)
+
+	existing, ok := s.getEditableRepositoryFromRequest(w, req)
+	if !ok {
+		return
+	}
+
+	var repo types.Repository
+	if err := json.NewDecoder(req.Body).Decode(&repo); err != nil {
+		http.Error(w, fmt.Sprintf("invalid repository json: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	if err := validateRepository(&repo); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Only admins can change who owns a repository
+	if !s.isAdmin(getUserFromContext(ctx)) && !equalIdentities(repo.Owners, existing.Owners) {
+		http.Error(w, "admin access is required to change repository owners", http.StatusForbidden)
+		return
+	}
+
+	renamed := repo.Name != existing.Name || repo.ProjectName != existing.ProjectName
+
+	// Ensure a rename doesn't collide with another repository
+	if renamed {
+		other, err := s.RepoStore.GetRepositoryByName(ctx, repo.Name, repo.ProjectName)
+		switch {
+		case err == nil && other.ID != existing.ID:
+			http.Error(w, fmt.Sprintf("repository %s/%s already exists", repo.ProjectName, repo.Name), http.StatusConflict)
+			return
+		case err != nil && !errors.Is(err, store.ErrNotFound):
+			logger.WithError(err).Errorf("failed to get repository %s/%s", repo.ProjectName, repo.Name)
+			http.Error(w, "failed to update repository", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	// Fields managed by the service are kept from the stored repository
+	now := time.Now().UTC()
+	repo.ID = existing.ID
+	repo.Created = existing.Created
+	repo.Updated = &now
+	repo.LastReconciled = existing.LastReconciled
+
+	switch {
+	// Force the reconciler to look up the renamed repository
+	case renamed:
+		repo.AdoRepoID = ""
+		repo.LastReconciled = time.Time{}
+	case repo.AdoRepoID == "":
+		repo.AdoRepoID = existing.AdoRepoID
+	}
+
+	if err := s.RepoStore.UpdateRepository(ctx, existing.ID.Hex(), &repo); err != nil {
+		logger.WithError(err).Errorf("failed to update repository %s", existing.ID.Hex())
+		http.Error(w, "failed to update repository", http.StatusInternalServerError)
+		return
+	}
+
+	logger.Infof("Updated repository: %s/%s", repo.ProjectName, repo.Name)
+	writeJSON(w, http.StatusOK, &repo)
+}
+
+func (s *Server) handleDeleteRepository(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	logger := log.G(ctx)
+
+	repo, ok := s.getEditableRepositoryFromRequest(w, req)
+	if !ok {
+		return
+	}
+
+	if err := s.RepoStore.DeleteRepository(ctx, repo.ID.Hex()); err != nil {
+		logger.WithError(err).Errorf("failed to delete repository %s", repo.ID.Hex())
+		http.Error(w, "failed to delete repository", http.StatusInternalServerError)
+		return
+	}
+
+	logger.Infof("Deleted repository: %s/%s", repo.ProjectName, repo.Name)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleSetRepositoryEnabled returns a handler that enables or disables a repository
+func (s *Server) handleSetRepositoryEnabled(enabled bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		logger := log.G(ctx)
+
+		repo, ok := s.getEditableRepositoryFromRequest(w, req)
+		if !ok {
+			return
+		}
+
+		now := time.Now().UTC()
+		repo.Enabled = enabled
+		repo.Updated = &now
+
+		if err := s.RepoStore.UpdateRepository(ctx, repo.ID.Hex(), repo); err != nil {
+			logger.WithError(err).Errorf("failed to update repository %s", repo.ID.Hex())
+			http.Error(w, "failed to update repository", http.StatusInternalServerError)
+			return
+		}
+
+		logger.Infof("Set repository %s/%s enabled: %t", repo.ProjectName, repo.Name, enabled)
+		writeJSON(w, http.StatusOK, repo)
+	}
+}
+
+// getRepositoryFromRequest looks up the repository from the request's id. On failure the error
+// response has already been written and false is returned.
+func (s *Server) getRepositoryFromRequest(w http.ResponseWriter, req *http.Request) (*types.Repository, bool) {
+	ctx := req.Context()
+
+	id := mux.Vars(req)[repositoryIDParam]
+	if !bson.IsObjectIdHex(id) {
+		http.Error(w, fmt.Sprintf("invalid repository id: %q", id), http.StatusBadRequest)
+		return nil, false
+	}
+
+	repo, err := s.RepoStore.GetRepositoryByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			http.Error(w, fmt.Sprintf("repository %q not found", id), http.StatusNotFound)
+			return nil, false
+		}
+
+		log.G(ctx).WithError(err).Errorf("failed to get repository %s", id)
+		http.Error(w, "failed to get repository", http.StatusInternalServerError)
+		return nil, false
+	}
+
+	return repo, true
+}
+
+// getEditableRepositoryFromRequest looks up the repository from the request's id and ensures the
+// current user is allowed to edit it. On failure the error response has already been written and false is returned.
+func (s *Server) getEditableRepositoryFromRequest(w http.ResponseWriter, req *http.Request) (*types.Repository, bool) {
+	ctx := req.Context()
+
+	repo, ok := s.getRepositoryFromRequest(w, req)
+	if !ok {
+		return nil, false
+	}
+
+	if !s.canEditRepository(getUserFromContext(ctx), repo) {
+		log.G(ctx).Warnf("denied edit access to repository %s/%s", repo.ProjectName, repo.Name)
+		http.Error(w, "repository owner or admin access is required", http.StatusForbidden)
+		return nil, false
+	}
+
+	return repo, true
+}
+
+// validateRepository ensures the required repository fields are set
+func validateRepository(repo *types.Repository) error {
+	if repo.Name == "" {
+		return errors.New("repository name is required")
+	}
+
+	if repo.ProjectName == "" {
+		return errors.New("repository projectName is required")
+	}
+
+	repo.Owners = normalizeIdentities(repo.Owners)
+
+	if counts := repo.ReviewerCounts; counts != nil {
+		if counts.RequiredOwners < 0 || counts.RequiredTeamMembers < 0 || counts.Optional < 0 {
+			return errors.New("repository reviewerCounts must not be negative")
+		}
+		if counts.RequiredOwners+counts.RequiredTeamMembers+counts.Optional > maxReviewersPerPullRequest {
+			return fmt.Errorf("repository reviewerCounts must not add more than %d reviewers", maxReviewersPerPullRequest)
+		}
+	}
+
+	if repo.SelectionStrategy != "" && !containsString(types.SelectionStrategies, repo.SelectionStrategy) {
+		return fmt.Errorf("repository selectionStrategy must be one of: %s", strings.Join(types.SelectionStrategies, ", "))
+	}
+
+	if repo.OwnersFormat != "" && !containsString(types.OwnersFormats, repo.OwnersFormat) {
+		return fmt.Errorf("repository ownersFormat must be one of: %s", strings.Join(types.OwnersFormats, ", "))
+	}
+
+	if repo.NoNotifyMode != "" && !containsString(types.NoNotifyModes, repo.NoNotifyMode) {
+		return fmt.Errorf("repository noNotifyMode must be one of: %s", strings.Join(types.NoNotifyModes, ", "))
+	}
+
+	if repo.ExpertiseWeight < 0 || repo.ExpertiseWeight > 1 {
+		return errors.New("repository expertiseWeight must be between 0 and 1")
+	}
+
+	// Expertise is blended with least recently used fairness
+	if repo.ExpertiseWeight > 0 && repo.SelectionStrategy != "" && repo.SelectionStrategy != types.SelectionStrategyLRU {
+		return errors.New("repository expertiseWeight can only be used with the lru selection strategy")
+	}
+
+	if repo.CommentTemplate != "" {
+		if err := autoreviewer.ValidateCommentTemplate(repo.CommentTemplate); err != nil {
+			return fmt.Errorf("repository commentTemplate is invalid: %v", err)
+		}
+	}
+
+	if err := autoreviewer.ValidateFilters(repo.Filters); err != nil {
+		return fmt.Errorf("repository filters are invalid: %v", err)
+	}
+
+	return nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,152 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/globalsign/mgo/bson"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/samkreter/devopshelper/pkg/types"
+)
+
+func TestRepositoryHandlers(t *testing.T) {
+	repoID := bson.NewObjectId().Hex()
+	otherID := bson.NewObjectId().Hex()
+	missingID := bson.NewObjectId().Hex()
+
+	tests := []struct {
+		Name           string
+		Method         string
+		Path           string
+		Body           string
+		ExpectedStatus int
+	}{
+		{
+			Name:           "Create",
+			Method:         "POST",
+			Path:           "/api/repositories",
+			Body:           `{"name": "new", "projectName": "project"}`,
+			ExpectedStatus: http.StatusCreated,
+		},
+		{
+			Name:           "Create Invalid JSON",
+			Method:         "POST",
+			Path:           "/api/repositories",
+			Body:           `{"name": `,
+			ExpectedStatus: http.StatusBadRequest,
+		},
+		{
+			Name:           "Create Missing Name",
+			Method:         "POST",
+			Path:           "/api/repositories",
+			Body:           `{"projectName": "project"}`,
+			ExpectedStatus: http.StatusBadRequest,
+		},
+		{
+			Name:           "Create Invalid Strategy",
+			Method:         "POST",
+			Path:           "/api/repositories",
+			Body:           `{"name": "new", "projectName": "project", "selectionStrategy": "unknown"}`,
+			ExpectedStatus: http.StatusBadRequest,
+		},
+		{
+			Name:           "Create Duplicate",
+			Method:         "POST",
+			Path:           "/api/repositories",
+			Body:           `{"name": "repo", "projectName": "project"}`,
+			ExpectedStatus: http.StatusConflict,
+		},
+		{
+			Name:           "Get",
+			Method:         "GET",
+			Path:           "/api/repositories/" + repoID,
+			ExpectedStatus: http.StatusOK,
+		},
+		{
+			Name:           "Get Malformed ID",
+			Method:         "GET",
+			Path:           "/api/repositories/not-an-id",
+			ExpectedStatus: http.StatusBadRequest,
+		},
+		{
+			Name:           "Get Not Found",
+			Method:         "GET",
+			Path:           "/api/repositories/" + missingID,
+			ExpectedStatus: http.StatusNotFound,
+		},
+		{
+			Name:           "Update",
+			Method:         "PUT",
+			Path:           "/api/repositories/" + repoID,
+			Body:           `{"name": "renamed", "projectName": "project", "owners": ["owner@contoso.com"]}`,
+			ExpectedStatus: http.StatusOK,
+		},
+		{
+			Name:           "Update Invalid JSON",
+			Method:         "PUT",
+			Path:           "/api/repositories/" + repoID,
+			Body:           `[]`,
+			ExpectedStatus: http.StatusBadRequest,
+		},
+		{
+			Name:           "Update Not Found",
+			Method:         "PUT",
+			Path:           "/api/repositories/" + missingID,
+			Body:           `{"name": "repo", "projectName": "project"}`,
+			ExpectedStatus: http.StatusNotFound,
+		},
+		{
+			Name:           "Update Rename Conflict",
+			Method:         "PUT",
+			Path:           "/api/repositories/" + repoID,
+			Body:           `{"name": "other", "projectName": "project", "owners": ["owner@contoso.com"]}`,
+			ExpectedStatus: http.StatusConflict,
+		},
+		{
+			Name:           "Delete",
+			Method:         "DELETE",
+			Path:           "/api/repositories/" + repoID,
+			ExpectedStatus: http.StatusNoContent,
+		},
+		{
+			Name:           "Delete Not Found",
+			Method:         "DELETE",
+			Path:           "/api/repositories/" + missingID,
+			ExpectedStatus: http.StatusNotFound,
+		},
+		{
+			Name:           "Enable Not Found",
+			Method:         "POST",
+			Path:           "/api/repositories/" + missingID + "/enable",
+			ExpectedStatus: http.StatusNotFound,
+		},
+		{
+			Name:           "Disable Malformed ID",
+			Method:         "POST",
+			Path:           "/api/repositories/not-an-id/disable",
+			ExpectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			ctx := context.Background()
+			server, s := newTestServer(t, nil)
+
+			for _, repo := range []*types.Repository{
+				{ID: bson.ObjectIdHex(repoID), Name: "repo", ProjectName: "project", Owners: []string{"owner@contoso.com"}},
+				{ID: bson.ObjectIdHex(otherID), Name: "other", ProjectName: "project"},
+			} {
+				if err := s.AddRepository(ctx, repo); err != nil {
+					t.Fatalf("failed to add repository: %v", err)
+				}
+			}
+
+			rec := doRequest(server, tt.Method, tt.Path, adminToken, tt.Body)
+			assert.Equal(t, tt.ExpectedStatus, rec.Code, strings.TrimSpace(rec.Body.String()))
+		})
+	}
+}
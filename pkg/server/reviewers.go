@@ -0,0 +1,216 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/globalsign/mgo/bson"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/samkreter/go-core/log"
+
+	"github.com/samkreter/devopshelper/pkg/store"
+	"github.com/samkreter/devopshelper/pkg/types"
+	"github.com/samkreter/devopshelper/pkg/utils"
+)
+
+const (
+	reviewerAliasParam = "alias"
+)
+
+// registerReviewerRoutes adds the reviewer management routes to the router
+func (s *Server) registerReviewerRoutes(router *mux.Router) {
+	router.HandleFunc("/reviewers", s.handleListReviewers).Methods("GET")
+	router.HandleFunc("/reviewers", s.requireAdmin(s.handleCreateReviewer)).Methods("POST")
+	router.HandleFunc("/reviewers/{alias}", s.handleGetReviewer).Methods("GET")
+	router.HandleFunc("/reviewers/{alias}", s.requireAdmin(s.handleUpdateReviewer)).Methods("PUT")
+	router.HandleFunc("/reviewers/{alias}", s.requireAdmin(s.handleDeleteReviewer)).Methods("DELETE")
+}
+
+func (s *Server) handleListReviewers(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+
+	reviewers, err := s.ReviewerStore.GetAllReviewers(ctx)
+	if err != nil {
+		log.G(ctx).WithError(err).Error("failed to get reviewers")
+		http.Error(w, "failed to get reviewers", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, reviewers)
+}
+
+func (s *Server) handleGetReviewer(w http.ResponseWriter, req *http.Request) {
+	reviewer, ok := s.getReviewerFromRequest(w, req)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, reviewer)
+}
+
+func (s *Server) handleCreateReviewer(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	logger := log.G(ctx)
+
+	var reviewer types.Reviewer
+	if err := json.NewDecoder(req.Body).Decode(&reviewer); err != nil {
+		http.Error(w, fmt.Sprintf("invalid reviewer json: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	if reviewer.Alias == "" {
+		http.Error(w, "reviewer alias is required", http.StatusBadRequest)
+		return
+	}
+
+	if reviewer.Weight < 0 || reviewer.MaxOpenReviews < 0 {
+		http.Error(w, "reviewer weight and maxOpenReviews must not be negative", http.StatusBadRequest)
+		return
+	}
+
+	if err := validateAvailability(reviewer.Unavailable, reviewer.WeeklyCapacity); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	_, err := s.ReviewerStore.GetReviewer(ctx, reviewer.Alias)
+	switch {
+	case err == nil:
+		http.Error(w, fmt.Sprintf("reviewer %q already exists", reviewer.Alias), http.StatusConflict)
+		return
+	case !errors.Is(err, store.ErrNotFound):
+		logger.WithError(err).Errorf("failed to get reviewer %q", reviewer.Alias)
+		http.Error(w, "failed to create reviewer", http.StatusInternalServerError)
+		return
+	}
+
+	// Look up the ADO identity when it isn't supplied
+	if reviewer.AdoID == "" {
+		if s.AdoIdentityClient == nil {
+			http.Error(w, "reviewer adoId is required", http.StatusBadRequest)
+			return
+		}
+
+		adoReviewer, err := utils.GetReviewerFromAlias(ctx, reviewer.Alias, s.AdoIdentityClient)
+		if err != nil {
+			logger.WithError(err).Errorf("failed to get ado identity for %q", reviewer.Alias)
+			http.Error(w, fmt.Sprintf("failed to find ado identity for alias %q", reviewer.Alias), http.StatusBadRequest)
+			return
+		}
+		reviewer.AdoID = adoReviewer.AdoID
+	}
+
+	reviewer.ID = bson.NewObjectId()
+
+	if err := s.ReviewerStore.AddReviewer(ctx, &reviewer); err != nil {
+		logger.WithError(err).Errorf("failed to add reviewer %q", reviewer.Alias)
+		http.Error(w, "failed to create reviewer", http.StatusInternalServerError)
+		return
+	}
+
+	logger.Infof("Added reviewer: %s", reviewer.Alias)
+	writeJSON(w, http.StatusCreated, &reviewer)
+}
+
+func (s *Server) handleUpdateReviewer(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	logger := log.G(ctx)
+
+	existing, ok := s.getReviewerFromRequest(w, req)
+	if !ok {
+		return
+	}
+
+	var reviewer types.Reviewer
+	if err := json.NewDecoder(req.Body).Decode(&reviewer); err != nil {
+		http.Error(w, fmt.Sprintf("invalid reviewer json: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	if reviewer.Alias != "" && reviewer.Alias != existing.Alias {
+		http.Error(w, "reviewer alias can not be changed", http.StatusBadRequest)
+		return
+	}
+
+	if reviewer.Weight < 0 || reviewer.MaxOpenReviews < 0 {
+		http.Error(w, "reviewer weight and maxOpenReviews must not be negative", http.StatusBadRequest)
+		return
+	}
+
+	if err := validateAvailability(reviewer.Unavailable, reviewer.WeeklyCapacity); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Only the settings are updated so a concurrent review assignment isn't overwritten
+	fields := []store.ReviewerField{
+		store.ReviewerFieldWeight,
+		store.ReviewerFieldMaxOpenReviews,
+		store.ReviewerFieldPaused,
+		store.ReviewerFieldUnavailable,
+		store.ReviewerFieldWeeklyCapacity,
+	}
+	if reviewer.AdoID != "" {
+		fields = append(fields, store.ReviewerFieldAdoID)
+	}
+
+	reviewer.ID = existing.ID
+	if err := s.ReviewerStore.UpdateReviewerFields(ctx, &reviewer, fields...); err != nil {
+		logger.WithError(err).Errorf("failed to update reviewer %q", existing.Alias)
+		http.Error(w, "failed to update reviewer", http.StatusInternalServerError)
+		return
+	}
+
+	updated, err := s.ReviewerStore.GetReviewer(ctx, existing.Alias)
+	if err != nil {
+		logger.WithError(err).Errorf("failed to get reviewer %q", existing.Alias)
+		http.Error(w, "failed to get reviewer", http.StatusInternalServerError)
+		return
+	}
+
+	logger.Infof("Updated reviewer: %s", updated.Alias)
+	writeJSON(w, http.StatusOK, updated)
+}
+
+func (s *Server) handleDeleteReviewer(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	logger := log.G(ctx)
+
+	reviewer, ok := s.getReviewerFromRequest(w, req)
+	if !ok {
+		return
+	}
+
+	if err := s.ReviewerStore.DeleteReviewer(ctx, reviewer.Alias); err != nil {
+		logger.WithError(err).Errorf("failed to delete reviewer %q", reviewer.Alias)
+		http.Error(w, "failed to delete reviewer", http.StatusInternalServerError)
+		return
+	}
+
+	logger.Infof("Deleted reviewer: %s", reviewer.Alias)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getReviewerFromRequest looks up the reviewer from the request's alias. On failure the error
+// response has already been written and false is returned.
+func (s *Server) getReviewerFromRequest(w http.ResponseWriter, req *http.Request) (*types.Reviewer, bool) {
+	ctx := req.Context()
+
+	alias := mux.Vars(req)[reviewerAliasParam]
+
+	reviewer, err := s.ReviewerStore.GetReviewer(ctx, alias)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			http.Error(w, fmt.Sprintf("reviewer %q not found", alias), http.StatusNotFound)
+			return nil, false
+		}
+
+		log.G(ctx).WithError(err).Errorf("failed to get reviewer %q", alias)
+		http.Error(w, "failed to get reviewer", http.StatusInternalServerError)
+		return nil, false
+	}
+
+	return reviewer, true
+}
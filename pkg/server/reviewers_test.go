@@ -0,0 +1,166 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/samkreter/devopshelper/pkg/types"
+)
+
+func TestReviewerHandlers(t *testing.T) {
+	tests := []struct {
+		Name           string
+		Method         string
+		Path           string
+		Body           string
+		ExpectedStatus int
+	}{
+		{
+			Name:           "Create",
+			Method:         "POST",
+			Path:           "/api/reviewers",
+			Body:           `{"alias": "bob", "adoId": "bob-id"}`,
+			ExpectedStatus: http.StatusCreated,
+		},
+		{
+			Name:           "Create Invalid JSON",
+			Method:         "POST",
+			Path:           "/api/reviewers",
+			Body:           `{"alias": `,
+			ExpectedStatus: http.StatusBadRequest,
+		},
+		{
+			Name:           "Create Missing Alias",
+			Method:         "POST",
+			Path:           "/api/reviewers",
+			Body:           `{"adoId": "bob-id"}`,
+			ExpectedStatus: http.StatusBadRequest,
+		},
+		{
+			Name:           "Create Negative Weight",
+			Method:         "POST",
+			Path:           "/api/reviewers",
+			Body:           `{"alias": "bob", "adoId": "bob-id", "weight": -1}`,
+			ExpectedStatus: http.StatusBadRequest,
+		},
+		{
+			Name:           "Create Missing ADO ID",
+			Method:         "POST",
+			Path:           "/api/reviewers",
+			Body:           `{"alias": "bob"}`,
+			ExpectedStatus: http.StatusBadRequest,
+		},
+		{
+			Name:           "Create Duplicate",
+			Method:         "POST",
+			Path:           "/api/reviewers",
+			Body:           `{"alias": "alice", "adoId": "alice-id"}`,
+			ExpectedStatus: http.StatusConflict,
+		},
+		{
+			Name:           "Get",
+			Method:         "GET",
+			Path:           "/api/reviewers/alice",
+			ExpectedStatus: http.StatusOK,
+		},
+		{
+			Name:           "Get Not Found",
+			Method:         "GET",
+			Path:           "/api/reviewers/missing",
+			ExpectedStatus: http.StatusNotFound,
+		},
+		{
+			Name:           "Update",
+			Method:         "PUT",
+			Path:           "/api/reviewers/alice",
+			Body:           `{"weight": 2}`,
+			ExpectedStatus: http.StatusOK,
+		},
+		{
+			Name:           "Update Invalid JSON",
+			Method:         "PUT",
+			Path:           "/api/reviewers/alice",
+			Body:           `[]`,
+			ExpectedStatus: http.StatusBadRequest,
+		},
+		{
+			Name:           "Update Alias",
+			Method:         "PUT",
+			Path:           "/api/reviewers/alice",
+			Body:           `{"alias": "bob"}`,
+			ExpectedStatus: http.StatusBadRequest,
+		},
+		{
+			Name:           "Update Negative Max Open Reviews",
+			Method:         "PUT",
+			Path:           "/api/reviewers/alice",
+			Body:           `{"maxOpenReviews": -1}`,
+			ExpectedStatus: http.StatusBadRequest,
+		},
+		{
+			Name:           "Update Not Found",
+			Method:         "PUT",
+			Path:           "/api/reviewers/missing",
+			Body:           `{"weight": 2}`,
+			ExpectedStatus: http.StatusNotFound,
+		},
+		{
+			Name:           "Delete",
+			Method:         "DELETE",
+			Path:           "/api/reviewers/alice",
+			ExpectedStatus: http.StatusNoContent,
+		},
+		{
+			Name:           "Delete Not Found",
+			Method:         "DELETE",
+			Path:           "/api/reviewers/missing",
+			ExpectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			server, s := newTestServer(t, nil)
+			if err := s.AddReviewer(context.Background(), &types.Reviewer{Alias: "alice", AdoID: "alice-id"}); err != nil {
+				t.Fatalf("failed to add reviewer: %v", err)
+			}
+
+			rec := doRequest(server, tt.Method, tt.Path, adminToken, tt.Body)
+			assert.Equal(t, tt.ExpectedStatus, rec.Code, strings.TrimSpace(rec.Body.String()))
+		})
+	}
+}
+
+func TestUpdateReviewerKeepsRotation(t *testing.T) {
+	ctx := context.Background()
+	lastReview := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	server, s := newTestServer(t, nil)
+	if err := s.AddReviewer(ctx, &types.Reviewer{
+		Alias:          "alice",
+		AdoID:          "alice-id",
+		WeeklyReviews:  2,
+		ReviewWeek:     types.ReviewWeekOf(lastReview),
+		LastReviewTime: lastReview,
+	}); err != nil {
+		t.Fatalf("failed to add reviewer: %v", err)
+	}
+
+	rec := doRequest(server, "PUT", "/api/reviewers/alice", adminToken, `{"weight": 3, "paused": true, "weeklyReviews": 0}`)
+	if !assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String()) {
+		return
+	}
+
+	reviewer, err := s.GetReviewer(ctx, "alice")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, reviewer.Weight, "Should update the weight")
+	assert.True(t, reviewer.Paused, "Should update paused")
+	assert.Equal(t, "alice-id", reviewer.AdoID, "Should keep the ado id")
+	assert.Equal(t, 2, reviewer.WeeklyReviews, "Should keep the weekly reviews")
+	assert.True(t, lastReview.Equal(reviewer.LastReviewTime), "Should keep the last review time")
+}
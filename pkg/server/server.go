@@ -14,8 +14,8 @@ import (
 	"github.com/samkreter/go-core/httputil"
 	"github.com/samkreter/go-core/log"
 
+	"github.com/samkreter/devopshelper/pkg/auth"
 	"github.com/samkreter/devopshelper/pkg/store"
-	"github.com/samkreter/devopshelper/pkg/types"
 )
 
 type contextKey string
@@ -25,8 +25,6 @@ const (
 
 	defaultAddr         = "localhost:8080"
 	currentUserLogField = "currentUser"
-	// GraphURI uri to grab the currently logged in users identity
-	GraphURI = "https://graph.microsoft.com/v1.0/me"
 )
 
 // Options to for starter the apiserver
@@ -34,6 +32,12 @@ type Options struct {
 	AllowCORS bool
 	Addr      string
 	Admins    []string
+	// Authenticator validates incoming requests, defaults to a cached graph lookup
+	Authenticator auth.Authenticator
+	// WebhookSecret enables the azure devops service hook endpoint
+	WebhookSecret string
+	// PullRequestBalancer balances pull requests from service hooks
+	PullRequestBalancer PullRequestBalancer
 }
 
 // Server holds configuration for the server
@@ -41,35 +45,42 @@ type Server struct {
 	AdoGitClient adogit.Client
 	AdoIdentityClient adoidentity.Client
 	RepoStore  store.RepositoryStore
+	ReviewerStore store.ReviewerStore
+	TeamStore store.TeamStore
 	Options    *Options
 }
 
 // NewServer creates a new server
-func NewServer(adoGitClient adogit.Client, adoIdentityClient adoidentity.Client, repoStore store.RepositoryStore, o *Options) (*Server, error) {
+func NewServer(adoGitClient adogit.Client, adoIdentityClient adoidentity.Client,
+	repoStore store.RepositoryStore, reviewerStore store.ReviewerStore, teamStore store.TeamStore, o *Options) (*Server, error) {
 	if o.Addr == "" {
 		o.Addr = defaultAddr
 	}
 
+	if o.WebhookSecret != "" && o.PullRequestBalancer == nil {
+		return nil, fmt.Errorf("a pull request balancer is required when the webhook secret is set")
+	}
+
+	if o.Authenticator == nil {
+		o.Authenticator = auth.NewCachingAuthenticator(auth.NewGraphAuthenticator(), auth.DefaultCacheTTL)
+	}
+
+	o.Admins = normalizeIdentities(o.Admins)
 	log.G(context.TODO()).Infof("Adding admins: '%s'", strings.Join(o.Admins, ", "))
 
 	return &Server{
 		AdoGitClient: adoGitClient,
 		AdoIdentityClient: adoIdentityClient,
 		RepoStore:  repoStore,
+		ReviewerStore: reviewerStore,
+		TeamStore: teamStore,
 		Options:    o,
 	}, nil
 }
 
 // Run start the frontend server
 func (s *Server) Run() {
-	router := mux.NewRouter()
-
-	router.Handle("/", http.FileServer(http.Dir("static")))
-
-	router.PathPrefix("/").HandlerFunc(s.catchAllHandler)
-
-	// Add authentication handler
-	handler := AuthMiddleware(router)
+	handler := s.Handler()
 
 	handler = httputil.SetUpHandler(handler, &httputil.HandlerConfig{
 		CorrelationEnabled: true,
@@ -88,12 +99,49 @@ func (s *Server) Run() {
 	log.G(context.TODO()).Fatal(http.ListenAndServe(s.Options.Addr, handler))
 }
 
+// Handler returns the authenticated api routes and the service hook endpoint
+func (s *Server) Handler() http.Handler {
+	router := mux.NewRouter()
+
+	router.Handle("/", http.FileServer(http.Dir("static")))
+
+	apiRouter := router.PathPrefix("/api").Subrouter()
+	s.registerRepositoryRoutes(apiRouter)
+	s.registerReviewerRoutes(apiRouter)
+	s.registerAvailabilityRoutes(apiRouter)
+	s.registerTeamRoutes(apiRouter)
+
+	router.PathPrefix("/").HandlerFunc(s.catchAllHandler)
+
+	// Service hooks authenticate with the shared secret instead of a user token
+	rootRouter := mux.NewRouter()
+	if s.Options.WebhookSecret != "" {
+		log.G(context.TODO()).Info("Enabling azure devops service hooks")
+		rootRouter.HandleFunc(WebhookPath, s.handleADOWebhook).Methods("POST")
+	}
+
+	// Add authentication handler
+	rootRouter.PathPrefix("/").Handler(AuthMiddleware(s.Options.Authenticator, router))
+
+	return rootRouter
+}
+
 func (s *Server) catchAllHandler(w http.ResponseWriter, req *http.Request) {
 	fmt.Fprintf(w, "API route not found.")
 }
 
+// writeJSON writes the value as a json response with the given status code
+func writeJSON(w http.ResponseWriter, statusCode int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.G(context.TODO()).WithError(err).Error("failed to write json response")
+	}
+}
+
 // AuthMiddleware only allows users in the security group and adds the user into the request context
-func AuthMiddleware(next http.Handler) http.Handler {
+func AuthMiddleware(authenticator auth.Authenticator, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
 		authHeader := req.Header.Get("Authorization")
 		if authHeader == "" {
@@ -104,7 +152,7 @@ func AuthMiddleware(next http.Handler) http.Handler {
 		ctx := req.Context()
 		logger := log.G(ctx)
 
-		user, err := getAuthenticatedUser(authHeader)
+		user, err := authenticator.Authenticate(ctx, authHeader)
 		if err != nil {
 			logger.Errorf("failed to auth user with err: '%v'", err)
 			http.Error(w, "authentication was invalid", http.StatusUnauthorized)
@@ -112,40 +160,12 @@ func AuthMiddleware(next http.Handler) http.Handler {
 		}
 
 		// Add the current user to the log fields
-		ctx = log.WithLogger(ctx, logger.WithField(currentUserLogField, user.Mail))
+		ctx = log.WithLogger(ctx, logger.WithField(currentUserLogField, user.UserPrincipalName))
 
 		ctx = context.WithValue(ctx, userInfoContextKey, user)
 
-		logger.Infof("using logged in user: '%s'", user.Mail)
+		logger.Infof("using logged in user: '%s'", user.UserPrincipalName)
 
 		next.ServeHTTP(w, req.WithContext(ctx))
 	})
 }
-
-func getAuthenticatedUser(authToken string) (*types.GraphUser, error) {
-	req, err := http.NewRequest("GET", GraphURI, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	req.Header.Add("Authorization", authToken)
-
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("graph returned non 200 status code: '%d'", resp.StatusCode)
-	}
-
-	defer resp.Body.Close()
-
-	var user types.GraphUser
-	err = json.NewDecoder(resp.Body).Decode(&user)
-	if err != nil {
-		return nil, err
-	}
-
-	return &user, nil
-}
@@ -0,0 +1,67 @@
+package server
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+
+	"github.com/samkreter/devopshelper/pkg/store/memory"
+	"github.com/samkreter/devopshelper/pkg/types"
+)
+
+const (
+	adminToken = "Bearer admin"
+	ownerToken = "Bearer owner"
+	userToken  = "Bearer user"
+)
+
+// testAuthenticator authenticates the fixed test tokens
+type testAuthenticator map[string]*types.GraphUser
+
+func (a testAuthenticator) Authenticate(ctx context.Context, authHeader string) (*types.GraphUser, error) {
+	user, ok := a[authHeader]
+	if !ok {
+		return nil, errors.New("invalid token")
+	}
+	return user, nil
+}
+
+func newTestUser(upn string) *types.GraphUser {
+	return &types.GraphUser{ID: upn + "-oid", Mail: upn, UserPrincipalName: upn}
+}
+
+// newTestServer creates a server backed by a memory store, admin@contoso.com is the only admin
+func newTestServer(t *testing.T, o *Options) (*Server, *memory.Store) {
+	if o == nil {
+		o = &Options{}
+	}
+	o.Admins = []string{"admin@contoso.com"}
+	o.Authenticator = testAuthenticator{
+		adminToken: newTestUser("admin@contoso.com"),
+		ownerToken: newTestUser("owner@contoso.com"),
+		userToken:  newTestUser("alice@contoso.com"),
+	}
+
+	s := memory.NewStore()
+	server, err := NewServer(nil, nil, s, s, s, o)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	return server, s
+}
+
+// doRequest sends the request through the server's handler, an empty token sends no Authorization header
+func doRequest(server *Server, method, path, token, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", token)
+	}
+
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, req)
+	return rec
+}
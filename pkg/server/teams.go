@@ -0,0 +1,168 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/globalsign/mgo/bson"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/samkreter/go-core/log"
+
+	"github.com/samkreter/devopshelper/pkg/store"
+	"github.com/samkreter/devopshelper/pkg/types"
+)
+
+const (
+	teamNameParam = "name"
+)
+
+// registerTeamRoutes adds the team management routes to the router
+func (s *Server) registerTeamRoutes(router *mux.Router) {
+	router.HandleFunc("/teams", s.handleListTeams).Methods("GET")
+	router.HandleFunc("/teams", s.requireAdmin(s.handleCreateTeam)).Methods("POST")
+	router.HandleFunc("/teams/{name}", s.handleGetTeam).Methods("GET")
+	router.HandleFunc("/teams/{name}", s.requireAdmin(s.handleUpdateTeam)).Methods("PUT")
+	router.HandleFunc("/teams/{name}", s.requireAdmin(s.handleDeleteTeam)).Methods("DELETE")
+}
+
+func (s *Server) handleListTeams(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+
+	teams, err := s.TeamStore.GetAllTeams(ctx)
+	if err != nil {
+		log.G(ctx).WithError(err).Error("failed to get teams")
+		http.Error(w, "failed to get teams", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, teams)
+}
+
+func (s *Server) handleGetTeam(w http.ResponseWriter, req *http.Request) {
+	team, ok := s.getTeamFromRequest(w, req)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, team)
+}
+
+func (s *Server) handleCreateTeam(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	logger := log.G(ctx)
+
+	var team types.Team
+	if err := json.NewDecoder(req.Body).Decode(&team); err != nil {
+		http.Error(w, fmt.Sprintf("invalid team json: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	if team.Name == "" {
+		http.Error(w, "team name is required", http.StatusBadRequest)
+		return
+	}
+
+	_, err := s.TeamStore.GetTeam(ctx, team.Name)
+	switch {
+	case err == nil:
+		http.Error(w, fmt.Sprintf("team %q already exists", team.Name), http.StatusConflict)
+		return
+	case !errors.Is(err, store.ErrNotFound):
+		logger.WithError(err).Errorf("failed to get team %q", team.Name)
+		http.Error(w, "failed to create team", http.StatusInternalServerError)
+		return
+	}
+
+	team.ID = bson.NewObjectId()
+	if team.Members == nil {
+		team.Members = []string{}
+	}
+
+	if err := s.TeamStore.AddTeam(ctx, &team); err != nil {
+		logger.WithError(err).Errorf("failed to add team %q", team.Name)
+		http.Error(w, "failed to create team", http.StatusInternalServerError)
+		return
+	}
+
+	logger.Infof("Added team: %s", team.Name)
+	writeJSON(w, http.StatusCreated, &team)
+}
+
+func (s *Server) handleUpdateTeam(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	logger := log.G(ctx)
+
+	existing, ok := s.getTeamFromRequest(w, req)
+	if !ok {
+		return
+	}
+
+	var team types.Team
+	if err := json.NewDecoder(req.Body).Decode(&team); err != nil {
+		http.Error(w, fmt.Sprintf("invalid team json: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	if team.Name != "" && team.Name != existing.Name {
+		http.Error(w, "team name can not be changed", http.StatusBadRequest)
+		return
+	}
+
+	team.ID = existing.ID
+	team.Name = existing.Name
+	if team.Members == nil {
+		team.Members = []string{}
+	}
+
+	if err := s.TeamStore.UpdateTeam(ctx, &team); err != nil {
+		logger.WithError(err).Errorf("failed to update team %q", existing.Name)
+		http.Error(w, "failed to update team", http.StatusInternalServerError)
+		return
+	}
+
+	logger.Infof("Updated team: %s", team.Name)
+	writeJSON(w, http.StatusOK, &team)
+}
+
+func (s *Server) handleDeleteTeam(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	logger := log.G(ctx)
+
+	team, ok := s.getTeamFromRequest(w, req)
+	if !ok {
+		return
+	}
+
+	if err := s.TeamStore.DeleteTeam(ctx, team.Name); err != nil {
+		logger.WithError(err).Errorf("failed to delete team %q", team.Name)
+		http.Error(w, "failed to delete team", http.StatusInternalServerError)
+		return
+	}
+
+	logger.Infof("Deleted team: %s", team.Name)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getTeamFromRequest looks up the team from the request's name. On failure the error
+// response has already been written and false is returned.
+func (s *Server) getTeamFromRequest(w http.ResponseWriter, req *http.Request) (*types.Team, bool) {
+	ctx := req.Context()
+
+	name := mux.Vars(req)[teamNameParam]
+
+	team, err := s.TeamStore.GetTeam(ctx, name)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			http.Error(w, fmt.Sprintf("team %q not found", name), http.StatusNotFound)
+			return nil, false
+		}
+
+		log.G(ctx).WithError(err).Errorf("failed to get team %q", name)
+		http.Error(w, "failed to get team", http.StatusInternalServerError)
+		return nil, false
+	}
+
+	return team, true
+}
@@ -0,0 +1,176 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/samkreter/devopshelper/pkg/types"
+)
+
+func TestTeamHandlers(t *testing.T) {
+	tests := []struct {
+		Name           string
+		Method         string
+		Path           string
+		Token          string
+		Body           string
+		ExpectedStatus int
+	}{
+		{
+			Name:           "List",
+			Method:         "GET",
+			Path:           "/api/teams",
+			Token:          userToken,
+			ExpectedStatus: http.StatusOK,
+		},
+		{
+			Name:           "Create",
+			Method:         "POST",
+			Path:           "/api/teams",
+			Token:          adminToken,
+			Body:           `{"name": "Compute", "members": ["bob"]}`,
+			ExpectedStatus: http.StatusCreated,
+		},
+		{
+			Name:           "Create Invalid JSON",
+			Method:         "POST",
+			Path:           "/api/teams",
+			Token:          adminToken,
+			Body:           `{"name": `,
+			ExpectedStatus: http.StatusBadRequest,
+		},
+		{
+			Name:           "Create Missing Name",
+			Method:         "POST",
+			Path:           "/api/teams",
+			Token:          adminToken,
+			Body:           `{"members": ["bob"]}`,
+			ExpectedStatus: http.StatusBadRequest,
+		},
+		{
+			Name:           "Create Duplicate",
+			Method:         "POST",
+			Path:           "/api/teams",
+			Token:          adminToken,
+			Body:           `{"name": "Platform"}`,
+			ExpectedStatus: http.StatusConflict,
+		},
+		{
+			Name:           "User Can't Create",
+			Method:         "POST",
+			Path:           "/api/teams",
+			Token:          userToken,
+			Body:           `{"name": "Compute"}`,
+			ExpectedStatus: http.StatusForbidden,
+		},
+		{
+			Name:           "Get",
+			Method:         "GET",
+			Path:           "/api/teams/Platform",
+			Token:          userToken,
+			ExpectedStatus: http.StatusOK,
+		},
+		{
+			Name:           "Get Not Found",
+			Method:         "GET",
+			Path:           "/api/teams/missing",
+			Token:          userToken,
+			ExpectedStatus: http.StatusNotFound,
+		},
+		{
+			Name:           "Update",
+			Method:         "PUT",
+			Path:           "/api/teams/Platform",
+			Token:          adminToken,
+			Body:           `{"members": ["alice", "bob"]}`,
+			ExpectedStatus: http.StatusOK,
+		},
+		{
+			Name:           "Update Invalid JSON",
+			Method:         "PUT",
+			Path:           "/api/teams/Platform",
+			Token:          adminToken,
+			Body:           `[]`,
+			ExpectedStatus: http.StatusBadRequest,
+		},
+		{
+			Name:           "Update Name",
+			Method:         "PUT",
+			Path:           "/api/teams/Platform",
+			Token:          adminToken,
+			Body:           `{"name": "Compute"}`,
+			ExpectedStatus: http.StatusBadRequest,
+		},
+		{
+			Name:           "Update Not Found",
+			Method:         "PUT",
+			Path:           "/api/teams/missing",
+			Token:          adminToken,
+			Body:           `{"members": ["bob"]}`,
+			ExpectedStatus: http.StatusNotFound,
+		},
+		{
+			Name:           "User Can't Update",
+			Method:         "PUT",
+			Path:           "/api/teams/Platform",
+			Token:          userToken,
+			Body:           `{"members": ["alice", "bob"]}`,
+			ExpectedStatus: http.StatusForbidden,
+		},
+		{
+			Name:           "Delete",
+			Method:         "DELETE",
+			Path:           "/api/teams/Platform",
+			Token:          adminToken,
+			ExpectedStatus: http.StatusNoContent,
+		},
+		{
+			Name:           "Delete Not Found",
+			Method:         "DELETE",
+			Path:           "/api/teams/missing",
+			Token:          adminToken,
+			ExpectedStatus: http.StatusNotFound,
+		},
+		{
+			Name:           "User Can't Delete",
+			Method:         "DELETE",
+			Path:           "/api/teams/Platform",
+			Token:          userToken,
+			ExpectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			server, s := newTestServer(t, nil)
+			if err := s.AddTeam(context.Background(), &types.Team{Name: "Platform", Members: []string{"alice"}}); err != nil {
+				t.Fatalf("failed to add team: %v", err)
+			}
+
+			rec := doRequest(server, tt.Method, tt.Path, tt.Token, tt.Body)
+			assert.Equal(t, tt.ExpectedStatus, rec.Code, strings.TrimSpace(rec.Body.String()))
+		})
+	}
+}
+
+func TestUpdateTeamMembers(t *testing.T) {
+	ctx := context.Background()
+
+	server, s := newTestServer(t, nil)
+	if err := s.AddTeam(ctx, &types.Team{Name: "Platform", Members: []string{"alice"}}); err != nil {
+		t.Fatalf("failed to add team: %v", err)
+	}
+
+	rec := doRequest(server, "PUT", "/api/teams/Platform", adminToken, `{"members": ["alice", "bob"]}`)
+	if !assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String()) {
+		return
+	}
+
+	team, err := s.GetTeam(ctx, "Platform")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"alice", "bob"}, team.Members, "Should update the members")
+}
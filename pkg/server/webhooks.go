@@ -0,0 +1,127 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/samkreter/go-core/log"
+
+	"github.com/samkreter/devopshelper/pkg/store"
+	"github.com/samkreter/devopshelper/pkg/types"
+)
+
+const (
+	// WebhookPath is the path azure devops service hooks are sent to
+	WebhookPath = "/api/webhooks/ado"
+
+	// WebhookSecretHeader can hold the shared secret when basic auth isn't used
+	WebhookSecretHeader = "X-Webhook-Secret"
+
+	eventPullRequestCreated = "git.pullrequest.created"
+	eventPullRequestUpdated = "git.pullrequest.updated"
+
+	webhookBalanceTimeout = time.Minute * 5
+)
+
+// PullRequestBalancer balances the reviewers for a single pull request
+type PullRequestBalancer interface {
+	BalancePullRequest(ctx context.Context, repo *types.Repository, pullRequestID int) error
+}
+
+// serviceHookEvent holds the fields used from an azure devops pull request service hook
+type serviceHookEvent struct {
+	EventType string `json:"eventType"`
+	Resource  struct {
+		PullRequestID int `json:"pullRequestId"`
+		Repository    struct {
+			Name    string `json:"name"`
+			Project struct {
+				Name string `json:"name"`
+			} `json:"project"`
+		} `json:"repository"`
+	} `json:"resource"`
+}
+
+// handleADOWebhook balances the reviewers for pull request created and updated events
+func (s *Server) handleADOWebhook(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	logger := log.G(ctx)
+
+	if !s.validWebhookSecret(req) {
+		http.Error(w, "invalid webhook credentials", http.StatusUnauthorized)
+		return
+	}
+
+	var event serviceHookEvent
+	if err := json.NewDecoder(req.Body).Decode(&event); err != nil {
+		http.Error(w, fmt.Sprintf("invalid service hook json: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	if event.EventType != eventPullRequestCreated && event.EventType != eventPullRequestUpdated {
+		logger.Infof("Ignoring service hook event: %q", event.EventType)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	repoName := event.Resource.Repository.Name
+	projectName := event.Resource.Repository.Project.Name
+	if repoName == "" || projectName == "" || event.Resource.PullRequestID == 0 {
+		http.Error(w, "service hook is missing the pull request repository", http.StatusBadRequest)
+		return
+	}
+
+	repo, err := s.RepoStore.GetRepositoryByName(ctx, repoName, projectName)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			logger.Infof("Ignoring service hook for unknown repository %s/%s", projectName, repoName)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		logger.WithError(err).Errorf("failed to get repository %s/%s", projectName, repoName)
+		http.Error(w, "failed to get repository", http.StatusInternalServerError)
+		return
+	}
+
+	if !repo.Enabled {
+		logger.Infof("Ignoring service hook for disabled repository %s/%s", projectName, repoName)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	// Service hooks time out quickly, balance in the background
+	prID := event.Resource.PullRequestID
+	go func() {
+		bgCtx, cancel := context.WithTimeout(log.WithLogger(context.Background(), logger), webhookBalanceTimeout)
+		defer cancel()
+
+		if err := s.Options.PullRequestBalancer.BalancePullRequest(bgCtx, repo, prID); err != nil {
+			logger.WithError(err).Errorf("failed to balance pull request %d for %s/%s", prID, projectName, repoName)
+			return
+		}
+		logger.Infof("Balanced pull request %d for %s/%s from %s", prID, projectName, repoName, event.EventType)
+	}()
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// validWebhookSecret checks for the shared secret as the basic auth password or in the secret header
+func (s *Server) validWebhookSecret(req *http.Request) bool {
+	secret := []byte(s.Options.WebhookSecret)
+
+	if _, password, ok := req.BasicAuth(); ok {
+		return subtle.ConstantTimeCompare([]byte(password), secret) == 1
+	}
+
+	if header := req.Header.Get(WebhookSecretHeader); header != "" {
+		return subtle.ConstantTimeCompare([]byte(header), secret) == 1
+	}
+
+	return false
+}
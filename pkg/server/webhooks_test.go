@@ -0,0 +1,286 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/microsoft/azure-devops-go-api/azuredevops"
+	adogit "github.com/microsoft/azure-devops-go-api/azuredevops/git"
+	"github.com/microsoft/azure-devops-go-api/azuredevops/webapi"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/samkreter/devopshelper/pkg/autoreviewer"
+	"github.com/samkreter/devopshelper/pkg/types"
+)
+
+const (
+	testWebhookSecret = "secret"
+)
+
+// waitBalancer calls the balancer and marks each call done so tests can wait for the background balancing
+type waitBalancer struct {
+	balancer PullRequestBalancer
+	wg       sync.WaitGroup
+
+	mu    sync.Mutex
+	calls int
+}
+
+func (b *waitBalancer) BalancePullRequest(ctx context.Context, repo *types.Repository, pullRequestID int) error {
+	defer b.wg.Done()
+
+	b.mu.Lock()
+	b.calls++
+	b.mu.Unlock()
+
+	if b.balancer == nil {
+		return nil
+	}
+	return b.balancer.BalancePullRequest(ctx, repo, pullRequestID)
+}
+
+// webhookGitClient fakes the ADO calls for balancing an active pull request that changes /src/main.go
+type webhookGitClient struct {
+	adogit.Client
+	repoID uuid.UUID
+
+	mu        sync.Mutex
+	reviewers []string
+	threads   int
+}
+
+func (c *webhookGitClient) GetPullRequestById(ctx context.Context, args adogit.GetPullRequestByIdArgs) (*adogit.GitPullRequest, error) {
+	title := "Fix"
+	creatorID := "creator-id"
+	targetRef := "refs/heads/master"
+	url := "https://dev.azure.com/pr"
+	return &adogit.GitPullRequest{
+		Repository:    &adogit.GitRepository{Id: &c.repoID},
+		PullRequestId: args.PullRequestId,
+		Status:        &adogit.PullRequestStatusValues.Active,
+		Title:         &title,
+		CreatedBy:     &webapi.IdentityRef{Id: &creatorID},
+		TargetRefName: &targetRef,
+		Url:           &url,
+	}, nil
+}
+
+func (c *webhookGitClient) GetPullRequests(ctx context.Context, args adogit.GetPullRequestsArgs) (*[]adogit.GitPullRequest, error) {
+	return &[]adogit.GitPullRequest{}, nil
+}
+
+func (c *webhookGitClient) GetThreads(ctx context.Context, args adogit.GetThreadsArgs) (*[]adogit.GitPullRequestCommentThread, error) {
+	return &[]adogit.GitPullRequestCommentThread{}, nil
+}
+
+func (c *webhookGitClient) GetPullRequestIterations(ctx context.Context, args adogit.GetPullRequestIterationsArgs) (*[]adogit.GitPullRequestIteration, error) {
+	return &[]adogit.GitPullRequestIteration{{}}, nil
+}
+
+func (c *webhookGitClient) GetPullRequestIterationChanges(ctx context.Context, args adogit.GetPullRequestIterationChangesArgs) (*adogit.GitPullRequestIterationChanges, error) {
+	changes := []adogit.GitPullRequestChange{{Item: map[string]interface{}{"path": "/src/main.go"}}}
+	return &adogit.GitPullRequestIterationChanges{ChangeEntries: &changes}, nil
+}
+
+func (c *webhookGitClient) GetBranch(ctx context.Context, args adogit.GetBranchArgs) (*adogit.GitBranchStats, error) {
+	head := c.repoID.String() + "-head"
+	return &adogit.GitBranchStats{Name: args.Name, Commit: &adogit.GitCommitRef{CommitId: &head}}, nil
+}
+
+func (c *webhookGitClient) GetItem(ctx context.Context, args adogit.GetItemArgs) (*adogit.GitItem, error) {
+	if *args.Path != "/owners.txt" {
+		statusCode := http.StatusNotFound
+		return nil, azuredevops.WrappedError{StatusCode: &statusCode}
+	}
+	owners := "alice\n"
+	return &adogit.GitItem{Path: args.Path, Content: &owners}, nil
+}
+
+func (c *webhookGitClient) CreatePullRequestReviewer(ctx context.Context, args adogit.CreatePullRequestReviewerArgs) (*adogit.IdentityRefWithVote, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.reviewers = append(c.reviewers, *args.ReviewerId)
+	return args.Reviewer, nil
+}
+
+func (c *webhookGitClient) CreateThread(ctx context.Context, args adogit.CreateThreadArgs) (*adogit.GitPullRequestCommentThread, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.threads++
+	return args.CommentThread, nil
+}
+
+func newServiceHookEvent(eventType, repo, project string, pullRequestID int) string {
+	return fmt.Sprintf(`{"eventType": %q, "resource": {"pullRequestId": %d, "repository": {"name": %q, "project": {"name": %q}}}}`,
+		eventType, pullRequestID, repo, project)
+}
+
+func doWebhookRequest(server *Server, body string, setAuth func(req *http.Request)) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("POST", WebhookPath, strings.NewReader(body))
+	if setAuth != nil {
+		setAuth(req)
+	}
+
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, req)
+	return rec
+}
+
+func TestADOWebhook(t *testing.T) {
+	secretHeader := func(secret string) func(req *http.Request) {
+		return func(req *http.Request) {
+			req.Header.Set(WebhookSecretHeader, secret)
+		}
+	}
+	basicAuth := func(password string) func(req *http.Request) {
+		return func(req *http.Request) {
+			req.SetBasicAuth("ado", password)
+		}
+	}
+
+	tests := []struct {
+		Name           string
+		Body           string
+		SetAuth        func(req *http.Request)
+		ExpectedStatus int
+	}{
+		{
+			Name:           "Secret Header",
+			Body:           newServiceHookEvent(eventPullRequestCreated, "repo", "project", 1),
+			SetAuth:        secretHeader(testWebhookSecret),
+			ExpectedStatus: http.StatusAccepted,
+		},
+		{
+			Name:           "Basic Auth",
+			Body:           newServiceHookEvent(eventPullRequestUpdated, "repo", "project", 1),
+			SetAuth:        basicAuth(testWebhookSecret),
+			ExpectedStatus: http.StatusAccepted,
+		},
+		{
+			Name:           "Missing Secret",
+			Body:           newServiceHookEvent(eventPullRequestCreated, "repo", "project", 1),
+			ExpectedStatus: http.StatusUnauthorized,
+		},
+		{
+			Name:           "Invalid Secret Header",
+			Body:           newServiceHookEvent(eventPullRequestCreated, "repo", "project", 1),
+			SetAuth:        secretHeader("wrong"),
+			ExpectedStatus: http.StatusUnauthorized,
+		},
+		{
+			Name:           "Invalid Basic Auth",
+			Body:           newServiceHookEvent(eventPullRequestCreated, "repo", "project", 1),
+			SetAuth:        basicAuth("wrong"),
+			ExpectedStatus: http.StatusUnauthorized,
+		},
+		{
+			Name:           "Invalid JSON",
+			Body:           `{"eventType": `,
+			SetAuth:        secretHeader(testWebhookSecret),
+			ExpectedStatus: http.StatusBadRequest,
+		},
+		{
+			Name:           "Missing Repository",
+			Body:           newServiceHookEvent(eventPullRequestCreated, "", "project", 1),
+			SetAuth:        secretHeader(testWebhookSecret),
+			ExpectedStatus: http.StatusBadRequest,
+		},
+		{
+			Name:           "Missing Pull Request",
+			Body:           newServiceHookEvent(eventPullRequestCreated, "repo", "project", 0),
+			SetAuth:        secretHeader(testWebhookSecret),
+			ExpectedStatus: http.StatusBadRequest,
+		},
+		{
+			Name:           "Ignored Event",
+			Body:           newServiceHookEvent("git.push", "repo", "project", 1),
+			SetAuth:        secretHeader(testWebhookSecret),
+			ExpectedStatus: http.StatusNoContent,
+		},
+		{
+			Name:           "Unknown Repository",
+			Body:           newServiceHookEvent(eventPullRequestCreated, "unknown", "project", 1),
+			SetAuth:        secretHeader(testWebhookSecret),
+			ExpectedStatus: http.StatusNoContent,
+		},
+		{
+			Name:           "Disabled Repository",
+			Body:           newServiceHookEvent(eventPullRequestCreated, "disabled", "project", 1),
+			SetAuth:        secretHeader(testWebhookSecret),
+			ExpectedStatus: http.StatusNoContent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			ctx := context.Background()
+			balancer := &waitBalancer{}
+			server, s := newTestServer(t, &Options{WebhookSecret: testWebhookSecret, PullRequestBalancer: balancer})
+
+			for _, repo := range []*types.Repository{
+				{Name: "repo", ProjectName: "project", Enabled: true},
+				{Name: "disabled", ProjectName: "project"},
+			} {
+				if err := s.AddRepository(ctx, repo); err != nil {
+					t.Fatalf("failed to add repository: %v", err)
+				}
+			}
+
+			expectedCalls := 0
+			if tt.ExpectedStatus == http.StatusAccepted {
+				expectedCalls = 1
+			}
+			balancer.wg.Add(expectedCalls)
+
+			rec := doWebhookRequest(server, tt.Body, tt.SetAuth)
+			assert.Equal(t, tt.ExpectedStatus, rec.Code, strings.TrimSpace(rec.Body.String()))
+
+			balancer.wg.Wait()
+			assert.Equal(t, expectedCalls, balancer.calls, "Should only balance accepted events")
+		})
+	}
+}
+
+func TestADOWebhookDuplicateDelivery(t *testing.T) {
+	ctx := context.Background()
+	client := &webhookGitClient{repoID: uuid.New()}
+
+	balancer := &waitBalancer{}
+	server, s := newTestServer(t, &Options{WebhookSecret: testWebhookSecret, PullRequestBalancer: balancer})
+	balancer.balancer = autoreviewer.NewBalancer(s, s, s, s, client, nil, nil, autoreviewer.Options{})
+
+	if err := s.AddRepository(ctx, &types.Repository{Name: "repo", ProjectName: "project", Enabled: true}); err != nil {
+		t.Fatalf("failed to add repository: %v", err)
+	}
+	if err := s.AddReviewer(ctx, &types.Reviewer{Alias: "alice", AdoID: "alice-id"}); err != nil {
+		t.Fatalf("failed to add reviewer: %v", err)
+	}
+
+	// Service hooks are retried, the created and updated events for the same pull request can also arrive together
+	const deliveries = 3
+	balancer.wg.Add(deliveries)
+	for i := 0; i < deliveries; i++ {
+		rec := doWebhookRequest(server, newServiceHookEvent(eventPullRequestCreated, "repo", "project", 1), func(req *http.Request) {
+			req.Header.Set(WebhookSecretHeader, testWebhookSecret)
+		})
+		assert.Equal(t, http.StatusAccepted, rec.Code, rec.Body.String())
+	}
+	balancer.wg.Wait()
+
+	assert.Equal(t, deliveries, balancer.calls, "Should balance each delivery")
+	assert.Equal(t, []string{"alice-id"}, client.reviewers, "Should only add the reviewers once")
+	assert.Equal(t, 1, client.threads, "Should only comment once")
+
+	assignment, err := s.GetAssignment(ctx, client.repoID.String(), 1)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{"alice"}, assignment.RequiredReviewers, "Should record the assignment")
+	}
+}
@@ -0,0 +1,697 @@
+package bolt
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+	"github.com/pkg/errors"
+	"github.com/samkreter/go-core/log"
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/samkreter/devopshelper/pkg/store"
+	"github.com/samkreter/devopshelper/pkg/types"
+)
+
+const (
+	// SchemaVersion is the current version of the file layout
+	SchemaVersion = 2
+
+	defaultOpenTimeout = time.Second * 5
+)
+
+var (
+	metaBucket       = []byte("meta")
+	repositoryBucket = []byte("repositories")
+	reviewerBucket   = []byte("reviewers")
+	teamBucket       = []byte("teams")
+	assignmentBucket = []byte("assignments")
+
+	schemaVersionKey = []byte("schemaVersion")
+
+	// migrations upgrade the file from the previous schema version, migrations[i] upgrades version i to i+1
+	migrations = []func(tx *bolt.Tx) error{
+		// Version 0 is a new file, create the buckets
+		func(tx *bolt.Tx) error {
+			for _, bucket := range [][]byte{repositoryBucket, reviewerBucket, teamBucket} {
+				if _, err := tx.CreateBucketIfNotExists(bucket); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		// Version 2 adds the pull request assignment ledger
+		func(tx *bolt.Tx) error {
+			_, err := tx.CreateBucketIfNotExists(assignmentBucket)
+			return err
+		},
+	}
+)
+
+// Validate the interface implementation
+var _ store.RepositoryStore = &Store{}
+var _ store.ReviewerStore = &Store{}
+var _ store.TeamStore = &Store{}
+var _ store.AssignmentStore = &Store{}
+
+// Store is a single file store backed by bbolt
+type Store struct {
+	db  *bolt.DB
+	now func() time.Time
+}
+
+// NewStore opens or creates the store file at the path and upgrades it to the current schema
+func NewStore(path string) (*Store, error) {
+	if path == "" {
+		return nil, errors.New("missing bolt store path")
+	}
+
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: defaultOpenTimeout})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open bolt store %q", path)
+	}
+
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	log.G(context.TODO()).Infof("BoltStore: Using file: '%s' with schema version: %d", path, SchemaVersion)
+
+	return &Store{
+		db:  db,
+		now: time.Now,
+	}, nil
+}
+
+// Close closes the store file
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// migrate runs each migration newer than the file's schema version
+func migrate(db *bolt.DB) error {
+	return db.Update(func(tx *bolt.Tx) error {
+		meta, err := tx.CreateBucketIfNotExists(metaBucket)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		version := 0
+		if raw := meta.Get(schemaVersionKey); raw != nil {
+			version, err = strconv.Atoi(string(raw))
+			if err != nil {
+				return errors.Wrapf(err, "invalid schema version %q", raw)
+			}
+		}
+
+		if version > SchemaVersion {
+			return errors.Errorf("store schema version %d is newer than supported version %d", version, SchemaVersion)
+		}
+
+		for ; version < SchemaVersion; version++ {
+			if err := migrations[version](tx); err != nil {
+				return errors.Wrapf(err, "failed to migrate schema version %d", version)
+			}
+		}
+
+		return meta.Put(schemaVersionKey, []byte(strconv.Itoa(SchemaVersion)))
+	})
+}
+
+// PopLRUReviewer gets the least recently used reviewers and marks them as just reviewed in a single transaction
+func (s *Store) PopLRUReviewer(ctx context.Context, aliases []string, count int) ([]*types.Reviewer, error) {
+	var reviewers []*types.Reviewer
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		remaining := aliases
+		now := s.now().UTC()
+		for len(reviewers) < count {
+			lru, err := lruReviewer(tx, remaining, now)
+			if errors.Is(err, store.ErrNotFound) && len(reviewers) > 0 {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+
+			lru.RecordReview(now)
+			if err := put(tx.Bucket(reviewerBucket), lru.ID, lru); err != nil {
+				return err
+			}
+
+			reviewers = append(reviewers, lru)
+			remaining = removeAlias(remaining, lru.Alias)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return reviewers, nil
+}
+
+// GetLRUReviewer gets the least recently used reviewer from the aliases
+func (s *Store) GetLRUReviewer(ctx context.Context, aliases []string) (*types.Reviewer, error) {
+	var reviewer *types.Reviewer
+	err := s.db.View(func(tx *bolt.Tx) error {
+		lru, err := lruReviewer(tx, aliases, s.now().UTC())
+		reviewer = lru
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return reviewer, nil
+}
+
+// lruReviewer returns the least recently used available reviewer, ties are broken by alias
+func lruReviewer(tx *bolt.Tx, aliases []string, now time.Time) (*types.Reviewer, error) {
+	wanted := make(map[string]bool, len(aliases))
+	for _, alias := range aliases {
+		wanted[alias] = true
+	}
+
+	var lru *types.Reviewer
+	err := forEachReviewer(tx, func(reviewer *types.Reviewer) bool {
+		if !wanted[reviewer.Alias] || !reviewer.Available(now) {
+			return false
+		}
+
+		if lru == nil || reviewer.LastReviewTime.Before(lru.LastReviewTime) ||
+			(reviewer.LastReviewTime.Equal(lru.LastReviewTime) && reviewer.Alias < lru.Alias) {
+			lru = reviewer
+		}
+		return false
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if lru == nil {
+		return nil, errors.WithStack(store.ErrNotFound)
+	}
+
+	return lru, nil
+}
+
+// removeAlias returns a copy of the aliases without alias
+func removeAlias(aliases []string, alias string) []string {
+	remaining := make([]string, 0, len(aliases))
+	for _, a := range aliases {
+		if a != alias {
+			remaining = append(remaining, a)
+		}
+	}
+	return remaining
+}
+
+// AddReviewer adds a new reviewer
+func (s *Store) AddReviewer(ctx context.Context, reviewer *types.Reviewer) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		existing, err := findReviewer(tx, func(r *types.Reviewer) bool { return r.Alias == reviewer.Alias })
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			return errors.Wrapf(store.ErrAlreadyExists, "reviewer %q", reviewer.Alias)
+		}
+
+		if reviewer.ID == "" {
+			reviewer.ID = bson.NewObjectId()
+		}
+
+		return put(tx.Bucket(reviewerBucket), reviewer.ID, reviewer)
+	})
+}
+
+// GetReviewer gets a reviewer by alias
+func (s *Store) GetReviewer(ctx context.Context, alias string) (*types.Reviewer, error) {
+	return s.getReviewer(func(r *types.Reviewer) bool { return r.Alias == alias })
+}
+
+// GetReviewerByADOID gets a reviewer by their azure devops id
+func (s *Store) GetReviewerByADOID(ctx context.Context, adoID string) (*types.Reviewer, error) {
+	return s.getReviewer(func(r *types.Reviewer) bool { return r.AdoID == adoID })
+}
+
+func (s *Store) getReviewer(match func(*types.Reviewer) bool) (*types.Reviewer, error) {
+	var reviewer *types.Reviewer
+	err := s.db.View(func(tx *bolt.Tx) error {
+		var err error
+		reviewer, err = findReviewer(tx, match)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if reviewer == nil {
+		return nil, errors.WithStack(store.ErrNotFound)
+	}
+
+	return reviewer, nil
+}
+
+// UpdateReviewer replaces the reviewer with the matching id
+func (s *Store) UpdateReviewer(ctx context.Context, reviewer *types.Reviewer) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(reviewerBucket)
+		if reviewer.ID == "" || bucket.Get([]byte(reviewer.ID.Hex())) == nil {
+			return errors.WithStack(store.ErrNotFound)
+		}
+
+		conflict, err := findReviewer(tx, func(r *types.Reviewer) bool { return r.Alias == reviewer.Alias && r.ID != reviewer.ID })
+		if err != nil {
+			return err
+		}
+		if conflict != nil {
+			return errors.Wrapf(store.ErrAlreadyExists, "reviewer %q", reviewer.Alias)
+		}
+
+		return put(bucket, reviewer.ID, reviewer)
+	})
+}
+
+// UpdateReviewerFields only updates the given fields of the reviewer with the matching id
+func (s *Store) UpdateReviewerFields(ctx context.Context, reviewer *types.Reviewer, fields ...store.ReviewerField) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(reviewerBucket)
+		if reviewer.ID == "" {
+			return errors.WithStack(store.ErrNotFound)
+		}
+
+		data := bucket.Get([]byte(reviewer.ID.Hex()))
+		if data == nil {
+			return errors.WithStack(store.ErrNotFound)
+		}
+
+		var existing types.Reviewer
+		if err := json.Unmarshal(data, &existing); err != nil {
+			return errors.Wrapf(err, "failed to decode reviewer %s", reviewer.ID.Hex())
+		}
+
+		if err := store.CopyReviewerFields(&existing, reviewer, fields); err != nil {
+			return err
+		}
+
+		return put(bucket, existing.ID, &existing)
+	})
+}
+
+// RecordReview marks the reviewer as just reviewed and counts the review against their weekly capacity in a
+// single transaction
+func (s *Store) RecordReview(ctx context.Context, alias string, now time.Time) (*types.Reviewer, error) {
+	var reviewer *types.Reviewer
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		var err error
+		reviewer, err = findReviewer(tx, func(r *types.Reviewer) bool { return r.Alias == alias })
+		if err != nil {
+			return err
+		}
+		if reviewer == nil {
+			return errors.WithStack(store.ErrNotFound)
+		}
+
+		reviewer.RecordReview(now.UTC())
+		return put(tx.Bucket(reviewerBucket), reviewer.ID, reviewer)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return reviewer, nil
+}
+
+// DeleteReviewer removes a reviewer by alias
+func (s *Store) DeleteReviewer(ctx context.Context, alias string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		reviewer, err := findReviewer(tx, func(r *types.Reviewer) bool { return r.Alias == alias })
+		if err != nil {
+			return err
+		}
+		if reviewer == nil {
+			return errors.WithStack(store.ErrNotFound)
+		}
+
+		return tx.Bucket(reviewerBucket).Delete([]byte(reviewer.ID.Hex()))
+	})
+}
+
+// GetAllReviewers retrieves all reviewers sorted by alias
+func (s *Store) GetAllReviewers(ctx context.Context) ([]*types.Reviewer, error) {
+	reviewers := []*types.Reviewer{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return forEachReviewer(tx, func(reviewer *types.Reviewer) bool {
+			reviewers = append(reviewers, reviewer)
+			return false
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(reviewers, func(i, j int) bool { return reviewers[i].Alias < reviewers[j].Alias })
+	return reviewers, nil
+}
+
+// AddTeam adds a new team
+func (s *Store) AddTeam(ctx context.Context, team *types.Team) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		existing, err := findTeam(tx, team.Name)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			return errors.Wrapf(store.ErrAlreadyExists, "team %q", team.Name)
+		}
+
+		if team.ID == "" {
+			team.ID = bson.NewObjectId()
+		}
+
+		return put(tx.Bucket(teamBucket), team.ID, team)
+	})
+}
+
+// GetTeam gets a team by name
+func (s *Store) GetTeam(ctx context.Context, name string) (*types.Team, error) {
+	var team *types.Team
+	err := s.db.View(func(tx *bolt.Tx) error {
+		var err error
+		team, err = findTeam(tx, name)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if team == nil {
+		return nil, errors.WithStack(store.ErrNotFound)
+	}
+
+	return team, nil
+}
+
+// UpdateTeam replaces the team with the matching id
+func (s *Store) UpdateTeam(ctx context.Context, team *types.Team) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(teamBucket)
+		if team.ID == "" || bucket.Get([]byte(team.ID.Hex())) == nil {
+			return errors.WithStack(store.ErrNotFound)
+		}
+
+		conflict, err := findTeam(tx, team.Name)
+		if err != nil {
+			return err
+		}
+		if conflict != nil && conflict.ID != team.ID {
+			return errors.Wrapf(store.ErrAlreadyExists, "team %q", team.Name)
+		}
+
+		return put(bucket, team.ID, team)
+	})
+}
+
+// DeleteTeam removes a team by name
+func (s *Store) DeleteTeam(ctx context.Context, name string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		team, err := findTeam(tx, name)
+		if err != nil {
+			return err
+		}
+		if team == nil {
+			return errors.WithStack(store.ErrNotFound)
+		}
+
+		return tx.Bucket(teamBucket).Delete([]byte(team.ID.Hex()))
+	})
+}
+
+// GetAllTeams retrieves all teams sorted by name
+func (s *Store) GetAllTeams(ctx context.Context) ([]*types.Team, error) {
+	teams := []*types.Team{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(teamBucket).ForEach(func(k, v []byte) error {
+			var team types.Team
+			if err := json.Unmarshal(v, &team); err != nil {
+				return errors.WithStack(err)
+			}
+			teams = append(teams, &team)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(teams, func(i, j int) bool { return teams[i].Name < teams[j].Name })
+	return teams, nil
+}
+
+// AddRepository adds a new repository
+func (s *Store) AddRepository(ctx context.Context, repo *types.Repository) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		existing, err := findRepository(tx, repo.Name, repo.ProjectName)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			return errors.Wrapf(store.ErrAlreadyExists, "repository %s/%s", repo.ProjectName, repo.Name)
+		}
+
+		if repo.ID == "" {
+			repo.ID = bson.NewObjectId()
+		}
+
+		return put(tx.Bucket(repositoryBucket), repo.ID, repo)
+	})
+}
+
+// UpdateRepository replaces the repository with the id
+func (s *Store) UpdateRepository(ctx context.Context, id string, repo *types.Repository) error {
+	if !bson.IsObjectIdHex(id) {
+		return errors.Wrapf(store.ErrNotFound, "malformed id %q", id)
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(repositoryBucket)
+		bsonID := bson.ObjectIdHex(id)
+		if bucket.Get([]byte(bsonID.Hex())) == nil {
+			return errors.WithStack(store.ErrNotFound)
+		}
+
+		conflict, err := findRepository(tx, repo.Name, repo.ProjectName)
+		if err != nil {
+			return err
+		}
+		if conflict != nil && conflict.ID != bsonID {
+			return errors.Wrapf(store.ErrAlreadyExists, "repository %s/%s", repo.ProjectName, repo.Name)
+		}
+
+		updated := *repo
+		updated.ID = bsonID
+		return put(bucket, bsonID, &updated)
+	})
+}
+
+// DeleteRepository deletes the repository with the id
+func (s *Store) DeleteRepository(ctx context.Context, id string) error {
+	if !bson.IsObjectIdHex(id) {
+		return errors.Wrapf(store.ErrNotFound, "malformed id %q", id)
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(repositoryBucket)
+		key := []byte(bson.ObjectIdHex(id).Hex())
+		if bucket.Get(key) == nil {
+			return errors.WithStack(store.ErrNotFound)
+		}
+
+		return bucket.Delete(key)
+	})
+}
+
+// GetRepositoryByID retrieves a repository by id
+func (s *Store) GetRepositoryByID(ctx context.Context, id string) (*types.Repository, error) {
+	if !bson.IsObjectIdHex(id) {
+		return nil, errors.Wrapf(store.ErrNotFound, "malformed id %q", id)
+	}
+
+	var repo *types.Repository
+	err := s.db.View(func(tx *bolt.Tx) error {
+		raw := tx.Bucket(repositoryBucket).Get([]byte(bson.ObjectIdHex(id).Hex()))
+		if raw == nil {
+			return errors.WithStack(store.ErrNotFound)
+		}
+
+		repo = &types.Repository{}
+		return errors.WithStack(json.Unmarshal(raw, repo))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return repo, nil
+}
+
+// GetAllRepositories retrieves all repositories sorted by project and name
+func (s *Store) GetAllRepositories(ctx context.Context) ([]*types.Repository, error) {
+	repos := []*types.Repository{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return forEachRepository(tx, func(repo *types.Repository) bool {
+			repos = append(repos, repo)
+			return false
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(repos, func(i, j int) bool {
+		if repos[i].ProjectName != repos[j].ProjectName {
+			return repos[i].ProjectName < repos[j].ProjectName
+		}
+		return repos[i].Name < repos[j].Name
+	})
+	return repos, nil
+}
+
+// GetRepositoryByName gets a repository by name and project
+func (s *Store) GetRepositoryByName(ctx context.Context, name, project string) (*types.Repository, error) {
+	var repo *types.Repository
+	err := s.db.View(func(tx *bolt.Tx) error {
+		var err error
+		repo, err = findRepository(tx, name, project)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if repo == nil {
+		return nil, errors.WithStack(store.ErrNotFound)
+	}
+
+	return repo, nil
+}
+
+// AddAssignment records the reviewers assigned to a pull request
+func (s *Store) AddAssignment(ctx context.Context, assignment *types.Assignment) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(assignmentBucket)
+		key := assignmentKey(assignment.RepositoryID, assignment.PullRequestID)
+		if bucket.Get(key) != nil {
+			return errors.Wrapf(store.ErrAlreadyExists, "assignment %s", key)
+		}
+
+		if assignment.ID == "" {
+			assignment.ID = bson.NewObjectId()
+		}
+
+		data, err := json.Marshal(assignment)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		return errors.WithStack(bucket.Put(key, data))
+	})
+}
+
+// ClaimAssignment adds the assignment unless the pull request already has one that isn't an abandoned claim
+func (s *Store) ClaimAssignment(ctx context.Context, assignment *types.Assignment, staleBefore time.Time) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(assignmentBucket)
+		key := assignmentKey(assignment.RepositoryID, assignment.PullRequestID)
+		if raw := bucket.Get(key); raw != nil {
+			var existing types.Assignment
+			if err := json.Unmarshal(raw, &existing); err != nil {
+				return errors.WithStack(err)
+			}
+
+			if !existing.Pending || !existing.Created.Before(staleBefore) {
+				return errors.Wrapf(store.ErrAlreadyExists, "assignment %s", key)
+			}
+			assignment.ID = existing.ID
+		}
+
+		if assignment.ID == "" {
+			assignment.ID = bson.NewObjectId()
+		}
+
+		data, err := json.Marshal(assignment)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		return errors.WithStack(bucket.Put(key, data))
+	})
+}
+
+// UpdateAssignment replaces the assignment with the matching id
+func (s *Store) UpdateAssignment(ctx context.Context, assignment *types.Assignment) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(assignmentBucket)
+		key := assignmentKey(assignment.RepositoryID, assignment.PullRequestID)
+
+		raw := bucket.Get(key)
+		if raw == nil {
+			return errors.WithStack(store.ErrNotFound)
+		}
+
+		var existing types.Assignment
+		if err := json.Unmarshal(raw, &existing); err != nil {
+			return errors.WithStack(err)
+		}
+		if existing.ID != assignment.ID {
+			return errors.WithStack(store.ErrNotFound)
+		}
+
+		data, err := json.Marshal(assignment)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		return errors.WithStack(bucket.Put(key, data))
+	})
+}
+
+// GetAssignment gets the assignment for a pull request
+func (s *Store) GetAssignment(ctx context.Context, repositoryID string, pullRequestID int) (*types.Assignment, error) {
+	var assignment *types.Assignment
+	err := s.db.View(func(tx *bolt.Tx) error {
+		raw := tx.Bucket(assignmentBucket).Get(assignmentKey(repositoryID, pullRequestID))
+		if raw == nil {
+			return errors.WithStack(store.ErrNotFound)
+		}
+
+		assignment = &types.Assignment{}
+		return errors.WithStack(json.Unmarshal(raw, assignment))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return assignment, nil
+}
+
+// DeleteAssignment removes the assignment for a pull request
+func (s *Store) DeleteAssignment(ctx context.Context, repositoryID string, pullRequestID int) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(assignmentBucket)
+		key := assignmentKey(repositoryID, pullRequestID)
+		if bucket.Get(key) == nil {
+			return errors.WithStack(store.ErrNotFound)
+		}
+
+		return bucket.Delete(key)
+	})
+}
+
+func assignmentKey(repositoryID string, pullRequestID int) []byte {
+	return []byte(repositoryID + "/" + strconv.Itoa(pullRequestID))
+}
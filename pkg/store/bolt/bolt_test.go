@@ -0,0 +1,76 @@
+package bolt
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/globalsign/mgo/bson"
+	"github.com/stretchr/testify/assert"
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/samkreter/devopshelper/pkg/store"
+	"github.com/samkreter/devopshelper/pkg/store/storetest"
+)
+
+func TestStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "boltstore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var stores []*Store
+	defer func() {
+		for _, s := range stores {
+			s.Close()
+		}
+	}()
+
+	newStore := func(t *testing.T) *Store {
+		s, err := NewStore(filepath.Join(dir, bson.NewObjectId().Hex()+".db"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		stores = append(stores, s)
+		return s
+	}
+
+	storetest.TestRepositoryStore(t, func(t *testing.T) store.RepositoryStore { return newStore(t) })
+	storetest.TestReviewerStore(t, func(t *testing.T) store.ReviewerStore { return newStore(t) })
+	storetest.TestTeamStore(t, func(t *testing.T) store.TeamStore { return newStore(t) })
+	storetest.TestAssignmentStore(t, func(t *testing.T) store.AssignmentStore { return newStore(t) })
+}
+
+func TestSchemaVersion(t *testing.T) {
+	dir, err := ioutil.TempDir("", "boltstore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "store.db")
+
+	s, err := NewStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NoError(t, s.Close(), "Should close store")
+
+	// Reopening an up to date store should succeed
+	s, err = NewStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(metaBucket).Put(schemaVersionKey, []byte("99"))
+	})
+	assert.NoError(t, err, "Should set schema version")
+	assert.NoError(t, s.Close(), "Should close store")
+
+	_, err = NewStore(path)
+	assert.Error(t, err, "Should refuse to open a newer schema version")
+}
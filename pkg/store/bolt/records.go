@@ -0,0 +1,111 @@
+package bolt
+
+import (
+	"encoding/json"
+
+	"github.com/globalsign/mgo/bson"
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/samkreter/devopshelper/pkg/types"
+)
+
+// errStopIteration stops a ForEach once a match is found
+var errStopIteration = errors.New("stop iteration")
+
+// put stores the record as json keyed by its hex id
+func put(bucket *bolt.Bucket, id bson.ObjectId, record interface{}) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return errors.WithStack(bucket.Put([]byte(id.Hex()), data))
+}
+
+// forEachReviewer calls fn for each reviewer until it returns true
+func forEachReviewer(tx *bolt.Tx, fn func(*types.Reviewer) bool) error {
+	err := tx.Bucket(reviewerBucket).ForEach(func(k, v []byte) error {
+		var reviewer types.Reviewer
+		if err := json.Unmarshal(v, &reviewer); err != nil {
+			return errors.Wrapf(err, "failed to decode reviewer %q", k)
+		}
+
+		if fn(&reviewer) {
+			return errStopIteration
+		}
+		return nil
+	})
+	if err == errStopIteration {
+		return nil
+	}
+
+	return err
+}
+
+func findReviewer(tx *bolt.Tx, match func(*types.Reviewer) bool) (*types.Reviewer, error) {
+	var found *types.Reviewer
+	err := forEachReviewer(tx, func(reviewer *types.Reviewer) bool {
+		if match(reviewer) {
+			found = reviewer
+			return true
+		}
+		return false
+	})
+
+	return found, err
+}
+
+func findTeam(tx *bolt.Tx, name string) (*types.Team, error) {
+	var found *types.Team
+	err := tx.Bucket(teamBucket).ForEach(func(k, v []byte) error {
+		var team types.Team
+		if err := json.Unmarshal(v, &team); err != nil {
+			return errors.Wrapf(err, "failed to decode team %q", k)
+		}
+
+		if team.Name == name {
+			found = &team
+			return errStopIteration
+		}
+		return nil
+	})
+	if err == errStopIteration {
+		return found, nil
+	}
+
+	return found, err
+}
+
+// forEachRepository calls fn for each repository until it returns true
+func forEachRepository(tx *bolt.Tx, fn func(*types.Repository) bool) error {
+	err := tx.Bucket(repositoryBucket).ForEach(func(k, v []byte) error {
+		var repo types.Repository
+		if err := json.Unmarshal(v, &repo); err != nil {
+			return errors.Wrapf(err, "failed to decode repository %q", k)
+		}
+
+		if fn(&repo) {
+			return errStopIteration
+		}
+		return nil
+	})
+	if err == errStopIteration {
+		return nil
+	}
+
+	return err
+}
+
+func findRepository(tx *bolt.Tx, name, project string) (*types.Repository, error) {
+	var found *types.Repository
+	err := forEachRepository(tx, func(repo *types.Repository) bool {
+		if repo.Name == name && repo.ProjectName == project {
+			found = repo
+			return true
+		}
+		return false
+	})
+
+	return found, err
+}
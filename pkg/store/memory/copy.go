@@ -0,0 +1,76 @@
+package memory
+
+import (
+	"github.com/samkreter/devopshelper/pkg/types"
+)
+
+// The store hands out copies so callers can't modify stored records without an update
+
+func copyReviewer(reviewer *types.Reviewer) *types.Reviewer {
+	c := *reviewer
+	if reviewer.Unavailable != nil {
+		c.Unavailable = append([]types.AvailabilityWindow{}, reviewer.Unavailable...)
+	}
+	return &c
+}
+
+func copyTeam(team *types.Team) *types.Team {
+	c := *team
+	if team.Members != nil {
+		c.Members = append([]string{}, team.Members...)
+	}
+	return &c
+}
+
+func copyRepository(repo *types.Repository) *types.Repository {
+	c := *repo
+	if repo.Created != nil {
+		created := *repo.Created
+		c.Created = &created
+	}
+	if repo.Updated != nil {
+		updated := *repo.Updated
+		c.Updated = &updated
+	}
+	if repo.Owners != nil {
+		c.Owners = append([]string{}, repo.Owners...)
+	}
+	if repo.ReviewerCounts != nil {
+		counts := *repo.ReviewerCounts
+		c.ReviewerCounts = &counts
+	}
+	if repo.Filters != nil {
+		c.Filters = copyFilters(repo.Filters)
+	}
+	return &c
+}
+
+func copyFilters(filters *types.PullRequestFilters) *types.PullRequestFilters {
+	c := *filters
+	c.TargetBranches = copyStrings(filters.TargetBranches)
+	c.IncludeTitles = copyStrings(filters.IncludeTitles)
+	c.ExcludeTitles = copyStrings(filters.ExcludeTitles)
+	c.AllowAuthors = copyStrings(filters.AllowAuthors)
+	c.DenyAuthors = copyStrings(filters.DenyAuthors)
+	c.RequireLabels = copyStrings(filters.RequireLabels)
+	c.ExcludeLabels = copyStrings(filters.ExcludeLabels)
+	return &c
+}
+
+func copyStrings(s []string) []string {
+	if s == nil {
+		return nil
+	}
+	return append([]string{}, s...)
+}
+
+func copyAssignment(assignment *types.Assignment) *types.Assignment {
+	c := *assignment
+	if assignment.RequiredReviewers != nil {
+		c.RequiredReviewers = append([]string{}, assignment.RequiredReviewers...)
+	}
+	if assignment.OptionalReviewers != nil {
+		c.OptionalReviewers = append([]string{}, assignment.OptionalReviewers...)
+	}
+	return &c
+}
@@ -0,0 +1,548 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+	"github.com/pkg/errors"
+
+	"github.com/samkreter/devopshelper/pkg/store"
+	"github.com/samkreter/devopshelper/pkg/types"
+)
+
+// Validate the interface implementation
+var _ store.RepositoryStore = &Store{}
+var _ store.ReviewerStore = &Store{}
+var _ store.TeamStore = &Store{}
+var _ store.AssignmentStore = &Store{}
+
+// Store is a thread safe in-memory store, useful for tests and local demos
+type Store struct {
+	mu           sync.RWMutex
+	repositories map[bson.ObjectId]*types.Repository
+	reviewers    map[bson.ObjectId]*types.Reviewer
+	teams        map[bson.ObjectId]*types.Team
+	assignments  map[string]*types.Assignment
+	now          func() time.Time
+}
+
+// NewStore creates a new empty in-memory store
+func NewStore() *Store {
+	return &Store{
+		repositories: map[bson.ObjectId]*types.Repository{},
+		reviewers:    map[bson.ObjectId]*types.Reviewer{},
+		teams:        map[bson.ObjectId]*types.Team{},
+		assignments:  map[string]*types.Assignment{},
+		now:          time.Now,
+	}
+}
+
+// PopLRUReviewer gets the least recently used reviewers and marks them as just reviewed
+func (s *Store) PopLRUReviewer(ctx context.Context, aliases []string, count int) ([]*types.Reviewer, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	remaining := make(map[string]bool, len(aliases))
+	for _, alias := range aliases {
+		remaining[alias] = true
+	}
+
+	now := s.now().UTC()
+	var reviewers []*types.Reviewer
+	for len(reviewers) < count {
+		reviewer := s.lruReviewer(remaining, now)
+		if reviewer == nil {
+			break
+		}
+
+		reviewer.RecordReview(now)
+		delete(remaining, reviewer.Alias)
+		reviewers = append(reviewers, copyReviewer(reviewer))
+	}
+
+	if len(reviewers) == 0 && count > 0 {
+		return nil, errors.WithStack(store.ErrNotFound)
+	}
+
+	return reviewers, nil
+}
+
+// GetLRUReviewer gets the least recently used reviewer from the aliases
+func (s *Store) GetLRUReviewer(ctx context.Context, aliases []string) (*types.Reviewer, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	wanted := make(map[string]bool, len(aliases))
+	for _, alias := range aliases {
+		wanted[alias] = true
+	}
+
+	reviewer := s.lruReviewer(wanted, s.now().UTC())
+	if reviewer == nil {
+		return nil, errors.WithStack(store.ErrNotFound)
+	}
+
+	return copyReviewer(reviewer), nil
+}
+
+// lruReviewer returns the least recently used available reviewer, ties are broken by alias. The lock must be held by the caller.
+func (s *Store) lruReviewer(wanted map[string]bool, now time.Time) *types.Reviewer {
+	var lru *types.Reviewer
+	for _, reviewer := range s.reviewers {
+		if !wanted[reviewer.Alias] || !reviewer.Available(now) {
+			continue
+		}
+
+		if lru == nil || reviewer.LastReviewTime.Before(lru.LastReviewTime) ||
+			(reviewer.LastReviewTime.Equal(lru.LastReviewTime) && reviewer.Alias < lru.Alias) {
+			lru = reviewer
+		}
+	}
+
+	return lru
+}
+
+// AddReviewer adds a new reviewer
+func (s *Store) AddReviewer(ctx context.Context, reviewer *types.Reviewer) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.findReviewer(func(r *types.Reviewer) bool { return r.Alias == reviewer.Alias }) != nil {
+		return errors.Wrapf(store.ErrAlreadyExists, "reviewer %q", reviewer.Alias)
+	}
+
+	if reviewer.ID == "" {
+		reviewer.ID = bson.NewObjectId()
+	}
+
+	if _, ok := s.reviewers[reviewer.ID]; ok {
+		return errors.Wrapf(store.ErrAlreadyExists, "reviewer id %q", reviewer.ID.Hex())
+	}
+
+	s.reviewers[reviewer.ID] = copyReviewer(reviewer)
+	return nil
+}
+
+// GetReviewer gets a reviewer by alias
+func (s *Store) GetReviewer(ctx context.Context, alias string) (*types.Reviewer, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	reviewer := s.findReviewer(func(r *types.Reviewer) bool { return r.Alias == alias })
+	if reviewer == nil {
+		return nil, errors.WithStack(store.ErrNotFound)
+	}
+
+	return copyReviewer(reviewer), nil
+}
+
+// GetReviewerByADOID gets a reviewer by their azure devops id
+func (s *Store) GetReviewerByADOID(ctx context.Context, adoID string) (*types.Reviewer, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	reviewer := s.findReviewer(func(r *types.Reviewer) bool { return r.AdoID == adoID })
+	if reviewer == nil {
+		return nil, errors.WithStack(store.ErrNotFound)
+	}
+
+	return copyReviewer(reviewer), nil
+}
+
+// UpdateReviewer replaces the reviewer with the matching id
+func (s *Store) UpdateReviewer(ctx context.Context, reviewer *types.Reviewer) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.reviewers[reviewer.ID]; !ok {
+		return errors.WithStack(store.ErrNotFound)
+	}
+
+	conflict := s.findReviewer(func(r *types.Reviewer) bool { return r.Alias == reviewer.Alias && r.ID != reviewer.ID })
+	if conflict != nil {
+		return errors.Wrapf(store.ErrAlreadyExists, "reviewer %q", reviewer.Alias)
+	}
+
+	s.reviewers[reviewer.ID] = copyReviewer(reviewer)
+	return nil
+}
+
+// UpdateReviewerFields only updates the given fields of the reviewer with the matching id
+func (s *Store) UpdateReviewerFields(ctx context.Context, reviewer *types.Reviewer, fields ...store.ReviewerField) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.reviewers[reviewer.ID]
+	if !ok {
+		return errors.WithStack(store.ErrNotFound)
+	}
+
+	updated := copyReviewer(existing)
+	if err := store.CopyReviewerFields(updated, reviewer, fields); err != nil {
+		return err
+	}
+
+	s.reviewers[reviewer.ID] = updated
+	return nil
+}
+
+// RecordReview marks the reviewer as just reviewed and counts the review against their weekly capacity
+func (s *Store) RecordReview(ctx context.Context, alias string, now time.Time) (*types.Reviewer, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	reviewer := s.findReviewer(func(r *types.Reviewer) bool { return r.Alias == alias })
+	if reviewer == nil {
+		return nil, errors.WithStack(store.ErrNotFound)
+	}
+
+	reviewer.RecordReview(now.UTC())
+	return copyReviewer(reviewer), nil
+}
+
+// DeleteReviewer removes a reviewer by alias
+func (s *Store) DeleteReviewer(ctx context.Context, alias string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	reviewer := s.findReviewer(func(r *types.Reviewer) bool { return r.Alias == alias })
+	if reviewer == nil {
+		return errors.WithStack(store.ErrNotFound)
+	}
+
+	delete(s.reviewers, reviewer.ID)
+	return nil
+}
+
+// GetAllReviewers retrieves all reviewers sorted by alias
+func (s *Store) GetAllReviewers(ctx context.Context) ([]*types.Reviewer, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	reviewers := make([]*types.Reviewer, 0, len(s.reviewers))
+	for _, reviewer := range s.reviewers {
+		reviewers = append(reviewers, copyReviewer(reviewer))
+	}
+
+	sort.Slice(reviewers, func(i, j int) bool { return reviewers[i].Alias < reviewers[j].Alias })
+	return reviewers, nil
+}
+
+func (s *Store) findReviewer(match func(*types.Reviewer) bool) *types.Reviewer {
+	for _, reviewer := range s.reviewers {
+		if match(reviewer) {
+			return reviewer
+		}
+	}
+
+	return nil
+}
+
+// AddTeam adds a new team
+func (s *Store) AddTeam(ctx context.Context, team *types.Team) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.findTeam(team.Name) != nil {
+		return errors.Wrapf(store.ErrAlreadyExists, "team %q", team.Name)
+	}
+
+	if team.ID == "" {
+		team.ID = bson.NewObjectId()
+	}
+
+	if _, ok := s.teams[team.ID]; ok {
+		return errors.Wrapf(store.ErrAlreadyExists, "team id %q", team.ID.Hex())
+	}
+
+	s.teams[team.ID] = copyTeam(team)
+	return nil
+}
+
+// GetTeam gets a team by name
+func (s *Store) GetTeam(ctx context.Context, name string) (*types.Team, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	team := s.findTeam(name)
+	if team == nil {
+		return nil, errors.WithStack(store.ErrNotFound)
+	}
+
+	return copyTeam(team), nil
+}
+
+// UpdateTeam replaces the team with the matching id
+func (s *Store) UpdateTeam(ctx context.Context, team *types.Team) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.teams[team.ID]; !ok {
+		return errors.WithStack(store.ErrNotFound)
+	}
+
+	if conflict := s.findTeam(team.Name); conflict != nil && conflict.ID != team.ID {
+		return errors.Wrapf(store.ErrAlreadyExists, "team %q", team.Name)
+	}
+
+	s.teams[team.ID] = copyTeam(team)
+	return nil
+}
+
+// DeleteTeam removes a team by name
+func (s *Store) DeleteTeam(ctx context.Context, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	team := s.findTeam(name)
+	if team == nil {
+		return errors.WithStack(store.ErrNotFound)
+	}
+
+	delete(s.teams, team.ID)
+	return nil
+}
+
+// GetAllTeams retrieves all teams sorted by name
+func (s *Store) GetAllTeams(ctx context.Context) ([]*types.Team, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	teams := make([]*types.Team, 0, len(s.teams))
+	for _, team := range s.teams {
+		teams = append(teams, copyTeam(team))
+	}
+
+	sort.Slice(teams, func(i, j int) bool { return teams[i].Name < teams[j].Name })
+	return teams, nil
+}
+
+func (s *Store) findTeam(name string) *types.Team {
+	for _, team := range s.teams {
+		if team.Name == name {
+			return team
+		}
+	}
+
+	return nil
+}
+
+// AddRepository adds a new repository
+func (s *Store) AddRepository(ctx context.Context, repo *types.Repository) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.findRepository(repo.Name, repo.ProjectName) != nil {
+		return errors.Wrapf(store.ErrAlreadyExists, "repository %s/%s", repo.ProjectName, repo.Name)
+	}
+
+	if repo.ID == "" {
+		repo.ID = bson.NewObjectId()
+	}
+
+	if _, ok := s.repositories[repo.ID]; ok {
+		return errors.Wrapf(store.ErrAlreadyExists, "repository id %q", repo.ID.Hex())
+	}
+
+	s.repositories[repo.ID] = copyRepository(repo)
+	return nil
+}
+
+// UpdateRepository replaces the repository with the id
+func (s *Store) UpdateRepository(ctx context.Context, id string, repo *types.Repository) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	bsonID, ok := toObjectID(id)
+	if !ok {
+		return errors.WithStack(store.ErrNotFound)
+	}
+
+	if _, ok := s.repositories[bsonID]; !ok {
+		return errors.WithStack(store.ErrNotFound)
+	}
+
+	if conflict := s.findRepository(repo.Name, repo.ProjectName); conflict != nil && conflict.ID != bsonID {
+		return errors.Wrapf(store.ErrAlreadyExists, "repository %s/%s", repo.ProjectName, repo.Name)
+	}
+
+	updated := copyRepository(repo)
+	updated.ID = bsonID
+	s.repositories[bsonID] = updated
+	return nil
+}
+
+// DeleteRepository deletes the repository with the id
+func (s *Store) DeleteRepository(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	bsonID, ok := toObjectID(id)
+	if !ok {
+		return errors.WithStack(store.ErrNotFound)
+	}
+
+	if _, ok := s.repositories[bsonID]; !ok {
+		return errors.WithStack(store.ErrNotFound)
+	}
+
+	delete(s.repositories, bsonID)
+	return nil
+}
+
+// GetRepositoryByID retrieves a repository by id
+func (s *Store) GetRepositoryByID(ctx context.Context, id string) (*types.Repository, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	bsonID, ok := toObjectID(id)
+	if !ok {
+		return nil, errors.WithStack(store.ErrNotFound)
+	}
+
+	repo, ok := s.repositories[bsonID]
+	if !ok {
+		return nil, errors.WithStack(store.ErrNotFound)
+	}
+
+	return copyRepository(repo), nil
+}
+
+// GetAllRepositories retrieves all repositories sorted by project and name
+func (s *Store) GetAllRepositories(ctx context.Context) ([]*types.Repository, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	repos := make([]*types.Repository, 0, len(s.repositories))
+	for _, repo := range s.repositories {
+		repos = append(repos, copyRepository(repo))
+	}
+
+	sort.Slice(repos, func(i, j int) bool {
+		if repos[i].ProjectName != repos[j].ProjectName {
+			return repos[i].ProjectName < repos[j].ProjectName
+		}
+		return repos[i].Name < repos[j].Name
+	})
+	return repos, nil
+}
+
+// GetRepositoryByName gets a repository by name and project
+func (s *Store) GetRepositoryByName(ctx context.Context, name, project string) (*types.Repository, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	repo := s.findRepository(name, project)
+	if repo == nil {
+		return nil, errors.WithStack(store.ErrNotFound)
+	}
+
+	return copyRepository(repo), nil
+}
+
+func (s *Store) findRepository(name, project string) *types.Repository {
+	for _, repo := range s.repositories {
+		if repo.Name == name && repo.ProjectName == project {
+			return repo
+		}
+	}
+
+	return nil
+}
+
+// AddAssignment records the reviewers assigned to a pull request
+func (s *Store) AddAssignment(ctx context.Context, assignment *types.Assignment) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := assignmentKey(assignment.RepositoryID, assignment.PullRequestID)
+	if _, ok := s.assignments[key]; ok {
+		return errors.Wrapf(store.ErrAlreadyExists, "assignment %s", key)
+	}
+
+	if assignment.ID == "" {
+		assignment.ID = bson.NewObjectId()
+	}
+
+	s.assignments[key] = copyAssignment(assignment)
+	return nil
+}
+
+// ClaimAssignment adds the assignment unless the pull request already has one that isn't an abandoned claim
+func (s *Store) ClaimAssignment(ctx context.Context, assignment *types.Assignment, staleBefore time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := assignmentKey(assignment.RepositoryID, assignment.PullRequestID)
+	if existing, ok := s.assignments[key]; ok {
+		if !existing.Pending || !existing.Created.Before(staleBefore) {
+			return errors.Wrapf(store.ErrAlreadyExists, "assignment %s", key)
+		}
+		assignment.ID = existing.ID
+	}
+
+	if assignment.ID == "" {
+		assignment.ID = bson.NewObjectId()
+	}
+
+	s.assignments[key] = copyAssignment(assignment)
+	return nil
+}
+
+// UpdateAssignment replaces the assignment with the matching id
+func (s *Store) UpdateAssignment(ctx context.Context, assignment *types.Assignment) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := assignmentKey(assignment.RepositoryID, assignment.PullRequestID)
+	existing, ok := s.assignments[key]
+	if !ok || existing.ID != assignment.ID {
+		return errors.WithStack(store.ErrNotFound)
+	}
+
+	s.assignments[key] = copyAssignment(assignment)
+	return nil
+}
+
+// GetAssignment gets the assignment for a pull request
+func (s *Store) GetAssignment(ctx context.Context, repositoryID string, pullRequestID int) (*types.Assignment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	assignment, ok := s.assignments[assignmentKey(repositoryID, pullRequestID)]
+	if !ok {
+		return nil, errors.WithStack(store.ErrNotFound)
+	}
+
+	return copyAssignment(assignment), nil
+}
+
+// DeleteAssignment removes the assignment for a pull request
+func (s *Store) DeleteAssignment(ctx context.Context, repositoryID string, pullRequestID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := assignmentKey(repositoryID, pullRequestID)
+	if _, ok := s.assignments[key]; !ok {
+		return errors.WithStack(store.ErrNotFound)
+	}
+
+	delete(s.assignments, key)
+	return nil
+}
+
+func assignmentKey(repositoryID string, pullRequestID int) string {
+	return fmt.Sprintf("%s/%d", repositoryID, pullRequestID)
+}
+
+func toObjectID(id string) (bson.ObjectId, bool) {
+	if !bson.IsObjectIdHex(id) {
+		return "", false
+	}
+
+	return bson.ObjectIdHex(id), true
+}
@@ -0,0 +1,52 @@
+package memory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/samkreter/devopshelper/pkg/store"
+	"github.com/samkreter/devopshelper/pkg/store/storetest"
+	"github.com/samkreter/devopshelper/pkg/types"
+)
+
+func TestStore(t *testing.T) {
+	storetest.TestRepositoryStore(t, func(t *testing.T) store.RepositoryStore { return NewStore() })
+	storetest.TestReviewerStore(t, func(t *testing.T) store.ReviewerStore { return NewStore() })
+	storetest.TestTeamStore(t, func(t *testing.T) store.TeamStore { return NewStore() })
+	storetest.TestAssignmentStore(t, func(t *testing.T) store.AssignmentStore { return NewStore() })
+}
+
+func TestRepositoryFiltersAreCopied(t *testing.T) {
+	ctx := context.Background()
+	s := NewStore()
+
+	repo := &types.Repository{
+		Name:        "repo",
+		ProjectName: "project",
+		Filters: &types.PullRequestFilters{
+			TargetBranches: []string{"master"},
+			DenyAuthors:    []string{"bot"},
+		},
+	}
+	if err := s.AddRepository(ctx, repo); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := s.GetRepositoryByID(ctx, repo.ID.Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	got.Filters.TargetBranches[0] = "release/*"
+	got.Filters.DenyAuthors = append(got.Filters.DenyAuthors, "alice")
+	got.Filters.MinChangedFiles = 5
+
+	stored, err := s.GetRepositoryByID(ctx, repo.ID.Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []string{"master"}, stored.Filters.TargetBranches, "Should not share the target branches")
+	assert.Equal(t, []string{"bot"}, stored.Filters.DenyAuthors, "Should not share the deny authors")
+	assert.Equal(t, 0, stored.Filters.MinChangedFiles, "Should not share the filters")
+}
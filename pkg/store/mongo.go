@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"net"
 	"net/url"
-	"sync"
 	"time"
 	"context"
 
@@ -22,10 +21,14 @@ const (
 	defaultRepositoryCollectionName = "repositories"
 	defaultReviewerCollectionName = "reviewers"
 	defaultTeamCollectionName = "teams"
+	defaultAssignmentCollectionName = "assignments"
 )
 
 // Validate the interface implementation
 var _ RepositoryStore = &MongoStore{}
+var _ ReviewerStore = &MongoStore{}
+var _ TeamStore = &MongoStore{}
+var _ AssignmentStore = &MongoStore{}
 
 // MongoStoreOptions options for a mongo store
 type MongoStoreOptions struct {
@@ -35,6 +38,9 @@ type MongoStoreOptions struct {
 	RepositoryCollection string
 	ReviewerCollection   string
 	TeamCollection string
+	AssignmentCollection string
+	MigrationCollection  string
+	SkipMigrations       bool
 }
 
 // MongoStore implementation to interact with a mongo database
@@ -42,7 +48,6 @@ type MongoStore struct {
 	ReviewerGroupCollectionName string
 	sesson                      *mgo.Session
 	Options                     *MongoStoreOptions
-	reviewerWriteLock sync.Mutex
 }
 
 // Close closes a mongo store and it's session
@@ -74,6 +79,14 @@ func NewMongoStore(o *MongoStoreOptions) (*MongoStore, error) {
 		o.TeamCollection = defaultTeamCollectionName
 	}
 
+	if o.AssignmentCollection == "" {
+		o.AssignmentCollection = defaultAssignmentCollectionName
+	}
+
+	if o.MigrationCollection == "" {
+		o.MigrationCollection = defaultMigrationCollectionName
+	}
+
 	logger.Infof("MongoStore: Using DB: '%s' for mongo with RepoCollection: %s, TeamCollection: %s, ReviewerCollection: %s",
 		o.DBName,
 		o.RepositoryCollection,
@@ -118,10 +131,19 @@ func NewMongoStore(o *MongoStoreOptions) (*MongoStore, error) {
 		}
 	}
 
-	return &MongoStore{
+	ms := &MongoStore{
 		sesson:  session,
 		Options: o,
-	}, nil
+	}
+
+	if !o.SkipMigrations {
+		if err := ms.Migrate(ctx); err != nil {
+			session.Close()
+			return nil, err
+		}
+	}
+
+	return ms, nil
 }
 
 func (ms *MongoStore) getCollection(collection string) (*mgo.Session, *mgo.Collection) {
@@ -130,47 +152,199 @@ func (ms *MongoStore) getCollection(collection string) (*mgo.Session, *mgo.Colle
 	return session, col
 }
 
-func (ms *MongoStore) PopLRUReviewer(ctx context.Context, alias []string) (*types.Reviewer, error) {
-	// Lock, trying to fake a transaction using mongo
-	// TODO: Create an actual concurrency solution i.e: stop using Cosmos mongo driver :)
-	ms.reviewerWriteLock.Lock()
-	defer ms.reviewerWriteLock.Unlock()
+// PopLRUReviewer atomically gets the least recently used available reviewers and records the review.
+// Each reviewer is claimed with a findAndModify that only matches while their review count is unchanged, so
+// concurrent callers, including other replicas, never receive the same reviewer for the same review or go over
+// their weekly capacity.
+func (ms *MongoStore) PopLRUReviewer(ctx context.Context, alias []string, count int) ([]*types.Reviewer, error) {
+	if count <= 0 {
+		return nil, nil
+	}
+	if len(alias) == 0 {
+		return nil, errors.WithStack(ErrNotFound)
+	}
 
-	lruReviewer, err := ms.GetLRUReviewer(ctx, alias)
-	if err != nil {
-		return nil, errors.WithStack(err)
+	session, col := ms.getCollection(ms.Options.ReviewerCollection)
+	defer session.Close()
+
+	now := time.Now().UTC()
+
+	var reviewers []*types.Reviewer
+	popped := []string{}
+	for len(reviewers) < count {
+		reviewer, err := popAvailableReviewer(col, bson.M{"$in": alias, "$nin": popped}, now)
+		if err == mgo.ErrNotFound {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		reviewers = append(reviewers, reviewer)
+		popped = append(popped, reviewer.Alias)
 	}
 
-	lruReviewer.LastReviewTime = time.Now().UTC()
+	if len(reviewers) == 0 {
+		return nil, errors.WithStack(ErrNotFound)
+	}
 
-	if err := ms.UpdateReviewer(ctx, lruReviewer); err != nil {
+	return reviewers, nil
+}
+
+// popAvailableReviewer claims the least recently used available reviewer, mgo.ErrNotFound is returned when none are
+// available. The query is retried when another caller records a review for the candidate first.
+func popAvailableReviewer(col *mgo.Collection, alias interface{}, now time.Time) (*types.Reviewer, error) {
+	for {
+		candidates, err := availableReviewers(col, alias, now)
+		if err != nil {
+			return nil, err
+		}
+		if len(candidates) == 0 {
+			return nil, mgo.ErrNotFound
+		}
+
+		reviewer := candidates[0]
+		reviews := reviewer.Reviews
+		reviewer.RecordReview(now)
+
+		change := mgo.Change{
+			Update: bson.M{"$set": bson.M{
+				"lastreviewtime": reviewer.LastReviewTime,
+				"reviewWeek":     reviewer.ReviewWeek,
+				"weeklyReviews":  reviewer.WeeklyReviews,
+				"reviews":        reviewer.Reviews,
+			}},
+			ReturnNew: true,
+		}
+
+		// Reviews is missing until the first review is recorded
+		match := interface{}(reviews)
+		if reviews == 0 {
+			match = bson.M{"$in": []interface{}{0, nil}}
+		}
+
+		_, err = col.Find(bson.M{"_id": reviewer.ID, "reviews": match}).Apply(change, reviewer)
+		if err == mgo.ErrNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		return reviewer, nil
+	}
+}
+
+// availableReviewers gets the available reviewers for the aliases, least recently used first. Ties are broken by
+// alias to keep the rotation deterministic. The weekly capacity is checked after the query since comparing two
+// fields isn't supported by every Mongo API.
+func availableReviewers(col *mgo.Collection, alias interface{}, now time.Time) ([]*types.Reviewer, error) {
+	var reviewers []*types.Reviewer
+	if err := col.Find(availableReviewersQuery(alias, now)).Sort("lastreviewtime", "alias").All(&reviewers); err != nil {
 		return nil, errors.WithStack(err)
 	}
 
-	return lruReviewer, nil
+	available := reviewers[:0]
+	for _, reviewer := range reviewers {
+		if reviewer.Available(now) {
+			available = append(available, reviewer)
+		}
+	}
+
+	return available, nil
+}
+
+// recordReview marks the matching reviewer as just reviewed and counts the review against their weekly capacity,
+// resetting the count on a new week
+func recordReview(col *mgo.Collection, selector bson.M, now time.Time, reviewer *types.Reviewer) error {
+	week := types.ReviewWeekOf(now)
+	for {
+		change := mgo.Change{
+			Update: bson.M{
+				"$set": bson.M{"lastreviewtime": now},
+				"$inc": bson.M{"weeklyReviews": 1, "reviews": 1},
+			},
+			ReturnNew: true,
+		}
+		_, err := col.Find(withReviewWeek(selector, week)).Apply(change, reviewer)
+		if err != mgo.ErrNotFound {
+			return errors.WithStack(err)
+		}
+
+		change = mgo.Change{
+			Update: bson.M{
+				"$set": bson.M{"lastreviewtime": now, "reviewWeek": week, "weeklyReviews": 1},
+				"$inc": bson.M{"reviews": 1},
+			},
+			ReturnNew: true,
+		}
+		_, err = col.Find(withReviewWeek(selector, bson.M{"$ne": week})).Apply(change, reviewer)
+		if err != mgo.ErrNotFound {
+			return errors.WithStack(err)
+		}
+
+		// Another update may start the new week first, in which case the increment is retried
+		n, err := col.Find(selector).Count()
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if n == 0 {
+			return errors.WithStack(ErrNotFound)
+		}
+	}
+}
+
+func withReviewWeek(selector bson.M, week interface{}) bson.M {
+	query := bson.M{"reviewWeek": week}
+	for key, value := range selector {
+		query[key] = value
+	}
+	return query
+}
+
+// availableReviewersQuery matches the aliases that aren't paused or out of office
+func availableReviewersQuery(alias interface{}, now time.Time) bson.M {
+	return bson.M{
+		"alias":  alias,
+		"paused": bson.M{"$ne": true},
+		"unavailable": bson.M{"$not": bson.M{"$elemMatch": bson.M{
+			"start": bson.M{"$lte": now},
+			"end":   bson.M{"$gt": now},
+		}}},
+	}
 }
 
 func (ms *MongoStore) GetLRUReviewer(ctx context.Context, alias []string) (*types.Reviewer, error) {
+	if len(alias) == 0 {
+		return nil, errors.WithStack(ErrNotFound)
+	}
+
 	session, col := ms.getCollection(ms.Options.ReviewerCollection)
 	defer session.Close()
 
-	var reviewer types.Reviewer
-	err := col.Find(bson.M{"alias": bson.M{"$in": alias}}).Sort("lastreviewtime").One(&reviewer)
+	reviewers, err := availableReviewers(col, bson.M{"$in": alias}, time.Now().UTC())
 	if err != nil {
-		if err == mgo.ErrNotFound {
-			return nil, errors.WithStack(ErrNotFound)
-		}
-		return nil, errors.WithStack(err)
+		return nil, err
+	}
+	if len(reviewers) == 0 {
+		return nil, errors.WithStack(ErrNotFound)
 	}
 
-	return &reviewer, nil
+	return reviewers[0], nil
 }
 
 func (ms *MongoStore) AddReviewer(ctx context.Context, reviewer *types.Reviewer) error {
 	session, col := ms.getCollection(ms.Options.ReviewerCollection)
 	defer session.Close()
 
+	if reviewer.ID == "" {
+		reviewer.ID = bson.NewObjectId()
+	}
+
 	if err := col.Insert(reviewer); err != nil {
+		if mgo.IsDup(err) {
+			return errors.Wrap(ErrAlreadyExists, err.Error())
+		}
 		return err
 	}
 
@@ -182,12 +356,87 @@ func (ms *MongoStore) UpdateReviewer(ctx context.Context, reviewer *types.Review
 	defer session.Close()
 
 	if err := col.UpdateId(reviewer.ID, reviewer); err != nil {
+		if err == mgo.ErrNotFound {
+			return errors.WithStack(ErrNotFound)
+		}
+		if mgo.IsDup(err) {
+			return errors.Wrap(ErrAlreadyExists, err.Error())
+		}
+		return err
+	}
+
+	return nil
+}
+
+// UpdateReviewerFields sets only the given fields of the reviewer with the matching id
+func (ms *MongoStore) UpdateReviewerFields(ctx context.Context, reviewer *types.Reviewer, fields ...ReviewerField) error {
+	set := bson.M{}
+	for _, field := range fields {
+		value, err := ReviewerFieldValue(reviewer, field)
+		if err != nil {
+			return err
+		}
+		set[string(field)] = value
+	}
+
+	session, col := ms.getCollection(ms.Options.ReviewerCollection)
+	defer session.Close()
+
+	if err := col.UpdateId(reviewer.ID, bson.M{"$set": set}); err != nil {
+		if err == mgo.ErrNotFound {
+			return errors.WithStack(ErrNotFound)
+		}
 		return err
 	}
 
 	return nil
 }
 
+// RecordReview atomically marks the reviewer as just reviewed and counts the review against their weekly capacity
+func (ms *MongoStore) RecordReview(ctx context.Context, alias string, now time.Time) (*types.Reviewer, error) {
+	session, col := ms.getCollection(ms.Options.ReviewerCollection)
+	defer session.Close()
+
+	var reviewer types.Reviewer
+	if err := recordReview(col, bson.M{"alias": alias}, now.UTC(), &reviewer); err != nil {
+		return nil, err
+	}
+
+	return &reviewer, nil
+}
+
+// DeleteReviewer removes a reviewer by alias
+func (ms *MongoStore) DeleteReviewer(ctx context.Context, alias string) error {
+	session, col := ms.getCollection(ms.Options.ReviewerCollection)
+	defer session.Close()
+
+	if err := col.Remove(bson.M{"alias": alias}); err != nil {
+		if err == mgo.ErrNotFound {
+			return errors.WithStack(ErrNotFound)
+		}
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+// GetAllReviewers retrieves all reviewers sorted by alias
+func (ms *MongoStore) GetAllReviewers(ctx context.Context) ([]*types.Reviewer, error) {
+	session, col := ms.getCollection(ms.Options.ReviewerCollection)
+	defer session.Close()
+
+	var reviewers []*types.Reviewer
+	if err := col.Find(nil).Sort("alias").All(&reviewers); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if reviewers == nil {
+		return []*types.Reviewer{}, nil
+	}
+
+	return reviewers, nil
+}
+
 func (ms *MongoStore) GetReviewerByADOID(ctx context.Context, adoID string) (*types.Reviewer, error) {
 	session, col := ms.getCollection(ms.Options.ReviewerCollection)
 	defer session.Close()
@@ -240,7 +489,14 @@ func (ms *MongoStore) AddTeam(ctx context.Context, team *types.Team) error {
 	session, col := ms.getCollection(ms.Options.TeamCollection)
 	defer session.Close()
 
+	if team.ID == "" {
+		team.ID = bson.NewObjectId()
+	}
+
 	if err := col.Insert(team); err != nil {
+		if mgo.IsDup(err) {
+			return errors.Wrap(ErrAlreadyExists, err.Error())
+		}
 		return err
 	}
 
@@ -252,18 +508,162 @@ func (ms *MongoStore) UpdateTeam(ctx context.Context, team *types.Team) error {
 	defer session.Close()
 
 	if err := col.UpdateId(team.ID, team); err != nil {
+		if err == mgo.ErrNotFound {
+			return errors.WithStack(ErrNotFound)
+		}
+		if mgo.IsDup(err) {
+			return errors.Wrap(ErrAlreadyExists, err.Error())
+		}
 		return err
 	}
 
 	return nil
 }
 
+// DeleteTeam removes a team by name
+func (ms *MongoStore) DeleteTeam(ctx context.Context, name string) error {
+	session, col := ms.getCollection(ms.Options.TeamCollection)
+	defer session.Close()
+
+	if err := col.Remove(bson.M{"name": name}); err != nil {
+		if err == mgo.ErrNotFound {
+			return errors.WithStack(ErrNotFound)
+		}
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+// GetAllTeams retrieves all teams sorted by name
+func (ms *MongoStore) GetAllTeams(ctx context.Context) ([]*types.Team, error) {
+	session, col := ms.getCollection(ms.Options.TeamCollection)
+	defer session.Close()
+
+	var teams []*types.Team
+	if err := col.Find(nil).Sort("name").All(&teams); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if teams == nil {
+		return []*types.Team{}, nil
+	}
+
+	return teams, nil
+}
+
+// AddAssignment records the reviewers assigned to a pull request
+func (ms *MongoStore) AddAssignment(ctx context.Context, assignment *types.Assignment) error {
+	session, col := ms.getCollection(ms.Options.AssignmentCollection)
+	defer session.Close()
+
+	if assignment.ID == "" {
+		assignment.ID = bson.NewObjectId()
+	}
+
+	if err := col.Insert(assignment); err != nil {
+		if mgo.IsDup(err) {
+			return errors.Wrap(ErrAlreadyExists, err.Error())
+		}
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+// ClaimAssignment inserts the assignment or replaces an abandoned pending one, the unique pull request index
+// rejects the claim when the pull request already has an assignment
+func (ms *MongoStore) ClaimAssignment(ctx context.Context, assignment *types.Assignment, staleBefore time.Time) error {
+	session, col := ms.getCollection(ms.Options.AssignmentCollection)
+	defer session.Close()
+
+	// The id is kept when replacing an abandoned claim
+	claim := *assignment
+	claim.ID = ""
+
+	change := mgo.Change{
+		Update:    &claim,
+		Upsert:    true,
+		ReturnNew: true,
+	}
+
+	query := bson.M{
+		"repositoryId":  assignment.RepositoryID,
+		"pullRequestId": assignment.PullRequestID,
+		"pending":       true,
+		"created":       bson.M{"$lt": staleBefore},
+	}
+
+	if _, err := col.Find(query).Apply(change, assignment); err != nil {
+		if mgo.IsDup(err) {
+			return errors.Wrap(ErrAlreadyExists, err.Error())
+		}
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+// UpdateAssignment replaces the assignment with the matching id
+func (ms *MongoStore) UpdateAssignment(ctx context.Context, assignment *types.Assignment) error {
+	session, col := ms.getCollection(ms.Options.AssignmentCollection)
+	defer session.Close()
+
+	if err := col.UpdateId(assignment.ID, assignment); err != nil {
+		if err == mgo.ErrNotFound {
+			return errors.WithStack(ErrNotFound)
+		}
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+// GetAssignment gets the assignment for a pull request
+func (ms *MongoStore) GetAssignment(ctx context.Context, repositoryID string, pullRequestID int) (*types.Assignment, error) {
+	session, col := ms.getCollection(ms.Options.AssignmentCollection)
+	defer session.Close()
+
+	var assignment types.Assignment
+	err := col.Find(bson.M{"repositoryId": repositoryID, "pullRequestId": pullRequestID}).One(&assignment)
+	if err != nil {
+		if err == mgo.ErrNotFound {
+			return nil, errors.WithStack(ErrNotFound)
+		}
+		return nil, errors.WithStack(err)
+	}
+
+	return &assignment, nil
+}
+
+// DeleteAssignment removes the assignment for a pull request
+func (ms *MongoStore) DeleteAssignment(ctx context.Context, repositoryID string, pullRequestID int) error {
+	session, col := ms.getCollection(ms.Options.AssignmentCollection)
+	defer session.Close()
+
+	if err := col.Remove(bson.M{"repositoryId": repositoryID, "pullRequestId": pullRequestID}); err != nil {
+		if err == mgo.ErrNotFound {
+			return errors.WithStack(ErrNotFound)
+		}
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
 // AddRepository adds a repository to the mongo database
 func (ms *MongoStore) AddRepository(ctx context.Context, repo *types.Repository) error {
 	session, col := ms.getCollection(ms.Options.RepositoryCollection)
 	defer session.Close()
 
+	if repo.ID == "" {
+		repo.ID = bson.NewObjectId()
+	}
+
 	if err := col.Insert(repo); err != nil {
+		if mgo.IsDup(err) {
+			return errors.Wrap(ErrAlreadyExists, err.Error())
+		}
 		return err
 	}
 
@@ -275,9 +675,18 @@ func (ms *MongoStore) UpdateRepository(ctx context.Context, id string, repositor
 	session, col := ms.getCollection(ms.Options.RepositoryCollection)
 	defer session.Close()
 
-	bsonID := bson.ObjectIdHex(id)
+	bsonID, err := toObjectID(id)
+	if err != nil {
+		return err
+	}
 
 	if err := col.UpdateId(bsonID, repository); err != nil {
+		if err == mgo.ErrNotFound {
+			return errors.WithStack(ErrNotFound)
+		}
+		if mgo.IsDup(err) {
+			return errors.Wrap(ErrAlreadyExists, err.Error())
+		}
 		return fmt.Errorf("MongoStore.UpdateRepository: %v", err)
 	}
 
@@ -289,9 +698,15 @@ func (ms *MongoStore) DeleteRepository(ctx context.Context, id string) error {
 	session, col := ms.getCollection(ms.Options.RepositoryCollection)
 	defer session.Close()
 
-	bsonID := bson.ObjectIdHex(id)
+	bsonID, err := toObjectID(id)
+	if err != nil {
+		return err
+	}
 
 	if err := col.RemoveId(bsonID); err != nil {
+		if err == mgo.ErrNotFound {
+			return errors.WithStack(ErrNotFound)
+		}
 		return err
 	}
 
@@ -303,10 +718,13 @@ func (ms *MongoStore) GetRepositoryByID(ctx context.Context, id string) (*types.
 	session, col := ms.getCollection(ms.Options.RepositoryCollection)
 	defer session.Close()
 
-	bsonID := bson.ObjectIdHex(id)
+	bsonID, err := toObjectID(id)
+	if err != nil {
+		return nil, err
+	}
 
 	var repo types.Repository
-	err := col.Find(bson.M{"_id": bsonID}).One(&repo)
+	err = col.Find(bson.M{"_id": bsonID}).One(&repo)
 	if err != nil {
 		if err == mgo.ErrNotFound {
 			return nil, errors.WithStack(ErrNotFound)
@@ -352,6 +770,15 @@ func (ms *MongoStore) GetRepositoryByName(ctx context.Context, name, project str
 	return &repo, nil
 }
 
+// toObjectID converts a hex id to an object id, malformed ids can't match a record so are not found
+func toObjectID(id string) (bson.ObjectId, error) {
+	if !bson.IsObjectIdHex(id) {
+		return "", errors.Wrapf(ErrNotFound, "malformed id %q", id)
+	}
+
+	return bson.ObjectIdHex(id), nil
+}
+
 // StripSSLFromURI removes the ssl from an URI
 func StripSSLFromURI(uri string) (string, error) {
 	u, err := url.Parse(uri)
@@ -0,0 +1,121 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+	"github.com/pkg/errors"
+	"github.com/samkreter/go-core/log"
+)
+
+const (
+	defaultMigrationCollectionName = "migrations"
+
+	// legacyAdoRepoIDKey is the key written by the malformed AdoRepoID struct tag
+	legacyAdoRepoIDKey = "adorepoid"
+	adoRepoIDKey       = "adoRepoId"
+)
+
+// mongoMigration upgrades the database by one schema version. Migrations must be safe to run
+// more than once since multiple replicas may start at the same time.
+type mongoMigration struct {
+	Version     int
+	Description string
+	Migrate     func(ms *MongoStore, db *mgo.Database) error
+}
+
+type migrationRecord struct {
+	Version     int       `bson:"_id"`
+	Description string    `bson:"description"`
+	Applied     time.Time `bson:"applied"`
+}
+
+// mongoMigrations are applied in order, new migrations must be appended with the next version
+var mongoMigrations = []mongoMigration{
+	{
+		Version:     1,
+		Description: "rename legacy repository AdoRepoID field",
+		Migrate: func(ms *MongoStore, db *mgo.Database) error {
+			_, err := db.C(ms.Options.RepositoryCollection).UpdateAll(
+				bson.M{legacyAdoRepoIDKey: bson.M{"$exists": true}},
+				bson.M{"$rename": bson.M{legacyAdoRepoIDKey: adoRepoIDKey}})
+			return err
+		},
+	},
+	{
+		Version:     2,
+		Description: "ensure unique and lookup indexes",
+		Migrate: func(ms *MongoStore, db *mgo.Database) error {
+			indexes := []struct {
+				collection string
+				index      mgo.Index
+			}{
+				{ms.Options.ReviewerCollection, mgo.Index{Key: []string{"alias"}, Unique: true}},
+				{ms.Options.ReviewerCollection, mgo.Index{Key: []string{"id"}, Unique: true, Sparse: true}},
+				{ms.Options.ReviewerCollection, mgo.Index{Key: []string{"lastreviewtime", "alias"}}},
+				{ms.Options.TeamCollection, mgo.Index{Key: []string{"name"}, Unique: true}},
+				{ms.Options.RepositoryCollection, mgo.Index{Key: []string{"name", "projectName"}, Unique: true}},
+			}
+
+			for _, idx := range indexes {
+				if err := db.C(idx.collection).EnsureIndex(idx.index); err != nil {
+					return errors.Wrapf(err, "failed to ensure index %v on %s, remove any duplicate records and restart",
+						idx.index.Key, idx.collection)
+				}
+			}
+
+			return nil
+		},
+	},
+	{
+		Version:     3,
+		Description: "ensure unique pull request assignment index",
+		Migrate: func(ms *MongoStore, db *mgo.Database) error {
+			return db.C(ms.Options.AssignmentCollection).EnsureIndex(mgo.Index{
+				Key:    []string{"repositoryId", "pullRequestId"},
+				Unique: true,
+			})
+		},
+	},
+}
+
+// Migrate applies each migration that hasn't been recorded in the migration collection
+func (ms *MongoStore) Migrate(ctx context.Context) error {
+	logger := log.G(ctx)
+
+	session := ms.sesson.Copy()
+	defer session.Close()
+
+	db := session.DB(ms.Options.DBName)
+	records := db.C(ms.Options.MigrationCollection)
+
+	for _, migration := range mongoMigrations {
+		count, err := records.FindId(migration.Version).Count()
+		if err != nil {
+			return errors.Wrapf(err, "failed to check migration %d", migration.Version)
+		}
+
+		if count > 0 {
+			continue
+		}
+
+		logger.Infof("MongoStore: Applying migration %d: %s", migration.Version, migration.Description)
+		if err := migration.Migrate(ms, db); err != nil {
+			return errors.Wrapf(err, "failed to apply migration %d", migration.Version)
+		}
+
+		err = records.Insert(&migrationRecord{
+			Version:     migration.Version,
+			Description: migration.Description,
+			Applied:     time.Now().UTC(),
+		})
+		// Another replica may have recorded the migration first
+		if err != nil && !mgo.IsDup(err) {
+			return errors.Wrapf(err, "failed to record migration %d", migration.Version)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,60 @@
+package store_test
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+
+	"github.com/samkreter/devopshelper/pkg/store"
+	"github.com/samkreter/devopshelper/pkg/store/storetest"
+)
+
+const (
+	mongoTestURIEnv     = "MONGO_TEST_URI"
+	defaultMongoTestURI = "mongodb://localhost:27017"
+)
+
+// TestMongoStore runs the store tests against a local mongod, set MONGO_TEST_URI to use a different server.
+func TestMongoStore(t *testing.T) {
+	uri := os.Getenv(mongoTestURIEnv)
+	if uri == "" {
+		uri = defaultMongoTestURI
+	}
+
+	session, err := mgo.DialWithTimeout(uri, time.Second*2)
+	if err != nil {
+		t.Skipf("skipping mongo store tests, no mongod available at %q: %v", uri, err)
+	}
+	defer session.Close()
+
+	var stores []*store.MongoStore
+	defer func() {
+		// Drop each test database once the tests finish
+		for _, ms := range stores {
+			session.DB(ms.Options.DBName).DropDatabase()
+			ms.Close()
+		}
+	}()
+
+	newStore := func(t *testing.T) *store.MongoStore {
+		ms, err := store.NewMongoStore(&store.MongoStoreOptions{
+			MongoURI: uri,
+			DBName:   fmt.Sprintf("storetest_%s", bson.NewObjectId().Hex()),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		stores = append(stores, ms)
+		return ms
+	}
+
+	storetest.TestRepositoryStore(t, func(t *testing.T) store.RepositoryStore { return newStore(t) })
+	storetest.TestReviewerStore(t, func(t *testing.T) store.ReviewerStore { return newStore(t) })
+	storetest.TestTeamStore(t, func(t *testing.T) store.TeamStore { return newStore(t) })
+	storetest.TestAssignmentStore(t, func(t *testing.T) store.AssignmentStore { return newStore(t) })
+}
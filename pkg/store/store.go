@@ -2,29 +2,56 @@ package store
 
 import (
 	"context"
+	"time"
+
 	"github.com/pkg/errors"
 	"github.com/samkreter/devopshelper/pkg/types"
 )
 
+// ReviewerField is a reviewer setting that can be updated without replacing the reviewer, the
+// value is the field's bson key
+type ReviewerField string
+
+const (
+	ReviewerFieldAdoID          ReviewerField = "id"
+	ReviewerFieldWeight         ReviewerField = "weight"
+	ReviewerFieldMaxOpenReviews ReviewerField = "maxOpenReviews"
+	ReviewerFieldPaused         ReviewerField = "paused"
+	ReviewerFieldUnavailable    ReviewerField = "unavailable"
+	ReviewerFieldWeeklyCapacity ReviewerField = "weeklyCapacity"
+)
+
 var (
 	// ErrNotFound the error is not found
 	ErrNotFound = errors.New("record not found")
+
+	// ErrAlreadyExists the record conflicts with an existing record
+	ErrAlreadyExists = errors.New("record already exists")
 )
 
 
 type ReviewerStore interface {
-	PopLRUReviewer(ctx context.Context, alias []string) (*types.Reviewer, error)
+	// PopLRUReviewer pops up to count distinct least recently used reviewers, ErrNotFound is returned when none match
+	PopLRUReviewer(ctx context.Context, alias []string, count int) ([]*types.Reviewer, error)
 	GetLRUReviewer(ctx context.Context, alias []string) (*types.Reviewer, error)
 	AddReviewer(ctx context.Context, reviewer *types.Reviewer) error
 	GetReviewer(ctx context.Context, alias string) (*types.Reviewer, error)
 	GetReviewerByADOID(ctx context.Context, adoID string) (*types.Reviewer, error)
 	UpdateReviewer(ctx context.Context, reviewer *types.Reviewer) error
+	// UpdateReviewerFields only updates the given fields of the reviewer with the matching id
+	UpdateReviewerFields(ctx context.Context, reviewer *types.Reviewer, fields ...ReviewerField) error
+	// RecordReview atomically marks the reviewer as just reviewed and counts the review against their weekly capacity
+	RecordReview(ctx context.Context, alias string, now time.Time) (*types.Reviewer, error)
+	DeleteReviewer(ctx context.Context, alias string) error
+	GetAllReviewers(ctx context.Context) ([]*types.Reviewer, error)
 }
 
 type TeamStore interface {
 	AddTeam(ctx context.Context, team *types.Team) error
 	GetTeam(ctx context.Context, alias string) (*types.Team, error)
 	UpdateTeam(ctx context.Context, team *types.Team) error
+	DeleteTeam(ctx context.Context, name string) error
+	GetAllTeams(ctx context.Context) ([]*types.Team, error)
 }
 
 // RepositoryStore holds information for a repository
@@ -35,4 +62,60 @@ type RepositoryStore interface {
 	GetRepositoryByID(ctx context.Context, id string) (*types.Repository, error)
 	GetAllRepositories(ctx context.Context) ([]*types.Repository, error)
 	GetRepositoryByName(ctx context.Context, name, project string) (*types.Repository, error)
-}
\ No newline at end of file
+}
+
+// AssignmentStore records the reviewers assigned to each pull request
+type AssignmentStore interface {
+	AddAssignment(ctx context.Context, assignment *types.Assignment) error
+	// ClaimAssignment adds the assignment unless the pull request already has one, a pending assignment created
+	// before staleBefore was abandoned and is replaced. ErrAlreadyExists is returned when the pull request is claimed.
+	ClaimAssignment(ctx context.Context, assignment *types.Assignment, staleBefore time.Time) error
+	// UpdateAssignment replaces the assignment with the matching id
+	UpdateAssignment(ctx context.Context, assignment *types.Assignment) error
+	GetAssignment(ctx context.Context, repositoryID string, pullRequestID int) (*types.Assignment, error)
+	DeleteAssignment(ctx context.Context, repositoryID string, pullRequestID int) error
+}
+
+// ReviewerFieldValue returns the reviewer's value for the field
+func ReviewerFieldValue(reviewer *types.Reviewer, field ReviewerField) (interface{}, error) {
+	switch field {
+	case ReviewerFieldAdoID:
+		return reviewer.AdoID, nil
+	case ReviewerFieldWeight:
+		return reviewer.Weight, nil
+	case ReviewerFieldMaxOpenReviews:
+		return reviewer.MaxOpenReviews, nil
+	case ReviewerFieldPaused:
+		return reviewer.Paused, nil
+	case ReviewerFieldUnavailable:
+		return reviewer.Unavailable, nil
+	case ReviewerFieldWeeklyCapacity:
+		return reviewer.WeeklyCapacity, nil
+	default:
+		return nil, errors.Errorf("unknown reviewer field %q", field)
+	}
+}
+
+// CopyReviewerFields copies the fields from src to dst
+func CopyReviewerFields(dst, src *types.Reviewer, fields []ReviewerField) error {
+	for _, field := range fields {
+		switch field {
+		case ReviewerFieldAdoID:
+			dst.AdoID = src.AdoID
+		case ReviewerFieldWeight:
+			dst.Weight = src.Weight
+		case ReviewerFieldMaxOpenReviews:
+			dst.MaxOpenReviews = src.MaxOpenReviews
+		case ReviewerFieldPaused:
+			dst.Paused = src.Paused
+		case ReviewerFieldUnavailable:
+			dst.Unavailable = append([]types.AvailabilityWindow(nil), src.Unavailable...)
+		case ReviewerFieldWeeklyCapacity:
+			dst.WeeklyCapacity = src.WeeklyCapacity
+		default:
+			return errors.Errorf("unknown reviewer field %q", field)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,758 @@
+// Package storetest provides a shared test suite that every store implementation must pass.
+//
+// The suite defines the store contract:
+//   - Lookups, updates and deletes of missing records return store.ErrNotFound.
+//   - Malformed repository ids are treated as missing records and never panic.
+//   - Add assigns a new id when the record doesn't have one.
+//   - Adding a duplicate repository name/project, reviewer alias or team name returns store.ErrAlreadyExists.
+//   - GetLRUReviewer and PopLRUReviewer return store.ErrNotFound for an empty alias list.
+//   - PopLRUReviewer returns up to count distinct reviewers and nothing for a zero count.
+//   - GetLRUReviewer and PopLRUReviewer skip paused, out of office and at capacity reviewers.
+//   - ClaimAssignment only replaces pending assignments created before staleBefore, one concurrent claim wins.
+//   - Concurrent pops never return the same reviewer twice or exceed a reviewer's weekly capacity.
+//   - Reviewers with equal LastReviewTime are ordered by alias.
+//   - UpdateReviewerFields only changes the given reviewer fields.
+//   - Concurrent RecordReview calls count every review and reset the weekly count on a new week.
+package storetest
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/samkreter/devopshelper/pkg/store"
+	"github.com/samkreter/devopshelper/pkg/types"
+)
+
+const (
+	malformedID = "not-an-object-id"
+)
+
+// RepositoryStoreFactory creates a new empty repository store for a test
+type RepositoryStoreFactory func(t *testing.T) store.RepositoryStore
+
+// ReviewerStoreFactory creates a new empty reviewer store for a test
+type ReviewerStoreFactory func(t *testing.T) store.ReviewerStore
+
+// AssignmentStoreFactory creates a new empty assignment store for a test
+type AssignmentStoreFactory func(t *testing.T) store.AssignmentStore
+
+// TeamStoreFactory creates a new empty team store for a test
+type TeamStoreFactory func(t *testing.T) store.TeamStore
+
+// TestRepositoryStore runs the repository store contract tests
+func TestRepositoryStore(t *testing.T, newStore RepositoryStoreFactory) {
+	ctx := context.Background()
+
+	t.Run("Add and Get", func(t *testing.T) {
+		s := newStore(t)
+
+		repo := &types.Repository{Name: "repo", ProjectName: "project", Enabled: true}
+		requireNoError(t, s.AddRepository(ctx, repo))
+		assert.True(t, repo.ID.Valid(), "Should assign an id")
+
+		byID, err := s.GetRepositoryByID(ctx, repo.ID.Hex())
+		requireNoError(t, err)
+		assert.Equal(t, "repo", byID.Name, "Should get repository by id")
+		assert.True(t, byID.Enabled, "Should keep enabled")
+
+		byName, err := s.GetRepositoryByName(ctx, "repo", "project")
+		requireNoError(t, err)
+		assert.Equal(t, repo.ID, byName.ID, "Should get repository by name")
+
+		_, err = s.GetRepositoryByName(ctx, "repo", "otherProject")
+		assertNotFound(t, err)
+	})
+
+	t.Run("Get All", func(t *testing.T) {
+		s := newStore(t)
+
+		repos, err := s.GetAllRepositories(ctx)
+		requireNoError(t, err)
+		assert.NotNil(t, repos, "Should return an empty slice")
+		assert.Len(t, repos, 0, "Should have no repositories")
+
+		requireNoError(t, s.AddRepository(ctx, &types.Repository{Name: "repo1", ProjectName: "project"}))
+		requireNoError(t, s.AddRepository(ctx, &types.Repository{Name: "repo2", ProjectName: "project"}))
+
+		repos, err = s.GetAllRepositories(ctx)
+		requireNoError(t, err)
+		assert.Len(t, repos, 2, "Should have all repositories")
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		s := newStore(t)
+
+		repo := &types.Repository{Name: "repo", ProjectName: "project"}
+		requireNoError(t, s.AddRepository(ctx, repo))
+
+		repo.Enabled = true
+		repo.AdoRepoID = "ado-id"
+		requireNoError(t, s.UpdateRepository(ctx, repo.ID.Hex(), repo))
+
+		updated, err := s.GetRepositoryByID(ctx, repo.ID.Hex())
+		requireNoError(t, err)
+		assert.True(t, updated.Enabled, "Should update enabled")
+		assert.Equal(t, "ado-id", updated.AdoRepoID, "Should update ado repo id")
+
+		assertNotFound(t, s.UpdateRepository(ctx, bson.NewObjectId().Hex(), repo))
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		s := newStore(t)
+
+		repo := &types.Repository{Name: "repo", ProjectName: "project"}
+		requireNoError(t, s.AddRepository(ctx, repo))
+		requireNoError(t, s.DeleteRepository(ctx, repo.ID.Hex()))
+
+		_, err := s.GetRepositoryByID(ctx, repo.ID.Hex())
+		assertNotFound(t, err)
+
+		assertNotFound(t, s.DeleteRepository(ctx, repo.ID.Hex()))
+	})
+
+	t.Run("Duplicate", func(t *testing.T) {
+		s := newStore(t)
+
+		requireNoError(t, s.AddRepository(ctx, &types.Repository{Name: "repo", ProjectName: "project"}))
+		assertAlreadyExists(t, s.AddRepository(ctx, &types.Repository{Name: "repo", ProjectName: "project"}))
+		requireNoError(t, s.AddRepository(ctx, &types.Repository{Name: "repo", ProjectName: "otherProject"}))
+	})
+
+	t.Run("Malformed ID", func(t *testing.T) {
+		s := newStore(t)
+
+		_, err := s.GetRepositoryByID(ctx, malformedID)
+		assertNotFound(t, err)
+
+		assertNotFound(t, s.UpdateRepository(ctx, malformedID, &types.Repository{Name: "repo"}))
+		assertNotFound(t, s.DeleteRepository(ctx, malformedID))
+	})
+}
+
+// TestReviewerStore runs the reviewer store contract tests
+func TestReviewerStore(t *testing.T, newStore ReviewerStoreFactory) {
+	ctx := context.Background()
+	base := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("Add and Get", func(t *testing.T) {
+		s := newStore(t)
+
+		reviewer := &types.Reviewer{Alias: "alice", AdoID: "ado-alice"}
+		requireNoError(t, s.AddReviewer(ctx, reviewer))
+		assert.True(t, reviewer.ID.Valid(), "Should assign an id")
+
+		byAlias, err := s.GetReviewer(ctx, "alice")
+		requireNoError(t, err)
+		assert.Equal(t, reviewer.ID, byAlias.ID, "Should get reviewer by alias")
+
+		byADOID, err := s.GetReviewerByADOID(ctx, "ado-alice")
+		requireNoError(t, err)
+		assert.Equal(t, "alice", byADOID.Alias, "Should get reviewer by ado id")
+
+		_, err = s.GetReviewer(ctx, "missing")
+		assertNotFound(t, err)
+
+		_, err = s.GetReviewerByADOID(ctx, "missing")
+		assertNotFound(t, err)
+	})
+
+	t.Run("Duplicate", func(t *testing.T) {
+		s := newStore(t)
+
+		requireNoError(t, s.AddReviewer(ctx, &types.Reviewer{Alias: "alice", AdoID: "ado-alice"}))
+		assertAlreadyExists(t, s.AddReviewer(ctx, &types.Reviewer{Alias: "alice"}))
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		s := newStore(t)
+
+		reviewer := &types.Reviewer{Alias: "alice"}
+		requireNoError(t, s.AddReviewer(ctx, reviewer))
+
+		reviewer.AdoID = "ado-alice"
+		reviewer.LastReviewTime = base
+		requireNoError(t, s.UpdateReviewer(ctx, reviewer))
+
+		updated, err := s.GetReviewer(ctx, "alice")
+		requireNoError(t, err)
+		assert.Equal(t, "ado-alice", updated.AdoID, "Should update ado id")
+		assert.True(t, base.Equal(updated.LastReviewTime), "Should update last review time")
+
+		assertNotFound(t, s.UpdateReviewer(ctx, &types.Reviewer{ID: bson.NewObjectId(), Alias: "missing"}))
+	})
+
+	t.Run("Update Fields", func(t *testing.T) {
+		s := newStore(t)
+
+		reviewer := &types.Reviewer{Alias: "alice", AdoID: "ado-alice", Weight: 2, WeeklyReviews: 3, ReviewWeek: types.ReviewWeekOf(base), LastReviewTime: base}
+		requireNoError(t, s.AddReviewer(ctx, reviewer))
+
+		update := &types.Reviewer{ID: reviewer.ID, Alias: "ignored", Weight: 5, Paused: true}
+		requireNoError(t, s.UpdateReviewerFields(ctx, update, store.ReviewerFieldWeight, store.ReviewerFieldPaused))
+
+		updated, err := s.GetReviewer(ctx, "alice")
+		requireNoError(t, err)
+		assert.Equal(t, 5, updated.Weight, "Should update weight")
+		assert.True(t, updated.Paused, "Should update paused")
+		assert.Equal(t, "ado-alice", updated.AdoID, "Should keep fields that weren't given")
+		assert.Equal(t, 3, updated.WeeklyReviews, "Should keep the weekly reviews")
+		assert.True(t, base.Equal(updated.LastReviewTime), "Should keep the last review time")
+
+		assertNotFound(t, s.UpdateReviewerFields(ctx, &types.Reviewer{ID: bson.NewObjectId()}, store.ReviewerFieldWeight))
+	})
+
+	t.Run("Record Review", func(t *testing.T) {
+		s := newStore(t)
+
+		now := time.Now().UTC().Truncate(time.Millisecond)
+		lastWeek := now.AddDate(0, 0, -7)
+		reviewer := &types.Reviewer{Alias: "alice", Weight: 2, WeeklyReviews: 3, ReviewWeek: types.ReviewWeekOf(lastWeek), LastReviewTime: lastWeek}
+		requireNoError(t, s.AddReviewer(ctx, reviewer))
+
+		recorded, err := s.RecordReview(ctx, "alice", now)
+		requireNoError(t, err)
+		assert.Equal(t, 1, recorded.WeeklyReviews, "Should reset the weekly reviews on a new week")
+		assert.Equal(t, types.ReviewWeekOf(now), recorded.ReviewWeek)
+		assert.Equal(t, 1, recorded.Reviews, "Should count the review")
+		assert.True(t, now.Equal(recorded.LastReviewTime), "Should update the last review time")
+
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := s.RecordReview(ctx, "alice", now)
+				assert.NoError(t, err)
+			}()
+		}
+		wg.Wait()
+
+		stored, err := s.GetReviewer(ctx, "alice")
+		requireNoError(t, err)
+		assert.Equal(t, 9, stored.WeeklyReviews, "Should count every concurrent review")
+		assert.Equal(t, 9, stored.Reviews, "Should count every concurrent review")
+		assert.Equal(t, 2, stored.Weight, "Should keep the other fields")
+
+		_, err = s.RecordReview(ctx, "missing", now)
+		assertNotFound(t, err)
+	})
+
+	t.Run("Delete and Get All", func(t *testing.T) {
+		s := newStore(t)
+
+		reviewers, err := s.GetAllReviewers(ctx)
+		requireNoError(t, err)
+		assert.NotNil(t, reviewers, "Should return an empty slice")
+		assert.Len(t, reviewers, 0, "Should have no reviewers")
+
+		requireNoError(t, s.AddReviewer(ctx, &types.Reviewer{Alias: "bob"}))
+		requireNoError(t, s.AddReviewer(ctx, &types.Reviewer{Alias: "alice"}))
+
+		reviewers, err = s.GetAllReviewers(ctx)
+		requireNoError(t, err)
+		assert.Equal(t, []string{"alice", "bob"}, reviewerAliases(reviewers), "Should get all reviewers sorted by alias")
+
+		requireNoError(t, s.DeleteReviewer(ctx, "alice"))
+		assertNotFound(t, s.DeleteReviewer(ctx, "alice"))
+
+		_, err = s.GetReviewer(ctx, "alice")
+		assertNotFound(t, err)
+	})
+
+	t.Run("Get LRU Reviewer", func(t *testing.T) {
+		s := newStore(t)
+
+		requireNoError(t, s.AddReviewer(ctx, &types.Reviewer{Alias: "alice", LastReviewTime: base.Add(time.Hour)}))
+		requireNoError(t, s.AddReviewer(ctx, &types.Reviewer{Alias: "bob", LastReviewTime: base}))
+		requireNoError(t, s.AddReviewer(ctx, &types.Reviewer{Alias: "carol", LastReviewTime: base.Add(2 * time.Hour)}))
+
+		tests := []struct {
+			Name          string
+			Aliases       []string
+			ExpectedAlias string
+		}{
+			{Name: "Oldest Reviewer", Aliases: []string{"alice", "bob", "carol"}, ExpectedAlias: "bob"},
+			{Name: "Subset Of Reviewers", Aliases: []string{"alice", "carol"}, ExpectedAlias: "alice"},
+			{Name: "Unknown Aliases Ignored", Aliases: []string{"carol", "unknown"}, ExpectedAlias: "carol"},
+			{Name: "Only Unknown Aliases", Aliases: []string{"unknown"}},
+			{Name: "Empty Alias List", Aliases: []string{}},
+			{Name: "Nil Alias List"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.Name, func(t *testing.T) {
+				reviewer, err := s.GetLRUReviewer(ctx, tt.Aliases)
+				if tt.ExpectedAlias == "" {
+					assertNotFound(t, err)
+					return
+				}
+
+				requireNoError(t, err)
+				assert.Equal(t, tt.ExpectedAlias, reviewer.Alias, "Should get least recently used reviewer")
+			})
+		}
+
+		// Getting the reviewer should not change the rotation
+		reviewer, err := s.GetLRUReviewer(ctx, []string{"alice", "bob", "carol"})
+		requireNoError(t, err)
+		assert.Equal(t, "bob", reviewer.Alias, "Should not update the last review time")
+	})
+
+	t.Run("Get LRU Reviewer With Equal Timestamps", func(t *testing.T) {
+		s := newStore(t)
+
+		requireNoError(t, s.AddReviewer(ctx, &types.Reviewer{Alias: "carol", LastReviewTime: base}))
+		requireNoError(t, s.AddReviewer(ctx, &types.Reviewer{Alias: "alice", LastReviewTime: base}))
+		requireNoError(t, s.AddReviewer(ctx, &types.Reviewer{Alias: "bob", LastReviewTime: base}))
+
+		reviewer, err := s.GetLRUReviewer(ctx, []string{"bob", "carol", "alice"})
+		requireNoError(t, err)
+		assert.Equal(t, "alice", reviewer.Alias, "Should break ties by alias")
+	})
+
+	t.Run("Pop LRU Reviewer", func(t *testing.T) {
+		s := newStore(t)
+
+		requireNoError(t, s.AddReviewer(ctx, &types.Reviewer{Alias: "alice", LastReviewTime: base.Add(time.Hour)}))
+		requireNoError(t, s.AddReviewer(ctx, &types.Reviewer{Alias: "bob", LastReviewTime: base}))
+
+		before := time.Now().Add(-time.Second)
+
+		popped, err := s.PopLRUReviewer(ctx, []string{"alice", "bob"}, 1)
+		requireNoError(t, err)
+		assert.Equal(t, 1, len(popped), "Should pop a single reviewer")
+		first := popped[0]
+		assert.Equal(t, "bob", first.Alias, "Should pop least recently used reviewer")
+		assert.True(t, first.LastReviewTime.After(before), "Should return the updated last review time")
+
+		stored, err := s.GetReviewer(ctx, "bob")
+		requireNoError(t, err)
+		assert.True(t, stored.LastReviewTime.After(before), "Should store the updated last review time")
+
+		popped, err = s.PopLRUReviewer(ctx, []string{"alice", "bob"}, 1)
+		requireNoError(t, err)
+		assert.Equal(t, "alice", popped[0].Alias, "Should rotate to the next reviewer")
+
+		_, err = s.PopLRUReviewer(ctx, []string{}, 1)
+		assertNotFound(t, err)
+
+		_, err = s.PopLRUReviewer(ctx, []string{"unknown"}, 1)
+		assertNotFound(t, err)
+
+		popped, err = s.PopLRUReviewer(ctx, []string{"alice", "bob"}, 0)
+		requireNoError(t, err)
+		assert.Equal(t, 0, len(popped), "Should not pop any reviewers for a zero count")
+	})
+
+	t.Run("Unavailable Reviewers", func(t *testing.T) {
+		s := newStore(t)
+		now := time.Now().UTC()
+
+		requireNoError(t, s.AddReviewer(ctx, &types.Reviewer{Alias: "alice", LastReviewTime: base, Paused: true}))
+		requireNoError(t, s.AddReviewer(ctx, &types.Reviewer{Alias: "bob", LastReviewTime: base, Unavailable: []types.AvailabilityWindow{
+			{Start: now.Add(-time.Hour), End: now.Add(time.Hour)},
+		}}))
+		requireNoError(t, s.AddReviewer(ctx, &types.Reviewer{Alias: "carol", LastReviewTime: base.Add(time.Hour), Unavailable: []types.AvailabilityWindow{
+			{Start: now.Add(-48 * time.Hour), End: now.Add(-24 * time.Hour)},
+		}}))
+		requireNoError(t, s.AddReviewer(ctx, &types.Reviewer{Alias: "dave", LastReviewTime: base.Add(2 * time.Hour), WeeklyCapacity: 1}))
+		aliases := []string{"alice", "bob", "carol", "dave"}
+
+		reviewer, err := s.GetLRUReviewer(ctx, aliases)
+		requireNoError(t, err)
+		assert.Equal(t, "carol", reviewer.Alias, "Should skip paused and out of office reviewers")
+
+		popped, err := s.PopLRUReviewer(ctx, aliases, 4)
+		requireNoError(t, err)
+		assert.Equal(t, []string{"carol", "dave"}, reviewerAliases(popped), "Should only pop available reviewers")
+
+		stored, err := s.GetReviewer(ctx, "dave")
+		requireNoError(t, err)
+		assert.Equal(t, 1, stored.WeeklyReviews, "Should count the review against the weekly capacity")
+		assert.Equal(t, types.ReviewWeekOf(time.Now()), stored.ReviewWeek)
+
+		popped, err = s.PopLRUReviewer(ctx, aliases, 4)
+		requireNoError(t, err)
+		assert.Equal(t, []string{"carol"}, reviewerAliases(popped), "Should skip reviewers at their weekly capacity")
+	})
+
+	t.Run("Pop Multiple LRU Reviewers", func(t *testing.T) {
+		s := newStore(t)
+
+		requireNoError(t, s.AddReviewer(ctx, &types.Reviewer{Alias: "alice", LastReviewTime: base.Add(2 * time.Hour)}))
+		requireNoError(t, s.AddReviewer(ctx, &types.Reviewer{Alias: "bob", LastReviewTime: base}))
+		requireNoError(t, s.AddReviewer(ctx, &types.Reviewer{Alias: "carol", LastReviewTime: base.Add(time.Hour)}))
+
+		popped, err := s.PopLRUReviewer(ctx, []string{"alice", "bob", "carol"}, 2)
+		requireNoError(t, err)
+		assert.Equal(t, []string{"bob", "carol"}, reviewerAliases(popped), "Should pop distinct reviewers in LRU order")
+
+		popped, err = s.PopLRUReviewer(ctx, []string{"alice", "bob", "carol"}, 5)
+		requireNoError(t, err)
+		assert.Equal(t, []string{"alice", "bob", "carol"}, reviewerAliases(popped), "Should pop every matching reviewer when count exceeds the matches")
+	})
+
+	t.Run("Concurrent Pop LRU Reviewer", func(t *testing.T) {
+		s := newStore(t)
+
+		aliases := []string{"alice", "bob", "carol", "dave", "erin", "frank", "grace", "heidi"}
+		for _, alias := range aliases {
+			requireNoError(t, s.AddReviewer(ctx, &types.Reviewer{Alias: alias, LastReviewTime: base}))
+		}
+
+		// Each caller must get a different reviewer since every pop moves the reviewer to the back of the rotation
+		var wg sync.WaitGroup
+		popped := make(chan string, len(aliases))
+		errs := make(chan error, len(aliases))
+		for range aliases {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				reviewers, err := s.PopLRUReviewer(ctx, aliases, 1)
+				if err != nil {
+					errs <- err
+					return
+				}
+				popped <- reviewers[0].Alias
+			}()
+		}
+		wg.Wait()
+		close(popped)
+		close(errs)
+
+		for err := range errs {
+			requireNoError(t, err)
+		}
+
+		seen := map[string]bool{}
+		for alias := range popped {
+			assert.False(t, seen[alias], "Should not pop %q more than once", alias)
+			seen[alias] = true
+		}
+		assert.Len(t, seen, len(aliases), "Should pop every reviewer once")
+	})
+
+	t.Run("Concurrent Pop Multiple LRU Reviewers", func(t *testing.T) {
+		s := newStore(t)
+
+		aliases := []string{"alice", "bob", "carol", "dave", "erin", "frank", "grace", "heidi"}
+		for _, alias := range aliases {
+			requireNoError(t, s.AddReviewer(ctx, &types.Reviewer{Alias: alias, LastReviewTime: base}))
+		}
+
+		// Callers popping several reviewers at once must still never share a reviewer
+		const callers, count = 4, 2
+		var wg sync.WaitGroup
+		popped := make(chan []*types.Reviewer, callers)
+		errs := make(chan error, callers)
+		for i := 0; i < callers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				reviewers, err := s.PopLRUReviewer(ctx, aliases, count)
+				if err != nil {
+					errs <- err
+					return
+				}
+				popped <- reviewers
+			}()
+		}
+		wg.Wait()
+		close(popped)
+		close(errs)
+
+		for err := range errs {
+			requireNoError(t, err)
+		}
+
+		seen := map[string]bool{}
+		for reviewers := range popped {
+			assert.Len(t, reviewers, count, "Should pop the requested count")
+			for _, reviewer := range reviewers {
+				assert.False(t, seen[reviewer.Alias], "Should not pop %q more than once", reviewer.Alias)
+				seen[reviewer.Alias] = true
+			}
+		}
+		assert.Len(t, seen, len(aliases), "Should pop every reviewer once")
+	})
+
+	t.Run("Concurrent Pop Weekly Capacity", func(t *testing.T) {
+		s := newStore(t)
+
+		const capacity = 2
+		aliases := []string{"alice", "bob", "carol", "dave"}
+		for _, alias := range aliases {
+			requireNoError(t, s.AddReviewer(ctx, &types.Reviewer{Alias: alias, LastReviewTime: base, WeeklyCapacity: capacity}))
+		}
+
+		// Twice as many pops as the total capacity, the extra pops must find no available reviewers
+		callers := 2 * capacity * len(aliases)
+		var wg sync.WaitGroup
+		popped := make(chan string, callers)
+		errs := make(chan error, callers)
+		for i := 0; i < callers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				reviewers, err := s.PopLRUReviewer(ctx, aliases, 1)
+				if err != nil {
+					errs <- err
+					return
+				}
+				for _, reviewer := range reviewers {
+					popped <- reviewer.Alias
+				}
+			}()
+		}
+		wg.Wait()
+		close(popped)
+		close(errs)
+
+		for err := range errs {
+			if !errors.Is(err, store.ErrNotFound) {
+				requireNoError(t, err)
+			}
+		}
+
+		counts := map[string]int{}
+		for alias := range popped {
+			counts[alias]++
+		}
+
+		for _, alias := range aliases {
+			assert.Equal(t, capacity, counts[alias], "Should pop %q up to their weekly capacity", alias)
+
+			stored, err := s.GetReviewer(ctx, alias)
+			requireNoError(t, err)
+			assert.Equal(t, capacity, stored.WeeklyReviews, "Should not count reviews past the weekly capacity for %q", alias)
+		}
+	})
+}
+
+// TestTeamStore runs the team store contract tests
+func TestTeamStore(t *testing.T, newStore TeamStoreFactory) {
+	ctx := context.Background()
+
+	t.Run("Add and Get", func(t *testing.T) {
+		s := newStore(t)
+
+		team := &types.Team{Name: "Team A", Members: []string{"alice", "bob"}}
+		requireNoError(t, s.AddTeam(ctx, team))
+		assert.True(t, team.ID.Valid(), "Should assign an id")
+
+		stored, err := s.GetTeam(ctx, "Team A")
+		requireNoError(t, err)
+		assert.Equal(t, team.ID, stored.ID, "Should get team by name")
+		assert.Equal(t, []string{"alice", "bob"}, stored.Members, "Should store members")
+
+		_, err = s.GetTeam(ctx, "missing")
+		assertNotFound(t, err)
+	})
+
+	t.Run("Duplicate", func(t *testing.T) {
+		s := newStore(t)
+
+		requireNoError(t, s.AddTeam(ctx, &types.Team{Name: "Team A"}))
+		assertAlreadyExists(t, s.AddTeam(ctx, &types.Team{Name: "Team A"}))
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		s := newStore(t)
+
+		team := &types.Team{Name: "Team A", Members: []string{"alice"}}
+		requireNoError(t, s.AddTeam(ctx, team))
+
+		team.Members = []string{"bob"}
+		requireNoError(t, s.UpdateTeam(ctx, team))
+
+		stored, err := s.GetTeam(ctx, "Team A")
+		requireNoError(t, err)
+		assert.Equal(t, []string{"bob"}, stored.Members, "Should update members")
+
+		assertNotFound(t, s.UpdateTeam(ctx, &types.Team{ID: bson.NewObjectId(), Name: "missing"}))
+	})
+
+	t.Run("Delete and Get All", func(t *testing.T) {
+		s := newStore(t)
+
+		teams, err := s.GetAllTeams(ctx)
+		requireNoError(t, err)
+		assert.NotNil(t, teams, "Should return an empty slice")
+		assert.Len(t, teams, 0, "Should have no teams")
+
+		requireNoError(t, s.AddTeam(ctx, &types.Team{Name: "Team B"}))
+		requireNoError(t, s.AddTeam(ctx, &types.Team{Name: "Team A"}))
+
+		teams, err = s.GetAllTeams(ctx)
+		requireNoError(t, err)
+		assert.Len(t, teams, 2, "Should get all teams")
+		assert.Equal(t, "Team A", teams[0].Name, "Should sort teams by name")
+
+		requireNoError(t, s.DeleteTeam(ctx, "Team A"))
+		assertNotFound(t, s.DeleteTeam(ctx, "Team A"))
+
+		_, err = s.GetTeam(ctx, "Team A")
+		assertNotFound(t, err)
+	})
+}
+
+// TestAssignmentStore runs the assignment store contract tests
+func TestAssignmentStore(t *testing.T, newStore AssignmentStoreFactory) {
+	ctx := context.Background()
+
+	t.Run("Add and Get", func(t *testing.T) {
+		s := newStore(t)
+
+		assignment := &types.Assignment{
+			RepositoryID:      "repo-id",
+			PullRequestID:     1,
+			RequiredReviewers: []string{"alice", "bob"},
+			OptionalReviewers: []string{"carol"},
+			Reason:            "test",
+			Created:           time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+		}
+		requireNoError(t, s.AddAssignment(ctx, assignment))
+		assert.True(t, assignment.ID.Valid(), "Should assign an id")
+
+		stored, err := s.GetAssignment(ctx, "repo-id", 1)
+		requireNoError(t, err)
+		assert.Equal(t, []string{"alice", "bob"}, stored.RequiredReviewers, "Should store required reviewers")
+		assert.Equal(t, []string{"carol"}, stored.OptionalReviewers, "Should store optional reviewers")
+		assert.Equal(t, "test", stored.Reason, "Should store reason")
+
+		_, err = s.GetAssignment(ctx, "repo-id", 2)
+		assertNotFound(t, err)
+
+		_, err = s.GetAssignment(ctx, "other-repo-id", 1)
+		assertNotFound(t, err)
+	})
+
+	t.Run("Duplicate", func(t *testing.T) {
+		s := newStore(t)
+
+		requireNoError(t, s.AddAssignment(ctx, &types.Assignment{RepositoryID: "repo-id", PullRequestID: 1}))
+		assertAlreadyExists(t, s.AddAssignment(ctx, &types.Assignment{RepositoryID: "repo-id", PullRequestID: 1}))
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		s := newStore(t)
+
+		requireNoError(t, s.AddAssignment(ctx, &types.Assignment{RepositoryID: "repo-id", PullRequestID: 1, DryRun: true}))
+
+		stored, err := s.GetAssignment(ctx, "repo-id", 1)
+		requireNoError(t, err)
+		assert.True(t, stored.DryRun, "Should store dry run")
+
+		requireNoError(t, s.DeleteAssignment(ctx, "repo-id", 1))
+
+		_, err = s.GetAssignment(ctx, "repo-id", 1)
+		assertNotFound(t, err)
+
+		assertNotFound(t, s.DeleteAssignment(ctx, "repo-id", 1))
+
+		requireNoError(t, s.AddAssignment(ctx, &types.Assignment{RepositoryID: "repo-id", PullRequestID: 1}))
+	})
+
+	t.Run("Claim and Update", func(t *testing.T) {
+		s := newStore(t)
+		now := time.Now().UTC()
+		staleBefore := now.Add(-time.Hour)
+
+		claim := &types.Assignment{RepositoryID: "repo-id", PullRequestID: 1, Pending: true, Created: now}
+		requireNoError(t, s.ClaimAssignment(ctx, claim, staleBefore))
+		assert.True(t, claim.ID.Valid(), "Should assign an id")
+
+		assertAlreadyExists(t, s.ClaimAssignment(ctx, &types.Assignment{RepositoryID: "repo-id", PullRequestID: 1, Pending: true, Created: now}, staleBefore))
+
+		claim.Pending = false
+		claim.RequiredReviewers = []string{"alice"}
+		requireNoError(t, s.UpdateAssignment(ctx, claim))
+
+		stored, err := s.GetAssignment(ctx, "repo-id", 1)
+		requireNoError(t, err)
+		assert.False(t, stored.Pending, "Should update pending")
+		assert.Equal(t, []string{"alice"}, stored.RequiredReviewers, "Should update required reviewers")
+
+		// Completed assignments are never replaced, no matter how old they are
+		assertAlreadyExists(t, s.ClaimAssignment(ctx, &types.Assignment{RepositoryID: "repo-id", PullRequestID: 1, Pending: true, Created: now}, now.Add(time.Hour)))
+
+		assertNotFound(t, s.UpdateAssignment(ctx, &types.Assignment{ID: bson.NewObjectId(), RepositoryID: "repo-id", PullRequestID: 1}))
+		assertNotFound(t, s.UpdateAssignment(ctx, &types.Assignment{ID: bson.NewObjectId(), RepositoryID: "repo-id", PullRequestID: 2}))
+	})
+
+	t.Run("Claim Abandoned", func(t *testing.T) {
+		s := newStore(t)
+		now := time.Now().UTC()
+
+		abandoned := &types.Assignment{RepositoryID: "repo-id", PullRequestID: 1, Pending: true, Created: now.Add(-2 * time.Hour)}
+		requireNoError(t, s.ClaimAssignment(ctx, abandoned, now.Add(-time.Hour)))
+
+		claim := &types.Assignment{RepositoryID: "repo-id", PullRequestID: 1, Pending: true, Reason: "retry", Created: now}
+		requireNoError(t, s.ClaimAssignment(ctx, claim, now.Add(-time.Hour)))
+
+		stored, err := s.GetAssignment(ctx, "repo-id", 1)
+		requireNoError(t, err)
+		assert.Equal(t, "retry", stored.Reason, "Should replace the abandoned claim")
+		assert.Equal(t, claim.ID, stored.ID, "Should return the stored id")
+	})
+
+	t.Run("Concurrent Claim", func(t *testing.T) {
+		s := newStore(t)
+		now := time.Now().UTC()
+
+		// Exactly one caller wins the claim for a pull request
+		const callers = 8
+		var wg sync.WaitGroup
+		results := make(chan error, callers)
+		for i := 0; i < callers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				results <- s.ClaimAssignment(ctx, &types.Assignment{RepositoryID: "repo-id", PullRequestID: 1, Pending: true, Created: now}, now.Add(-time.Hour))
+			}()
+		}
+		wg.Wait()
+		close(results)
+
+		won := 0
+		for err := range results {
+			if err == nil {
+				won++
+				continue
+			}
+			assertAlreadyExists(t, err)
+		}
+		assert.Equal(t, 1, won, "Should only let one caller claim the pull request")
+	})
+}
+
+func requireNoError(t *testing.T, err error) {
+	t.Helper()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+}
+
+func assertNotFound(t *testing.T, err error) {
+	t.Helper()
+	assert.True(t, errors.Is(err, store.ErrNotFound), "Should return ErrNotFound, got: %v", err)
+}
+
+func assertAlreadyExists(t *testing.T, err error) {
+	t.Helper()
+	assert.True(t, errors.Is(err, store.ErrAlreadyExists), "Should return ErrAlreadyExists, got: %v", err)
+}
+
+func reviewerAliases(reviewers []*types.Reviewer) []string {
+	aliases := make([]string, len(reviewers))
+	for i, reviewer := range reviewers {
+		aliases[i] = reviewer.Alias
+	}
+	return aliases
+}
@@ -1,6 +1,7 @@
 package types
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/globalsign/mgo/bson"
@@ -30,19 +31,200 @@ type Repository struct {
 	Name           string         `json:"name" bson:"name,omitempty"`
 	ProjectName    string         `json:"projectName" bson:"projectName,omitempty"`
 	Enabled        bool           `json:"enabled" bson:"enabled,omitempty"`
-	AdoRepoID      string         `json:"AdoRepoID" bson: "AdoRepoID,omitempty"`
+	AdoRepoID      string         `json:"AdoRepoID" bson:"adoRepoId,omitempty"`
+	Owners         []string       `json:"owners" bson:"owners,omitempty"`
+	ReviewerCounts *ReviewerCounts `json:"reviewerCounts,omitempty" bson:"reviewerCounts,omitempty"`
+	SelectionStrategy string      `json:"selectionStrategy,omitempty" bson:"selectionStrategy,omitempty"`
+	SelectionSeed  int64          `json:"selectionSeed,omitempty" bson:"selectionSeed,omitempty"`
+	ExpertiseWeight float64       `json:"expertiseWeight,omitempty" bson:"expertiseWeight,omitempty"`
+	OwnersFormat   string         `json:"ownersFormat,omitempty" bson:"ownersFormat,omitempty"`
+	NoNotifyMode   string         `json:"noNotifyMode,omitempty" bson:"noNotifyMode,omitempty"`
+	// DryRun selects and records reviewers without adding them to pull requests or changing the rotation
+	DryRun         bool           `json:"dryRun,omitempty" bson:"dryRun,omitempty"`
+	// DryRunPreviewComment posts a single comment with the would be reviewers when in dry run mode
+	DryRunPreviewComment bool     `json:"dryRunPreviewComment,omitempty" bson:"dryRunPreviewComment,omitempty"`
+	// CommentTemplate is the text/template for the reviewer comment, the default comment is used when empty
+	CommentTemplate string        `json:"commentTemplate,omitempty" bson:"commentTemplate,omitempty"`
+	// Filters selects the pull requests that are balanced, the default filters are used when not set
+	Filters        *PullRequestFilters `json:"filters,omitempty" bson:"filters,omitempty"`
 	LastReconciled time.Time
 }
 
+const (
+	// DefaultRequiredOwners is the number of required reviewers drawn from the owners when not configured
+	DefaultRequiredOwners = 1
+	// DefaultRequiredTeamMembers is the number of required reviewers drawn from the teams when not configured
+	DefaultRequiredTeamMembers = 1
+	// DefaultOptionalReviewers is the number of optional reviewers added when not configured
+	DefaultOptionalReviewers = 0
+)
+
+// Selection strategies that can be configured for a repository
+const (
+	SelectionStrategyLRU                = "lru"
+	SelectionStrategyWeightedRoundRobin = "weighted-round-robin"
+	SelectionStrategyRandom             = "random"
+	SelectionStrategyLeastOpenReviews   = "least-open-reviews"
+)
+
+// Owners file formats that can be configured for a repository
+const (
+	// OwnersFormatOwnersTxt is an owners.txt file per directory, the closest file to a change applies
+	OwnersFormatOwnersTxt = "owners.txt"
+	// OwnersFormatCodeOwners is a single CODEOWNERS file with path patterns, the last matching pattern applies
+	OwnersFormatCodeOwners = "codeowners"
+)
+
+// OwnersFormats are the known owners file formats, an empty format defaults to owners.txt
+var OwnersFormats = []string{
+	OwnersFormatOwnersTxt,
+	OwnersFormatCodeOwners,
+}
+
+// Modes for the owners listed with the no notify prefix
+const (
+	// NoNotifyModeExclude never adds no notify owners to pull requests
+	NoNotifyModeExclude = "exclude"
+	// NoNotifyModeOptional adds no notify owners as optional reviewers without notifying them
+	NoNotifyModeOptional = "optional"
+)
+
+// NoNotifyModes are the known no notify modes, an empty mode defaults to exclude
+var NoNotifyModes = []string{
+	NoNotifyModeExclude,
+	NoNotifyModeOptional,
+}
+
+// SelectionStrategies are the known selection strategies, an empty strategy defaults to lru
+var SelectionStrategies = []string{
+	SelectionStrategyLRU,
+	SelectionStrategyWeightedRoundRobin,
+	SelectionStrategyRandom,
+	SelectionStrategyLeastOpenReviews,
+}
+
+// ReviewerCounts configures how many reviewers are added to each pull request
+type ReviewerCounts struct {
+	RequiredOwners      int `json:"requiredOwners" bson:"requiredOwners"`
+	RequiredTeamMembers int `json:"requiredTeamMembers" bson:"requiredTeamMembers"`
+	Optional            int `json:"optional" bson:"optional"`
+}
+
+// PullRequestFilters configures which pull requests are balanced for a repository. Every configured rule
+// must pass for a pull request to be balanced, empty rules always pass.
+type PullRequestFilters struct {
+	// TargetBranches are glob patterns matched against the target branch without refs/heads/, ex: release/*
+	TargetBranches  []string `json:"targetBranches,omitempty" bson:"targetBranches,omitempty"`
+	// IncludeTitles are regular expressions, the title must match at least one
+	IncludeTitles   []string `json:"includeTitles,omitempty" bson:"includeTitles,omitempty"`
+	// ExcludeTitles are regular expressions, the title must not match any
+	ExcludeTitles   []string `json:"excludeTitles,omitempty" bson:"excludeTitles,omitempty"`
+	// AllowAuthors are the aliases whose pull requests are balanced
+	AllowAuthors    []string `json:"allowAuthors,omitempty" bson:"allowAuthors,omitempty"`
+	// DenyAuthors are the aliases whose pull requests are never balanced
+	DenyAuthors     []string `json:"denyAuthors,omitempty" bson:"denyAuthors,omitempty"`
+	// RequireLabels are the labels the pull request must have at least one of
+	RequireLabels   []string `json:"requireLabels,omitempty" bson:"requireLabels,omitempty"`
+	// ExcludeLabels are the labels the pull request must not have
+	ExcludeLabels   []string `json:"excludeLabels,omitempty" bson:"excludeLabels,omitempty"`
+	// MinChangedFiles is the minimum number of changed files across all iterations
+	MinChangedFiles int      `json:"minChangedFiles,omitempty" bson:"minChangedFiles,omitempty"`
+	// IncludeDrafts balances draft pull requests
+	IncludeDrafts   bool     `json:"includeDrafts,omitempty" bson:"includeDrafts,omitempty"`
+}
+
+// GetReviewerCounts returns the configured reviewer counts or the defaults when they aren't set
+func (r *Repository) GetReviewerCounts() ReviewerCounts {
+	if r.ReviewerCounts == nil {
+		return ReviewerCounts{
+			RequiredOwners:      DefaultRequiredOwners,
+			RequiredTeamMembers: DefaultRequiredTeamMembers,
+			Optional:            DefaultOptionalReviewers,
+		}
+	}
+	return *r.ReviewerCounts
+}
+
 type Reviewer struct {
 	Alias          string        `json:"alias" bson:"alias,omitempty"`
 	AdoID          string        `json:"adoId" bson:"id,omitempty"`
 	ID             bson.ObjectId `json:"id" bson:"_id,omitempty"`
+	Weight         int           `json:"weight,omitempty" bson:"weight,omitempty"`
+	MaxOpenReviews int           `json:"maxOpenReviews,omitempty" bson:"maxOpenReviews,omitempty"`
+	Paused         bool          `json:"paused,omitempty" bson:"paused,omitempty"`
+	Unavailable    []AvailabilityWindow `json:"unavailable,omitempty" bson:"unavailable,omitempty"`
+	WeeklyCapacity int           `json:"weeklyCapacity,omitempty" bson:"weeklyCapacity,omitempty"`
+	WeeklyReviews  int           `json:"weeklyReviews,omitempty" bson:"weeklyReviews,omitempty"`
+	ReviewWeek     string        `json:"reviewWeek,omitempty" bson:"reviewWeek,omitempty"`
+	Reviews        int           `json:"reviews,omitempty" bson:"reviews,omitempty"`
 	LastReviewTime time.Time
 }
 
+// AvailabilityWindow is a period when a reviewer can't take reviews, the end is exclusive
+type AvailabilityWindow struct {
+	Start  time.Time `json:"start" bson:"start"`
+	End    time.Time `json:"end" bson:"end"`
+	Reason string    `json:"reason,omitempty" bson:"reason,omitempty"`
+	Source string    `json:"source,omitempty" bson:"source,omitempty"`
+}
+
+// ReviewWeekOf returns the ISO week used to count weekly reviews, ex: 2020-W01
+func ReviewWeekOf(t time.Time) string {
+	year, week := t.UTC().ISOWeek()
+	return fmt.Sprintf("%d-W%02d", year, week)
+}
+
+// Available checks if the reviewer can take a review at the time
+func (r *Reviewer) Available(now time.Time) bool {
+	if r.Paused {
+		return false
+	}
+
+	for _, window := range r.Unavailable {
+		if !now.Before(window.Start) && now.Before(window.End) {
+			return false
+		}
+	}
+
+	if r.WeeklyCapacity > 0 && r.ReviewWeek == ReviewWeekOf(now) && r.WeeklyReviews >= r.WeeklyCapacity {
+		return false
+	}
+
+	return true
+}
+
+// RecordReview marks the reviewer as just reviewed and counts the review against their weekly capacity
+func (r *Reviewer) RecordReview(now time.Time) {
+	week := ReviewWeekOf(now)
+	if r.ReviewWeek != week {
+		r.ReviewWeek = week
+		r.WeeklyReviews = 0
+	}
+
+	r.WeeklyReviews++
+	r.Reviews++
+	r.LastReviewTime = now
+}
+
 type Team struct {
 	ID      bson.ObjectId  `json:"id,omitempty" bson:"_id,omitempty"`
 	Name    string   `json:"name" bson:"name,omitempty"`
 	Members []string `json:"members" bson:"members"`
 }
+
+// Assignment records the reviewers assigned to a pull request
+type Assignment struct {
+	ID                bson.ObjectId `json:"id,omitempty" bson:"_id,omitempty"`
+	RepositoryID      string        `json:"repositoryId" bson:"repositoryId"`
+	RepositoryName    string        `json:"repositoryName" bson:"repositoryName,omitempty"`
+	ProjectName       string        `json:"projectName" bson:"projectName,omitempty"`
+	PullRequestID     int           `json:"pullRequestId" bson:"pullRequestId"`
+	RequiredReviewers []string      `json:"requiredReviewers" bson:"requiredReviewers"`
+	OptionalReviewers []string      `json:"optionalReviewers" bson:"optionalReviewers"`
+	Reason            string        `json:"reason" bson:"reason,omitempty"`
+	// DryRun is set for the would be assignments recorded in dry run mode, no reviewers were added
+	DryRun            bool          `json:"dryRun,omitempty" bson:"dryRun,omitempty"`
+	// Pending is set while the pull request is claimed and its reviewers are being added
+	Pending           bool          `json:"pending,omitempty" bson:"pending,omitempty"`
+	Created           time.Time     `json:"created" bson:"created"`
+}
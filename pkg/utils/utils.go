@@ -2,6 +2,7 @@ package utils
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -13,6 +14,9 @@ import (
 
 var (
 	DirectoryAliasIdentitySearchFilter = "DirectoryAlias"
+
+	// ErrIdentityNotFound is returned when no identity matches the alias
+	ErrIdentityNotFound = errors.New("no ado identities found")
 )
 
 
@@ -41,7 +45,7 @@ func GetDevOpsIdentity(ctx context.Context, alias string, adoIdentityClient adoI
 	}
 
 	if len(*identities) == 0 {
-		return nil, fmt.Errorf("no ado identities found for alias: %s", alias)
+		return nil, fmt.Errorf("%w for alias: %s", ErrIdentityNotFound, alias)
 	}
 
 	if len(*identities) == 1 {
@@ -58,7 +62,7 @@ func GetDevOpsIdentity(ctx context.Context, alias string, adoIdentityClient adoI
 		}
 	}
 
-	return nil, fmt.Errorf("no ado identities found for alias: %s", alias)
+	return nil, fmt.Errorf("%w for alias: %s", ErrIdentityNotFound, alias)
 }
 
 func GetIdentityAlias(identity adoIdentity.Identity) (string, error) {